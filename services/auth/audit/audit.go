@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"go.uber.org/zap"
+)
+
+// Recorder writes authentication events to the audit trail in Postgres and to the log.
+type Recorder struct {
+	repo   repository.AuthEventRepository
+	logger *zap.Logger
+}
+
+// NewRecorder creates a Recorder that stores events through repo and logs them to logger.
+func NewRecorder(repo repository.AuthEventRepository, logger *zap.Logger) *Recorder {
+	return &Recorder{repo: repo, logger: logger}
+}
+
+// Record logs the event and stores it. Storing outlives the caller's context, so events of
+// requests the client gave up on are kept too. A failure to store is logged rather than returned:
+// the audit trail must not turn a login into an error.
+func (r *Recorder) Record(ctx context.Context, event *entities.AuthEvent) {
+	fields := []zap.Field{
+		zap.String("type", event.Type),
+		zap.String("outcome", event.Outcome),
+		zap.String("actor", event.Actor),
+		zap.String("impersonator", event.Impersonator),
+		zap.String("email", event.Email),
+		zap.String("ip_address", event.IPAddress),
+		zap.String("user_agent", event.UserAgent),
+		zap.String("session_id", event.SessionID),
+		zap.String("detail", event.Detail),
+	}
+	if event.UserID != nil {
+		fields = append(fields, zap.Uint("user_id", *event.UserID))
+	}
+
+	if event.Outcome == entities.AuthEventFailure {
+		r.logger.Warn("Auth event", fields...)
+	} else {
+		r.logger.Info("Auth event", fields...)
+	}
+
+	if err := r.repo.Create(context.WithoutCancel(ctx), event); err != nil {
+		r.logger.Error("Failed to store auth event", append(fields, zap.Error(err))...)
+	}
+}
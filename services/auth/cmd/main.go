@@ -5,16 +5,33 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	identitypb "github.com/himakhaitan/noreboothq/proto/identity"
+	"github.com/himakhaitan/noreboothq/services/auth/audit"
 	"github.com/himakhaitan/noreboothq/services/auth/config"
+	"github.com/himakhaitan/noreboothq/services/auth/controllers"
 	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/handlers"
+	"github.com/himakhaitan/noreboothq/services/auth/jobs"
+	"github.com/himakhaitan/noreboothq/services/auth/mfa"
+	"github.com/himakhaitan/noreboothq/services/auth/oidc"
+	"github.com/himakhaitan/noreboothq/services/auth/organizations"
+	"github.com/himakhaitan/noreboothq/services/auth/password"
 	"github.com/himakhaitan/noreboothq/services/auth/repository"
 	"github.com/himakhaitan/noreboothq/services/auth/server"
+	"github.com/himakhaitan/noreboothq/services/auth/tokens"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+	"github.com/himakhaitan/noreboothq/shared/cache"
 	sharedConfig "github.com/himakhaitan/noreboothq/shared/config"
 	sharedDB "github.com/himakhaitan/noreboothq/shared/db"
 	"github.com/himakhaitan/noreboothq/shared/env"
 	sharedLogger "github.com/himakhaitan/noreboothq/shared/logger"
+	"github.com/himakhaitan/noreboothq/shared/mailer"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -44,8 +61,7 @@ func main() {
 	}
 	defer sharedLogger.Sync() // flushes logs on exit
 
-	// Initialize database connection with User model for migration
-	// You can add more models as needed
+	// Initialize database connection
 	db, err := sharedDB.NewConnection(sharedDB.Config{
 		Host:     cfg.DB.Host,
 		Port:     cfg.DB.Port,
@@ -53,13 +69,139 @@ func main() {
 		Password: cfg.DB.Password,
 		DBName:   cfg.DB.DBName,
 		SSLMode:  cfg.DB.SSLMode,
-	}, sharedLogger.Logger(), &entities.User{})
+	}, sharedLogger.Logger())
 	if err != nil {
 		sharedLogger.Logger().Fatal("Failed to connect to database", zap.Error(err))
 	}
 
+	// Users created before email verification existed are marked verified before the column is
+	// migrated in; otherwise they would all lose their scopes to the unverified_login policy
+	if err := backfillEmailVerified(db); err != nil {
+		sharedLogger.Logger().Fatal("Failed to backfill email verification", zap.Error(err))
+	}
+
+	// Run migrations for the auth models
+	// You can add more models as needed
+	if err := db.AutoMigrate(
+		&entities.User{},
+		&entities.RefreshToken{},
+		&entities.RevokedToken{},
+		&entities.LoginThrottle{},
+		&entities.RecoveryCode{},
+		&entities.MFAChallenge{},
+		&entities.PasswordResetToken{},
+		&entities.EmailVerificationToken{},
+		&entities.APIKey{},
+		&entities.ServiceAccount{},
+		&entities.ExternalIdentity{},
+		&entities.OIDCLogin{},
+		&entities.DeviceAuthorization{},
+		&entities.Session{},
+		&entities.AuthEvent{},
+	); err != nil {
+		sharedLogger.Logger().Fatal("Failed to run migrations", zap.Error(err))
+	}
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	revokedTokenRepo := repository.NewRevokedTokenRepository(db)
+	loginThrottleRepo := repository.NewLoginThrottleRepository(db)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
+	mfaChallengeRepo := repository.NewMFAChallengeRepository(db)
+	passwordResetTokenRepo := repository.NewPasswordResetTokenRepository(db)
+	emailVerificationTokenRepo := repository.NewEmailVerificationTokenRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	serviceAccountRepo := repository.NewServiceAccountRepository(db)
+	externalIdentityRepo := repository.NewExternalIdentityRepository(db)
+	oidcLoginRepo := repository.NewOIDCLoginRepository(db)
+	deviceAuthorizationRepo := repository.NewDeviceAuthorizationRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	authEventRepo := repository.NewAuthEventRepository(db)
+
+	// Load the signing keys and initialize the token manager used to sign access tokens
+	keySet, err := tokens.LoadKeySet(cfg.JWT.KeysDir, cfg.JWT.GenerateKeyIfMissing)
+	if err != nil {
+		sharedLogger.Logger().Fatal("Failed to load signing keys", zap.Error(err))
+	}
+	sharedLogger.Logger().Info("Loaded signing keys", zap.String("active_kid", keySet.ActiveKeyID()))
+
+	tokenManager, err := tokens.NewManager(cfg.JWT, keySet)
+	if err != nil {
+		sharedLogger.Logger().Fatal("Failed to initialize token manager", zap.Error(err))
+	}
+
+	// Initialize the password policy applied to new passwords
+	passwordPolicy, err := password.NewPolicy(cfg.PasswordPolicy)
+	if err != nil {
+		sharedLogger.Logger().Fatal("Failed to initialize password policy", zap.Error(err))
+	}
+
+	// Initialize the hasher for new passwords; stored hashes made with older settings are upgraded on login
+	passwordHasher, err := password.NewHasher(cfg.PasswordHashing)
+	if err != nil {
+		sharedLogger.Logger().Fatal("Failed to initialize password hasher", zap.Error(err))
+	}
+
+	// Initialize the TOTP manager, loading the key that encrypts TOTP secrets at rest
+	mfaManager, err := mfa.NewManager(cfg.MFA)
+	if err != nil {
+		sharedLogger.Logger().Fatal("Failed to initialize MFA", zap.Error(err))
+	}
+
+	// Initialize the mailer used for password reset and other account emails
+	mail, err := mailer.New(mailer.Config{
+		Driver:       cfg.Mail.Driver,
+		From:         cfg.Mail.From,
+		SMTPHost:     cfg.Mail.SMTP.Host,
+		SMTPPort:     cfg.Mail.SMTP.Port,
+		SMTPUsername: cfg.Mail.SMTP.Username,
+		SMTPPassword: cfg.Mail.SMTP.Password,
+		FilePath:     cfg.Mail.FilePath,
+	})
+	if err != nil {
+		sharedLogger.Logger().Fatal("Failed to initialize mailer", zap.Error(err))
+	}
+
+	// Initialize the external identity providers users can sign in with
+	oidcProviders, err := oidc.NewProviders(cfg.OIDC)
+	if err != nil {
+		sharedLogger.Logger().Fatal("Failed to initialize OIDC providers", zap.Error(err))
+	}
+
+	// Reject a mistyped policy for unverified emails rather than silently refusing every such login
+	switch cfg.EmailVerification.UnverifiedLogin {
+	case controllers.UnverifiedLoginRestrict, controllers.UnverifiedLoginDeny:
+	default:
+		sharedLogger.Logger().Fatal("Invalid email_verification unverified_login",
+			zap.String("unverified_login", cfg.EmailVerification.UnverifiedLogin))
+	}
+	if cfg.Impersonation.TokenTTL <= 0 {
+		sharedLogger.Logger().Fatal("impersonation token_ttl must be positive")
+	}
+	if cfg.TokenExchange.MaxTTL <= 0 {
+		sharedLogger.Logger().Fatal("token_exchange max_ttl must be positive")
+	}
+	if cfg.DeviceAuthorization.CodeTTL <= 0 || cfg.DeviceAuthorization.PollInterval < time.Second {
+		sharedLogger.Logger().Fatal("device_authorization code_ttl must be positive and poll_interval at least 1s")
+	}
+
+	// Connect to the identity service, if configured, which answers whether users belong to the
+	// organizations their sessions switch to. Traffic between services stays on the internal network.
+	var organizationDirectory controllers.OrganizationDirectory
+	if cfg.Identity.Address != "" {
+		if cfg.Identity.Audience == "" {
+			sharedLogger.Logger().Fatal("identity audience is required when an identity address is set")
+		}
+		identityConn, err := grpc.NewClient(cfg.Identity.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			sharedLogger.Logger().Fatal("Failed to create identity service client", zap.Error(err))
+		}
+		defer identityConn.Close()
+		organizationDirectory = organizations.NewDirectory(identitypb.NewIdentityServiceClient(identityConn), tokenManager, cfg.Identity.Audience)
+	} else {
+		sharedLogger.Logger().Info("No identity service configured; organization switching is disabled")
+	}
 
 	sharedLogger.Logger().Info("Auth Service Started")
 
@@ -67,9 +209,181 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	// Start the gRPC server
-	grpcServer := server.NewGRPCServer(sharedLogger.Logger(), &userRepo, cfg.Server.Port)
+	// Periodically prune revocation entries for tokens that have expired anyway
+	go jobs.Run(ctx, sharedLogger.Logger(), "prune-revoked-tokens", cfg.JWT.RevocationPruneInterval, func(ctx context.Context) error {
+		pruned, err := revokedTokenRepo.DeleteExpired(ctx, time.Now())
+		if err == nil && pruned > 0 {
+			sharedLogger.Logger().Info("Pruned expired revoked tokens", zap.Int64("count", pruned))
+		}
+		return err
+	})
+
+	// Periodically drop sessions that were revoked or whose refresh tokens have all expired
+	go jobs.Run(ctx, sharedLogger.Logger(), "prune-sessions", cfg.JWT.RevocationPruneInterval, func(ctx context.Context) error {
+		pruned, err := sessionRepo.DeleteStale(ctx, time.Now().Add(-cfg.JWT.RefreshTokenTTL))
+		if err == nil && pruned > 0 {
+			sharedLogger.Logger().Info("Pruned stale sessions", zap.Int64("count", pruned))
+		}
+		return err
+	})
+
+	// Periodically drop failed login counters that no longer affect any lockout
+	go jobs.Run(ctx, sharedLogger.Logger(), "prune-login-throttles", cfg.Lockout.FailureWindow, func(ctx context.Context) error {
+		pruned, err := loginThrottleRepo.DeleteStale(ctx, time.Now().Add(-cfg.Lockout.FailureWindow))
+		if err == nil && pruned > 0 {
+			sharedLogger.Logger().Info("Pruned stale login throttles", zap.Int64("count", pruned))
+		}
+		return err
+	})
+
+	// Periodically drop MFA challenges that can no longer be completed
+	go jobs.Run(ctx, sharedLogger.Logger(), "prune-mfa-challenges", cfg.MFA.ChallengeTTL, func(ctx context.Context) error {
+		pruned, err := mfaChallengeRepo.DeleteExpired(ctx, time.Now())
+		if err == nil && pruned > 0 {
+			sharedLogger.Logger().Info("Pruned expired MFA challenges", zap.Int64("count", pruned))
+		}
+		return err
+	})
+
+	// Periodically drop email verification tokens that can no longer be redeemed
+	go jobs.Run(ctx, sharedLogger.Logger(), "prune-email-verification-tokens", cfg.EmailVerification.TokenTTL, func(ctx context.Context) error {
+		pruned, err := emailVerificationTokenRepo.DeleteExpired(ctx, time.Now())
+		if err == nil && pruned > 0 {
+			sharedLogger.Logger().Info("Pruned expired email verification tokens", zap.Int64("count", pruned))
+		}
+		return err
+	})
+
+	// Periodically drop password reset tokens that can no longer be redeemed
+	go jobs.Run(ctx, sharedLogger.Logger(), "prune-password-reset-tokens", cfg.PasswordReset.TokenTTL, func(ctx context.Context) error {
+		pruned, err := passwordResetTokenRepo.DeleteExpired(ctx, time.Now())
+		if err == nil && pruned > 0 {
+			sharedLogger.Logger().Info("Pruned expired password reset tokens", zap.Int64("count", pruned))
+		}
+		return err
+	})
+
+	// Periodically drop OIDC logins the identity provider never redirected back from
+	go jobs.Run(ctx, sharedLogger.Logger(), "prune-oidc-logins", cfg.OIDC.StateTTL, func(ctx context.Context) error {
+		pruned, err := oidcLoginRepo.DeleteExpired(ctx, time.Now())
+		if err == nil && pruned > 0 {
+			sharedLogger.Logger().Info("Pruned expired OIDC logins", zap.Int64("count", pruned))
+		}
+		return err
+	})
+
+	// Periodically drop device authorizations that can no longer be approved or redeemed
+	go jobs.Run(ctx, sharedLogger.Logger(), "prune-device-authorizations", cfg.DeviceAuthorization.CodeTTL, func(ctx context.Context) error {
+		pruned, err := deviceAuthorizationRepo.DeleteExpired(ctx, time.Now())
+		if err == nil && pruned > 0 {
+			sharedLogger.Logger().Info("Pruned expired device authorizations", zap.Int64("count", pruned))
+		}
+		return err
+	})
+
+	// Periodically re-read the signing keys so they can be rotated without a restart
+	go jobs.Run(ctx, sharedLogger.Logger(), "reload-signing-keys", cfg.JWT.KeyReloadInterval, func(ctx context.Context) error {
+		return keySet.Reload()
+	})
+
+	// Initialize the controller shared by the gRPC and HTTP servers
+	authCtrl := controllers.NewAuthController(controllers.Repositories{
+		Users:                   userRepo,
+		RefreshTokens:           refreshTokenRepo,
+		RevokedTokens:           revokedTokenRepo,
+		LoginThrottles:          loginThrottleRepo,
+		RecoveryCodes:           recoveryCodeRepo,
+		MFAChallenges:           mfaChallengeRepo,
+		PasswordResetTokens:     passwordResetTokenRepo,
+		EmailVerificationTokens: emailVerificationTokenRepo,
+		APIKeys:                 apiKeyRepo,
+		ServiceAccounts:         serviceAccountRepo,
+		ExternalIdentities:      externalIdentityRepo,
+		OIDCLogins:              oidcLoginRepo,
+		DeviceAuthorizations:    deviceAuthorizationRepo,
+		Sessions:                sessionRepo,
+		AuthEvents:              authEventRepo,
+	}, controllers.Dependencies{
+		Tokens:                 tokenManager,
+		PasswordPolicy:         passwordPolicy,
+		PasswordHasher:         passwordHasher,
+		MFA:                    mfaManager,
+		Mailer:                 mail,
+		Audit:                  audit.NewRecorder(authEventRepo, sharedLogger.Logger()),
+		Logger:                 sharedLogger.Logger(),
+		RevocationCache:        cache.New[string, bool](cfg.Introspection.CacheTTL, cfg.Introspection.CacheSize),
+		SessionRevocationCache: cache.New[string, time.Time](cfg.Introspection.CacheTTL, cfg.Introspection.CacheSize),
+		RevokedSessionCache:    cache.New[string, bool](cfg.Introspection.CacheTTL, cfg.Introspection.CacheSize),
+		APIKeyCache:            cache.New[string, *entities.APIKey](cfg.Introspection.CacheTTL, cfg.Introspection.CacheSize),
+		Lockout:                cfg.Lockout,
+		PasswordReset:          cfg.PasswordReset,
+		EmailVerification:      cfg.EmailVerification,
+		ServiceAccounts:        cfg.ServiceAccounts,
+		Impersonation:          cfg.Impersonation,
+		TokenExchange:          cfg.TokenExchange,
+		DeviceAuthorization:    cfg.DeviceAuthorization,
+		OIDCProviders:          oidcProviders,
+		OIDC:                   cfg.OIDC,
+		Organizations:          organizationDirectory,
+	})
+
+	// Report how many users still have password hashes made with legacy settings
+	go func() {
+		report, err := authCtrl.ReportPasswordHashes(ctx)
+		if err != nil {
+			sharedLogger.Logger().Warn("Failed to report password hashes", zap.Error(err))
+			return
+		}
+		sharedLogger.Logger().Info("Password hash report",
+			zap.String("current_scheme", report.CurrentScheme),
+			zap.Int64("current_users", report.CurrentUsers),
+			zap.Int64("legacy_users", report.LegacyUsers),
+		)
+		for _, scheme := range report.Schemes {
+			if scheme.Scheme != report.CurrentScheme {
+				sharedLogger.Logger().Info("Users with legacy password hashes",
+					zap.String("scheme", scheme.Scheme),
+					zap.Int64("users", scheme.Users),
+				)
+			}
+		}
+	}()
+
+	// Start the HTTP server (JWKS and other plain-HTTP endpoints)
+	httpServer := server.NewHTTPServer(sharedLogger.Logger(), authCtrl, cfg.Server.HTTPPort)
+	go func() {
+		if err := httpServer.Start(ctx); err != nil {
+			sharedLogger.Logger().Fatal("Failed to start HTTP server", zap.Error(err))
+		}
+	}()
+
+	// Start the gRPC server, authenticating every non-public RPC with the controller as verifier
+	// and logging every call made with an impersonation token. The issuer names this service in aud claims.
+	authInterceptor := authn.NewInterceptor(authCtrl, handlers.PublicMethods...).
+		WithAudience(cfg.JWT.Issuer).
+		WithLogger(sharedLogger.Logger())
+	grpcServer := server.NewGRPCServer(sharedLogger.Logger(), authCtrl, cfg.Server.Port,
+		grpc.ChainUnaryInterceptor(authInterceptor.Unary()),
+		grpc.ChainStreamInterceptor(authInterceptor.Stream()),
+	)
 	if err := grpcServer.Start(ctx); err != nil {
 		sharedLogger.Logger().Fatal("Failed to start gRPC server", zap.Error(err))
 	}
 }
+
+// backfillEmailVerified adds the users table's email_verified column, if the table predates it, and
+// marks every existing user verified in the same transaction. They signed up before there was a way
+// to verify an email, so leaving them unverified would restrict or lock out every one of them, admins
+// included. Once the column exists, as on new databases, this does nothing.
+func backfillEmailVerified(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&entities.User{}) || migrator.HasColumn(&entities.User{}, "EmailVerified") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().AddColumn(&entities.User{}, "EmailVerified"); err != nil {
+			return err
+		}
+		return tx.Exec("UPDATE users SET email_verified = true").Error
+	})
+}
@@ -1,12 +1,28 @@
 package config
 
+import "time"
+
 // This file defines the configuration structure for the auth service.
 // Add new configuration fields as needed, ensuring they are properly tagged for koanf.
 type AuthServiceConfig struct {
-	Server ServerConfig   `koanf:"server"`
-	JWT    JWTConfig      `koanf:"jwt"`
-	Log    LogConfig      `koanf:"logging"`
-	DB     DatabaseConfig `koanf:"database"`
+	Server              ServerConfig              `koanf:"server"`
+	JWT                 JWTConfig                 `koanf:"jwt"`
+	Log                 LogConfig                 `koanf:"logging"`
+	DB                  DatabaseConfig            `koanf:"database"`
+	PasswordPolicy      PasswordPolicyConfig      `koanf:"password_policy"`
+	PasswordHashing     PasswordHashingConfig     `koanf:"password_hashing"`
+	Introspection       IntrospectionConfig       `koanf:"introspection"`
+	Lockout             LockoutConfig             `koanf:"lockout"`
+	MFA                 MFAConfig                 `koanf:"mfa"`
+	Mail                MailConfig                `koanf:"mail"`
+	PasswordReset       PasswordResetConfig       `koanf:"password_reset"`
+	EmailVerification   EmailVerificationConfig   `koanf:"email_verification"`
+	ServiceAccounts     ServiceAccountConfig      `koanf:"service_accounts"`
+	Impersonation       ImpersonationConfig       `koanf:"impersonation"`
+	TokenExchange       TokenExchangeConfig       `koanf:"token_exchange"`
+	DeviceAuthorization DeviceAuthorizationConfig `koanf:"device_authorization"`
+	OIDC                OIDCConfig                `koanf:"oidc"`
+	Identity            IdentityConfig            `koanf:"identity"`
 }
 
 type DatabaseConfig struct {
@@ -19,13 +35,179 @@ type DatabaseConfig struct {
 }
 
 type ServerConfig struct {
-	Port int `koanf:"port"`
+	Port     int `koanf:"port"`
+	HTTPPort int `koanf:"http_port"`
 }
 
 type JWTConfig struct {
-	SecretKey string `koanf:"secret_key"`
+	Issuer          string        `koanf:"issuer"`
+	AccessTokenTTL  time.Duration `koanf:"access_token_ttl"`  // e.g. "15m"
+	RefreshTokenTTL time.Duration `koanf:"refresh_token_ttl"` // e.g. "720h"
+	// Directory of PEM-encoded RSA or Ed25519 keys named <kid>.pem.
+	// The private key with the greatest kid signs; every key verifies.
+	KeysDir string `koanf:"keys_dir"`
+	// Generate an Ed25519 key if KeysDir holds none. Intended for development only.
+	GenerateKeyIfMissing bool `koanf:"generate_key_if_missing"`
+	// How often KeysDir is re-read so keys can be rotated without a restart.
+	KeyReloadInterval time.Duration `koanf:"key_reload_interval"`
+	// How often expired entries are pruned from the access token revocation list.
+	RevocationPruneInterval time.Duration `koanf:"revocation_prune_interval"`
 }
 
 type LogConfig struct {
 	Level string `koanf:"level"`
 }
+
+type PasswordPolicyConfig struct {
+	MinLength           int    `koanf:"min_length"`
+	MaxLength           int    `koanf:"max_length"`
+	RequireUpper        bool   `koanf:"require_upper"`
+	RequireLower        bool   `koanf:"require_lower"`
+	RequireDigit        bool   `koanf:"require_digit"`
+	RequireSymbol       bool   `koanf:"require_symbol"`
+	BannedPasswordsFile string `koanf:"banned_passwords_file"` // one password per line; empty disables the check
+	// Sorted SHA-1 edition of the Have I Been Pwned Pwned Passwords list; empty disables the check.
+	BreachedPasswordsFile string `koanf:"breached_passwords_file"`
+	// How many breaches a password must appear in to be rejected; defaults to 1.
+	BreachedMinCount int `koanf:"breached_min_count"`
+}
+
+type PasswordHashingConfig struct {
+	// Algorithm new hashes are made with: "argon2id" or "bcrypt". Existing hashes of either kind keep verifying.
+	Algorithm  string       `koanf:"algorithm"`
+	BcryptCost int          `koanf:"bcrypt_cost"`
+	Argon2     Argon2Config `koanf:"argon2"`
+}
+
+type Argon2Config struct {
+	Memory      uint32 `koanf:"memory"` // KiB
+	Iterations  uint32 `koanf:"iterations"`
+	Parallelism uint8  `koanf:"parallelism"`
+	SaltLength  uint32 `koanf:"salt_length"` // bytes
+	KeyLength   uint32 `koanf:"key_length"`  // bytes
+}
+
+type IntrospectionConfig struct {
+	// How long a token's revocation status is cached in-process. Zero disables the cache.
+	CacheTTL  time.Duration `koanf:"cache_ttl"`
+	CacheSize int           `koanf:"cache_size"`
+}
+
+type LockoutConfig struct {
+	// Consecutive failures after which an email, or a peer address, is locked. Zero disables that counter.
+	MaxFailuresPerEmail int `koanf:"max_failures_per_email"`
+	MaxFailuresPerIP    int `koanf:"max_failures_per_ip"`
+	// The first lockout lasts BaseDuration; every further failure doubles it, up to MaxDuration.
+	BaseDuration time.Duration `koanf:"base_duration"` // e.g. "30s"
+	MaxDuration  time.Duration `koanf:"max_duration"`  // e.g. "1h"
+	// Failures older than this no longer count; counters start over after a quiet period.
+	FailureWindow time.Duration `koanf:"failure_window"` // e.g. "15m"
+}
+
+type MFAConfig struct {
+	// Issuer shown next to the account in authenticator apps.
+	Issuer string `koanf:"issuer"`
+	// File holding the base64-encoded 32-byte AES key that encrypts TOTP secrets at rest.
+	EncryptionKeyFile string `koanf:"encryption_key_file"`
+	// Generate EncryptionKeyFile if it does not exist. Intended for development only.
+	GenerateKeyIfMissing bool `koanf:"generate_key_if_missing"`
+	// How long a password-verified login has to present its second factor.
+	ChallengeTTL time.Duration `koanf:"challenge_ttl"` // e.g. "5m"
+	// Number of single-use recovery codes issued when MFA is enabled.
+	RecoveryCodes int `koanf:"recovery_codes"`
+}
+
+type MailConfig struct {
+	Driver   string     `koanf:"driver"` // "smtp" or "file"
+	From     string     `koanf:"from"`
+	SMTP     SMTPConfig `koanf:"smtp"`
+	FilePath string     `koanf:"file_path"` // used by the "file" driver; empty writes to stdout
+}
+
+type SMTPConfig struct {
+	Host     string `koanf:"host"`
+	Port     int    `koanf:"port"`
+	Username string `koanf:"username"`
+	Password string `koanf:"password"`
+}
+
+type PasswordResetConfig struct {
+	// How long a reset link stays valid.
+	TokenTTL time.Duration `koanf:"token_ttl"` // e.g. "1h"
+	// Page the reset email links to; the token is appended as the "token" query parameter.
+	ResetURL string `koanf:"reset_url"`
+	// How long after a reset email RequestPasswordReset sends no other; it still succeeds.
+	ResetCooldown time.Duration `koanf:"reset_cooldown"` // e.g. "5m"
+}
+
+type EmailVerificationConfig struct {
+	// How long a verification link stays valid.
+	TokenTTL time.Duration `koanf:"token_ttl"` // e.g. "24h"
+	// Page the verification email links to; the token is appended as the "token" query parameter.
+	VerifyURL string `koanf:"verify_url"`
+	// What happens when a user with an unverified email logs in: "restrict" issues tokens limited to
+	// UnverifiedScopes, "deny" refuses the login.
+	UnverifiedLogin string `koanf:"unverified_login"`
+	// Scopes an unverified user's tokens keep under the "restrict" policy, if the user holds them.
+	UnverifiedScopes []string `koanf:"unverified_scopes"`
+	// How long after a verification email ResendVerificationEmail sends no other; it still succeeds.
+	ResendCooldown time.Duration `koanf:"resend_cooldown"` // e.g. "5m"
+}
+
+type ServiceAccountConfig struct {
+	// Lifetime of access tokens issued through the client-credentials grant.
+	// Keep it short: service accounts simply request a new token when theirs expires.
+	AccessTokenTTL time.Duration `koanf:"access_token_ttl"` // e.g. "5m"
+}
+
+type ImpersonationConfig struct {
+	// Lifetime of impersonation tokens. They cannot be refreshed, so an admin who needs
+	// more time impersonates the user again and leaves another audit entry.
+	TokenTTL time.Duration `koanf:"token_ttl"` // e.g. "10m"
+}
+
+type TokenExchangeConfig struct {
+	// Longest lifetime of a token obtained through token exchange, and the lifetime it gets
+	// unless the client asks for less. It never outlives the token it was exchanged for.
+	MaxTTL time.Duration `koanf:"max_ttl"` // e.g. "15m"
+}
+
+type DeviceAuthorizationConfig struct {
+	// How long a device has to get its user code approved.
+	CodeTTL time.Duration `koanf:"code_ttl"` // e.g. "10m"
+	// How long devices wait between polls; every slow_down response adds 5 seconds for that device.
+	PollInterval time.Duration `koanf:"poll_interval"` // e.g. "5s"
+	// Page where users enter the user code; the code is appended as the "user_code" query parameter
+	// for verification_uri_complete.
+	VerificationURL string `koanf:"verification_url"`
+	// Public clients, such as the CLI, allowed to start a device authorization.
+	ClientIDs []string `koanf:"client_ids"`
+}
+
+type OIDCConfig struct {
+	// How long a started login has to come back from the identity provider.
+	StateTTL time.Duration `koanf:"state_ttl"` // e.g. "10m"
+	// External identity providers keyed by the name clients pass to StartOIDCLogin, e.g. "google".
+	Providers map[string]OIDCProviderConfig `koanf:"providers"`
+}
+
+type OIDCProviderConfig struct {
+	// Issuer URL; the provider's endpoints are discovered from <issuer>/.well-known/openid-configuration.
+	Issuer       string `koanf:"issuer"`
+	ClientID     string `koanf:"client_id"`
+	ClientSecret string `koanf:"client_secret"`
+	// Page the provider redirects back to with the code and state, which it passes to CompleteOIDCLogin.
+	RedirectURL string `koanf:"redirect_url"`
+	// Extra scopes requested besides "openid email profile".
+	Scopes []string `koanf:"scopes"`
+	// Email domains allowed to sign in through this provider. Empty allows any verified email.
+	AllowedDomains []string `koanf:"allowed_domains"`
+}
+
+type IdentityConfig struct {
+	// Address of the identity service's gRPC server, which SwitchOrganization and refreshes ask about
+	// organization memberships. Empty disables organizations: every session acts within none.
+	Address string `koanf:"address"` // e.g. "identity:8090"
+	// Name the identity service accepts in aud claims; must match its auth.audience setting.
+	Audience string `koanf:"audience"`
+}
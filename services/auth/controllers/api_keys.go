@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"github.com/himakhaitan/noreboothq/services/auth/tokens"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+	"github.com/himakhaitan/noreboothq/shared/opaque"
+)
+
+// CreateAPIKey issues a new API key owned by the caller, a user or a service account, with the given
+// scopes and optional lifetime (zero means the key never expires). Scopes must be a subset of the
+// caller's own, so a key can never grant more than the credential that created it. The raw key is
+// only returned here.
+func (c *AuthController) CreateAPIKey(ctx context.Context, principal *authn.Principal, name string, scopes []string, ttl time.Duration) (*entities.APIKey, string, error) {
+	// An API key would outlive the token and lose its act and aud claims.
+	if principal.Impersonated() {
+		return nil, "", ErrImpersonationNotAllowed
+	}
+	if len(principal.Audience) > 0 {
+		return nil, "", fmt.Errorf("%w: audience-restricted tokens cannot create api keys", ErrScopeNotAllowed)
+	}
+
+	owner, err := c.apiKeyOwner(ctx, principal)
+	if err != nil {
+		return nil, "", err
+	}
+
+	scopes = normalizeScopes(scopes)
+	if len(scopes) == 0 {
+		return nil, "", ErrNoScopes
+	}
+	for _, scope := range scopes {
+		if !principal.HasScope(scope) {
+			return nil, "", fmt.Errorf("%w: %s", ErrScopeNotAllowed, scope)
+		}
+	}
+
+	raw, prefix, hash, err := tokens.NewAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &entities.APIKey{
+		UserID:           owner.UserID,
+		ServiceAccountID: owner.ServiceAccountID,
+		Name:             name,
+		Prefix:           prefix,
+		KeyHash:          hash,
+		Scopes:           strings.Join(scopes, " "),
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := c.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("failed to store api key: %w", err)
+	}
+	return key, raw, nil
+}
+
+// ListAPIKeys returns the caller's API keys. Only prefixes are returned, never the keys themselves.
+func (c *AuthController) ListAPIKeys(ctx context.Context, principal *authn.Principal) ([]entities.APIKey, error) {
+	owner, err := c.apiKeyOwner(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []entities.APIKey
+	if owner.ServiceAccountID != nil {
+		keys, err = c.apiKeyRepo.ListByServiceAccount(ctx, *owner.ServiceAccountID)
+	} else {
+		keys, err = c.apiKeyRepo.ListByUser(ctx, *owner.UserID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes one of the caller's API keys. Holders of the auth.admin scope may revoke
+// any key; for everyone else, another user's key is reported as ErrAPIKeyNotFound.
+func (c *AuthController) RevokeAPIKey(ctx context.Context, principal *authn.Principal, id uint, client ClientInfo) error {
+	key, err := c.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return fmt.Errorf("failed to look up api key: %w", err)
+	}
+	if apiKeySubject(key) != principal.Subject && !principal.HasScope(tokens.ScopeAuthAdmin) {
+		return ErrAPIKeyNotFound
+	}
+
+	if err := c.apiKeyRepo.Revoke(ctx, key.ID); err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	c.apiKeys.Delete(key.Prefix)
+	c.recordEvent(ctx, client, &entities.AuthEvent{
+		Type:    entities.AuthEventTokenRevocation,
+		Outcome: entities.AuthEventSuccess,
+		UserID:  key.UserID,
+		Detail:  "api key " + key.Prefix + " revoked",
+	})
+	return nil
+}
+
+// verifyAPIKey authenticates a raw API key and returns claims equivalent to an access token's,
+// so API keys are accepted wherever access tokens are. Keys are cached by prefix like revocation
+// lookups; the last-used time is only refreshed on a cache miss. Like access tokens, keys stop
+// working when their owner's sessions are revoked: a user's keys created before a password reset or
+// RevokeAllSessions, and every key of a disabled service account.
+func (c *AuthController) verifyAPIKey(ctx context.Context, raw string) (*tokens.Claims, error) {
+	prefix, ok := tokens.APIKeyPrefix(raw)
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed api key", ErrInvalidAccessToken)
+	}
+
+	key, ok := c.apiKeys.Get(prefix)
+	if !ok {
+		var err error
+		key, err = c.apiKeyRepo.GetByPrefix(ctx, prefix)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, fmt.Errorf("%w: unknown api key", ErrInvalidAccessToken)
+			}
+			return nil, fmt.Errorf("failed to look up api key: %w", err)
+		}
+		if err := c.apiKeyRepo.TouchLastUsed(ctx, key.ID, time.Now()); err != nil {
+			return nil, fmt.Errorf("failed to record api key use: %w", err)
+		}
+		c.apiKeys.Set(prefix, key)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(opaque.Hash(raw)), []byte(key.KeyHash)) != 1 {
+		return nil, fmt.Errorf("%w: unknown api key", ErrInvalidAccessToken)
+	}
+	if !key.Active(time.Now()) {
+		return nil, fmt.Errorf("%w: api key %s is revoked or expired", ErrInvalidAccessToken, key.Prefix)
+	}
+
+	subject := apiKeySubject(key)
+	revokedAt, err := c.sessionsRevokedAt(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	if key.ServiceAccountID != nil && !revokedAt.IsZero() {
+		return nil, fmt.Errorf("%w: owner of api key %s is disabled", ErrInvalidAccessToken, key.Prefix)
+	}
+	if key.CreatedAt.Before(revokedAt) {
+		return nil, fmt.Errorf("%w: sessions of owner of api key %s have been revoked", ErrInvalidAccessToken, key.Prefix)
+	}
+
+	claims := &tokens.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       key.Prefix,
+			Subject:  subject,
+			IssuedAt: jwt.NewNumericDate(key.CreatedAt),
+		},
+		Scope: key.Scopes,
+	}
+	if key.ExpiresAt != nil {
+		claims.ExpiresAt = jwt.NewNumericDate(*key.ExpiresAt)
+	}
+	return claims, nil
+}
+
+// apiKeyOwnerRef names the user or service account that owns an API key.
+type apiKeyOwnerRef struct {
+	UserID           *uint
+	ServiceAccountID *uint
+}
+
+// apiKeyOwner returns the owner of the keys the principal creates and lists: the service account
+// or user its subject names.
+func (c *AuthController) apiKeyOwner(ctx context.Context, principal *authn.Principal) (*apiKeyOwnerRef, error) {
+	if !isServiceAccountSubject(principal.Subject) {
+		user, err := c.userForPrincipal(ctx, principal)
+		if err != nil {
+			return nil, err
+		}
+		return &apiKeyOwnerRef{UserID: &user.ID}, nil
+	}
+
+	id, err := strconv.ParseUint(strings.TrimPrefix(principal.Subject, serviceAccountSubjectPrefix), 10, 64)
+	if err != nil {
+		return nil, ErrInvalidAccessToken
+	}
+	account, err := c.serviceAccountRepo.GetByID(ctx, uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidAccessToken
+		}
+		return nil, fmt.Errorf("failed to look up service account: %w", err)
+	}
+	return &apiKeyOwnerRef{ServiceAccountID: &account.ID}, nil
+}
+
+// apiKeySubject returns the sub claim a key authenticates as: its service account's or its user's.
+func apiKeySubject(key *entities.APIKey) string {
+	if key.ServiceAccountID != nil {
+		return serviceAccountSubject(*key.ServiceAccountID)
+	}
+	return subjectFor(*key.UserID)
+}
+
+// normalizeScopes trims, de-duplicates and sorts requested scopes, dropping empty entries.
+func normalizeScopes(scopes []string) []string {
+	out := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			out = append(out, scope)
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+)
+
+const (
+	// defaultAuthEventPageSize is used when ListAuthEvents is called without a page size.
+	defaultAuthEventPageSize = 50
+	// maxAuthEventPageSize caps the page size a caller may ask for.
+	maxAuthEventPageSize = 500
+)
+
+// ListAuthEvents returns a page of the audit trail matching filter, newest first, and the token of
+// the next page, which is empty on the last page. pageToken is the token returned with the previous page.
+func (c *AuthController) ListAuthEvents(ctx context.Context, filter repository.AuthEventFilter, pageSize int, pageToken string) ([]entities.AuthEvent, string, error) {
+	if pageSize <= 0 {
+		pageSize = defaultAuthEventPageSize
+	}
+	pageSize = min(pageSize, maxAuthEventPageSize)
+
+	var beforeID uint64
+	if pageToken != "" {
+		var err error
+		if beforeID, err = strconv.ParseUint(pageToken, 10, 64); err != nil || beforeID == 0 {
+			return nil, "", ErrInvalidPageToken
+		}
+	}
+
+	// Ask for one extra event to tell whether another page follows.
+	events, err := c.authEventRepo.List(ctx, filter, uint(beforeID), pageSize+1)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list auth events: %w", err)
+	}
+	if len(events) <= pageSize {
+		return events, "", nil
+	}
+	events = events[:pageSize]
+	return events, strconv.FormatUint(uint64(events[pageSize-1].ID), 10), nil
+}
+
+// recordEvent adds an event to the audit trail, filling in the client it came from and, for
+// authenticated requests, the caller as the actor unless one is set.
+func (c *AuthController) recordEvent(ctx context.Context, client ClientInfo, event *entities.AuthEvent) {
+	if principal, ok := authn.PrincipalFromContext(ctx); ok && event.Actor == "" {
+		event.Actor = principal.Subject
+		event.Impersonator = principal.Actor
+	}
+	event.IPAddress = client.IPAddress
+	event.UserAgent = truncate(client.UserAgent, maxUserAgentLength)
+	c.audit.Record(ctx, event)
+}
+
+// eventUser returns a user ID in the form AuthEvent.UserID takes.
+func eventUser(userID uint) *uint {
+	return &userID
+}
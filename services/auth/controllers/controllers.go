@@ -1,18 +1,159 @@
 package controllers
 
-import "github.com/himakhaitan/noreboothq/services/auth/repository"
+import (
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/audit"
+	"github.com/himakhaitan/noreboothq/services/auth/config"
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/mfa"
+	"github.com/himakhaitan/noreboothq/services/auth/oidc"
+	"github.com/himakhaitan/noreboothq/services/auth/password"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"github.com/himakhaitan/noreboothq/services/auth/tokens"
+	"github.com/himakhaitan/noreboothq/shared/cache"
+	"github.com/himakhaitan/noreboothq/shared/mailer"
+	"go.uber.org/zap"
+)
+
+// Repositories groups the data access dependencies of the AuthController.
+type Repositories struct {
+	Users                   repository.UserRepository
+	RefreshTokens           repository.RefreshTokenRepository
+	RevokedTokens           repository.RevokedTokenRepository
+	LoginThrottles          repository.LoginThrottleRepository
+	RecoveryCodes           repository.RecoveryCodeRepository
+	MFAChallenges           repository.MFAChallengeRepository
+	PasswordResetTokens     repository.PasswordResetTokenRepository
+	EmailVerificationTokens repository.EmailVerificationTokenRepository
+	APIKeys                 repository.APIKeyRepository
+	ServiceAccounts         repository.ServiceAccountRepository
+	ExternalIdentities      repository.ExternalIdentityRepository
+	OIDCLogins              repository.OIDCLoginRepository
+	DeviceAuthorizations    repository.DeviceAuthorizationRepository
+	Sessions                repository.SessionRepository
+	AuthEvents              repository.AuthEventRepository
+}
+
+// Dependencies groups the services, caches and settings the AuthController relies on besides its repositories.
+type Dependencies struct {
+	Tokens         *tokens.Manager
+	PasswordPolicy *password.Policy
+	// PasswordHasher hashes new passwords and decides which stored hashes are due an upgrade.
+	PasswordHasher *password.Hasher
+	MFA            *mfa.Manager
+	Mailer         mailer.Mailer
+	// Audit records authentication events.
+	Audit *audit.Recorder
+	// Logger reports failures that must not fail the request, such as a password hash upgrade.
+	Logger *zap.Logger
+	// RevocationCache caches revocation status by jti.
+	RevocationCache *cache.Cache[string, bool]
+	// SessionRevocationCache caches, by subject, the time before which the user's tokens are rejected.
+	SessionRevocationCache *cache.Cache[string, time.Time]
+	// RevokedSessionCache caches, by session ID, whether a session has been revoked.
+	RevokedSessionCache *cache.Cache[string, bool]
+	// APIKeyCache caches API keys by prefix.
+	APIKeyCache         *cache.Cache[string, *entities.APIKey]
+	Lockout             config.LockoutConfig
+	PasswordReset       config.PasswordResetConfig
+	EmailVerification   config.EmailVerificationConfig
+	ServiceAccounts     config.ServiceAccountConfig
+	Impersonation       config.ImpersonationConfig
+	TokenExchange       config.TokenExchangeConfig
+	DeviceAuthorization config.DeviceAuthorizationConfig
+	// OIDCProviders are the external identity providers users can sign in with, keyed by name.
+	OIDCProviders map[string]*oidc.Provider
+	OIDC          config.OIDCConfig
+	// Organizations checks organization memberships. Nil when no identity service is configured,
+	// which leaves every session without an organization.
+	Organizations OrganizationDirectory
+}
 
 // AuthController handles authentication-related operations.
-// It interacts with the UserRepository to perform user-related actions such as login, etc.
+// It interacts with the repositories to perform user-related actions such as login, registration, etc.
 type AuthController struct {
-	userRepo *repository.UserRepository
+	userRepo           repository.UserRepository
+	refreshRepo        repository.RefreshTokenRepository
+	revokedRepo        repository.RevokedTokenRepository
+	throttleRepo       repository.LoginThrottleRepository
+	recoveryRepo       repository.RecoveryCodeRepository
+	challengeRepo      repository.MFAChallengeRepository
+	resetRepo          repository.PasswordResetTokenRepository
+	verificationRepo   repository.EmailVerificationTokenRepository
+	apiKeyRepo         repository.APIKeyRepository
+	serviceAccountRepo repository.ServiceAccountRepository
+	identityRepo       repository.ExternalIdentityRepository
+	oidcLoginRepo      repository.OIDCLoginRepository
+	deviceAuthRepo     repository.DeviceAuthorizationRepository
+	sessionRepo        repository.SessionRepository
+	authEventRepo      repository.AuthEventRepository
+	tokens             *tokens.Manager
+	policy             *password.Policy
+	hasher             *password.Hasher
+	mfa                *mfa.Manager
+	mailer             mailer.Mailer
+	audit              *audit.Recorder
+	logger             *zap.Logger
+	// revoked caches revocation status by jti so verification doesn't hit Postgres on every call.
+	revoked *cache.Cache[string, bool]
+	// sessionsRevoked caches each user's SessionsRevokedAt by subject for the same reason.
+	sessionsRevoked *cache.Cache[string, time.Time]
+	// revokedSessions caches whether each session, by ID, has been revoked.
+	revokedSessions *cache.Cache[string, bool]
+	// apiKeys caches API keys by prefix; revocations on other replicas apply once entries expire.
+	apiKeys             *cache.Cache[string, *entities.APIKey]
+	lockout             config.LockoutConfig
+	passwordReset       config.PasswordResetConfig
+	emailVerification   config.EmailVerificationConfig
+	serviceAccounts     config.ServiceAccountConfig
+	impersonation       config.ImpersonationConfig
+	tokenExchange       config.TokenExchangeConfig
+	deviceAuthorization config.DeviceAuthorizationConfig
+	oidcProviders       map[string]*oidc.Provider
+	oidc                config.OIDCConfig
+	organizations       OrganizationDirectory
 }
 
-// NewAuthController creates a new instance of AuthController with the provided UserRepository.
-func NewAuthController(userRepo *repository.UserRepository) *AuthController {
-	return &AuthController{userRepo: userRepo}
+// NewAuthController creates a new instance of AuthController with the provided repositories
+// and dependencies.
+func NewAuthController(repos Repositories, deps Dependencies) *AuthController {
+	return &AuthController{
+		userRepo:            repos.Users,
+		refreshRepo:         repos.RefreshTokens,
+		revokedRepo:         repos.RevokedTokens,
+		throttleRepo:        repos.LoginThrottles,
+		recoveryRepo:        repos.RecoveryCodes,
+		challengeRepo:       repos.MFAChallenges,
+		resetRepo:           repos.PasswordResetTokens,
+		verificationRepo:    repos.EmailVerificationTokens,
+		apiKeyRepo:          repos.APIKeys,
+		serviceAccountRepo:  repos.ServiceAccounts,
+		identityRepo:        repos.ExternalIdentities,
+		oidcLoginRepo:       repos.OIDCLogins,
+		deviceAuthRepo:      repos.DeviceAuthorizations,
+		sessionRepo:         repos.Sessions,
+		authEventRepo:       repos.AuthEvents,
+		tokens:              deps.Tokens,
+		policy:              deps.PasswordPolicy,
+		hasher:              deps.PasswordHasher,
+		mfa:                 deps.MFA,
+		mailer:              deps.Mailer,
+		audit:               deps.Audit,
+		logger:              deps.Logger,
+		revoked:             deps.RevocationCache,
+		sessionsRevoked:     deps.SessionRevocationCache,
+		revokedSessions:     deps.RevokedSessionCache,
+		apiKeys:             deps.APIKeyCache,
+		lockout:             deps.Lockout,
+		passwordReset:       deps.PasswordReset,
+		emailVerification:   deps.EmailVerification,
+		serviceAccounts:     deps.ServiceAccounts,
+		impersonation:       deps.Impersonation,
+		tokenExchange:       deps.TokenExchange,
+		deviceAuthorization: deps.DeviceAuthorization,
+		oidcProviders:       deps.OIDCProviders,
+		oidc:                deps.OIDC,
+		organizations:       deps.Organizations,
+	}
 }
-
-// func (c *AuthController) Login(ctx context.Context, email string, password string) (string, error) {
-//  // Handle user login logic here
-// }
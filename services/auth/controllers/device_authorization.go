@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"github.com/himakhaitan/noreboothq/services/auth/tokens"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+	"github.com/himakhaitan/noreboothq/shared/opaque"
+)
+
+// slowDownStep is how much every slow_down response lengthens a device's polling interval (RFC 8628 section 3.5).
+const slowDownStep = 5 * time.Second
+
+// maxUserCodeAttempts bounds how often StartDeviceAuthorization draws a new user code after a collision.
+const maxUserCodeAttempts = 3
+
+// DeviceCode is a started device authorization. The device shows UserCode and VerificationURI to the
+// user and polls PollDeviceToken with DeviceCode, at most once per Interval, until ExpiresIn has passed.
+type DeviceCode struct {
+	DeviceCode              string
+	UserCode                string
+	VerificationURI         string
+	VerificationURIComplete string
+	ExpiresIn               time.Duration
+	Interval                time.Duration
+}
+
+// StartDeviceAuthorization begins an RFC 8628 device authorization for one of the configured
+// public clients, such as the CLI on a machine without a browser.
+func (c *AuthController) StartDeviceAuthorization(ctx context.Context, clientID string) (*DeviceCode, error) {
+	if !slices.Contains(c.deviceAuthorization.ClientIDs, clientID) {
+		return nil, ErrInvalidClient
+	}
+
+	deviceCode, deviceCodeHash, err := opaque.New()
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		userCode, err := tokens.NewUserCode()
+		if err != nil {
+			return nil, err
+		}
+
+		authorization := &entities.DeviceAuthorization{
+			DeviceCodeHash: deviceCodeHash,
+			UserCode:       tokens.NormalizeUserCode(userCode),
+			ClientID:       clientID,
+			Status:         entities.DeviceAuthorizationPending,
+			PollInterval:   int(c.deviceAuthorization.PollInterval / time.Second),
+			ExpiresAt:      time.Now().Add(c.deviceAuthorization.CodeTTL),
+		}
+		err = c.deviceAuthRepo.Create(ctx, authorization)
+		if errors.Is(err, repository.ErrDuplicate) && attempt < maxUserCodeAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to store device authorization: %w", err)
+		}
+
+		complete, err := url.Parse(c.deviceAuthorization.VerificationURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid device verification url: %w", err)
+		}
+		query := complete.Query()
+		query.Set("user_code", userCode)
+		complete.RawQuery = query.Encode()
+
+		return &DeviceCode{
+			DeviceCode:              deviceCode,
+			UserCode:                userCode,
+			VerificationURI:         c.deviceAuthorization.VerificationURL,
+			VerificationURIComplete: complete.String(),
+			ExpiresIn:               c.deviceAuthorization.CodeTTL,
+			Interval:                c.deviceAuthorization.PollInterval,
+		}, nil
+	}
+}
+
+// ApproveDevice lets the signed-in caller approve the device showing userCode, which is then
+// signed in as the caller on its next poll. It returns the approved authorization so the caller
+// can be shown which client they signed in.
+func (c *AuthController) ApproveDevice(ctx context.Context, principal *authn.Principal, userCode string, client ClientInfo) (*entities.DeviceAuthorization, error) {
+	return c.reviewDevice(ctx, principal, userCode, entities.DeviceAuthorizationApproved, client)
+}
+
+// DenyDevice lets the signed-in caller refuse the device showing userCode, e.g. because they
+// did not start the sign-in. The device's next poll fails with ErrDeviceAccessDenied.
+func (c *AuthController) DenyDevice(ctx context.Context, principal *authn.Principal, userCode string, client ClientInfo) (*entities.DeviceAuthorization, error) {
+	return c.reviewDevice(ctx, principal, userCode, entities.DeviceAuthorizationDenied, client)
+}
+
+// reviewDevice records the caller's decision on a pending device authorization.
+func (c *AuthController) reviewDevice(ctx context.Context, principal *authn.Principal, userCode string, status string, client ClientInfo) (*entities.DeviceAuthorization, error) {
+	// The device gets a full session, which must not come from a borrowed or restricted token.
+	if principal.Impersonated() {
+		return nil, ErrImpersonationNotAllowed
+	}
+	if len(principal.Audience) > 0 {
+		return nil, fmt.Errorf("%w: audience-restricted tokens cannot approve devices", ErrScopeNotAllowed)
+	}
+	user, err := c.userForPrincipal(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	authorization, err := c.deviceAuthRepo.GetByUserCode(ctx, tokens.NormalizeUserCode(userCode))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidUserCode
+		}
+		return nil, fmt.Errorf("failed to look up device authorization: %w", err)
+	}
+	if err := c.deviceAuthRepo.Review(ctx, authorization.ID, user.ID, status); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, ErrInvalidUserCode
+		}
+		return nil, fmt.Errorf("failed to review device authorization: %w", err)
+	}
+	authorization.Status = status
+	authorization.UserID = &user.ID
+
+	c.recordEvent(ctx, client, &entities.AuthEvent{
+		Type:    entities.AuthEventDeviceAuthorization,
+		Outcome: entities.AuthEventSuccess,
+		UserID:  eventUser(user.ID),
+		Detail:  fmt.Sprintf("%s %s", authorization.ClientID, status),
+	})
+	return authorization, nil
+}
+
+// PollDeviceToken is the device_code grant the device polls with. It fails with
+// ErrAuthorizationPending until the user reviews the request, ErrSlowDown when polled too often,
+// ErrDeviceAccessDenied once denied and ErrDeviceCodeExpired once expired. After approval the first
+// poll signs the device in as the approving user, starting a session like Login does.
+func (c *AuthController) PollDeviceToken(ctx context.Context, clientID string, deviceCode string, client ClientInfo) (*AuthTokens, error) {
+	authorization, err := c.deviceAuthRepo.GetByDeviceCodeHash(ctx, opaque.Hash(deviceCode))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidDeviceCode
+		}
+		return nil, fmt.Errorf("failed to look up device authorization: %w", err)
+	}
+	if authorization.ClientID != clientID || authorization.UsedAt != nil {
+		return nil, ErrInvalidDeviceCode
+	}
+
+	now := time.Now()
+	if now.After(authorization.ExpiresAt) {
+		return nil, ErrDeviceCodeExpired
+	}
+
+	interval := time.Duration(authorization.PollInterval) * time.Second
+	tooSoon := authorization.LastPolledAt != nil && now.Sub(*authorization.LastPolledAt) < interval
+	if tooSoon {
+		interval += slowDownStep
+	}
+	err = c.deviceAuthRepo.RecordPoll(ctx, authorization.ID, authorization.LastPolledAt, now, int(interval/time.Second))
+	switch {
+	case errors.Is(err, repository.ErrConflict):
+		// Another poll for the same code is in flight.
+		return nil, ErrSlowDown
+	case err != nil:
+		return nil, fmt.Errorf("failed to record device poll: %w", err)
+	case tooSoon:
+		return nil, ErrSlowDown
+	}
+
+	switch authorization.Status {
+	case entities.DeviceAuthorizationPending:
+		return nil, ErrAuthorizationPending
+	case entities.DeviceAuthorizationDenied:
+		return nil, ErrDeviceAccessDenied
+	}
+
+	if err := c.deviceAuthRepo.MarkUsed(ctx, authorization.ID); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, ErrInvalidDeviceCode
+		}
+		return nil, fmt.Errorf("failed to redeem device authorization: %w", err)
+	}
+
+	user, err := c.userRepo.GetByID(ctx, *authorization.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	return c.startSession(ctx, user, "device:"+authorization.ClientID, client)
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"github.com/himakhaitan/noreboothq/shared/mailer"
+	"github.com/himakhaitan/noreboothq/shared/opaque"
+)
+
+// Policies for users who log in before verifying their email, set by email_verification.unverified_login.
+const (
+	// UnverifiedLoginRestrict issues tokens limited to email_verification.unverified_scopes.
+	UnverifiedLoginRestrict = "restrict"
+	// UnverifiedLoginDeny refuses the login with ErrEmailNotVerified.
+	UnverifiedLoginDeny = "deny"
+)
+
+// SendVerificationEmail emails the user a single-use link that proves they own their email address.
+// Links sent earlier and not yet used stop working, so only the newest one is ever valid.
+func (c *AuthController) SendVerificationEmail(ctx context.Context, user *entities.User) error {
+	raw, hash, err := opaque.New()
+	if err != nil {
+		return err
+	}
+
+	if err := c.verificationRepo.DeleteUnusedByUser(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to invalidate earlier email verification tokens: %w", err)
+	}
+
+	verificationToken := &entities.EmailVerificationToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(c.emailVerification.TokenTTL),
+	}
+	if err := c.verificationRepo.Create(ctx, verificationToken); err != nil {
+		return fmt.Errorf("failed to store email verification token: %w", err)
+	}
+
+	link, err := opaque.Link(c.emailVerification.VerifyURL, raw)
+	if err != nil {
+		return fmt.Errorf("invalid email_verification verify_url: %w", err)
+	}
+
+	return c.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your NoRebootHQ email address",
+		Body: fmt.Sprintf("Welcome to NoRebootHQ!\n\n"+
+			"Use this link within %s to confirm this is your email address:\n\n%s\n\n"+
+			"If you didn't create an account, ignore this email.\n",
+			c.emailVerification.TokenTTL, link),
+	})
+}
+
+// ResendVerificationEmail emails a new verification link to the user with the given email.
+// It succeeds without sending anything for unknown and already verified emails, so callers
+// cannot tell which accounts exist, and while the last link was sent less than
+// email_verification.resend_cooldown ago, so the endpoint cannot be used to flood an inbox.
+func (c *AuthController) ResendVerificationEmail(ctx context.Context, email string) error {
+	user, err := c.userRepo.GetByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to look up user: %w", err)
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	latest, err := c.verificationRepo.GetLatestByUser(ctx, user.ID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("failed to look up email verification token: %w", err)
+	}
+	if latest != nil && time.Since(latest.CreatedAt) < c.emailVerification.ResendCooldown {
+		return nil
+	}
+	return c.SendVerificationEmail(ctx, user)
+}
+
+// VerifyEmail marks the user's email as verified using a token from a verification email.
+// Tokens issued before the change keep their scopes; the next refresh or login gets the full set.
+func (c *AuthController) VerifyEmail(ctx context.Context, rawToken string) error {
+	verificationToken, err := c.verificationRepo.GetByHash(ctx, opaque.Hash(rawToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidVerificationToken
+		}
+		return fmt.Errorf("failed to look up email verification token: %w", err)
+	}
+	if verificationToken.UsedAt != nil || time.Now().After(verificationToken.ExpiresAt) {
+		return ErrInvalidVerificationToken
+	}
+
+	if err := c.verificationRepo.MarkUsed(ctx, verificationToken.ID); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return ErrInvalidVerificationToken
+		}
+		return fmt.Errorf("failed to redeem email verification token: %w", err)
+	}
+
+	if err := c.userRepo.MarkEmailVerified(ctx, verificationToken.UserID); err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+	return nil
+}
+
+// checkEmailVerified returns ErrEmailNotVerified if the user may not sign in until they verify their email.
+// Unknown policies are treated as "deny".
+func (c *AuthController) checkEmailVerified(user *entities.User) error {
+	if user.EmailVerified || c.emailVerification.UnverifiedLogin == UnverifiedLoginRestrict {
+		return nil
+	}
+	return ErrEmailNotVerified
+}
+
+// tokenScopes returns the scopes to put in the user's access tokens: all of their scopes once their
+// email is verified, and only those also listed in email_verification.unverified_scopes before.
+func (c *AuthController) tokenScopes(user *entities.User) []string {
+	scopes := user.ScopeList()
+	if user.EmailVerified {
+		return scopes
+	}
+	return slices.DeleteFunc(scopes, func(scope string) bool {
+		return !slices.Contains(c.emailVerification.UnverifiedScopes, scope)
+	})
+}
+
+// markEmailVerified records that the user proved they own their email, unless that is already known.
+func (c *AuthController) markEmailVerified(ctx context.Context, user *entities.User) error {
+	if user.EmailVerified {
+		return nil
+	}
+	if err := c.userRepo.MarkEmailVerified(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+	user.EmailVerified = true
+	return nil
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/himakhaitan/noreboothq/services/auth/password"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+)
+
+// Errors returned by the AuthController. Handlers translate these into gRPC status codes.
+var (
+	// ErrInvalidCredentials is returned for both unknown emails and wrong passwords
+	// so that callers cannot tell which accounts exist.
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrInvalidEmail is returned when an email address is malformed.
+	ErrInvalidEmail = errors.New("invalid email address")
+	// ErrWeakPassword is wrapped by the error returned when a new password is rejected by the
+	// password policy. The wrapping error's message carries the reason for the client.
+	ErrWeakPassword = password.ErrPolicyViolation
+	// ErrEmailTaken is returned when registering an email that already belongs to a user.
+	ErrEmailTaken = errors.New("email is already registered")
+	// ErrInvalidRefreshToken is returned when a refresh token is unknown, expired or revoked.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when an already-rotated refresh token is presented again.
+	// The token's whole family has been revoked by the time this is returned.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	// ErrInvalidAccessToken is returned when an access token fails verification or has been revoked.
+	// It is the shared authn error so the gRPC interceptor treats it as Unauthenticated.
+	ErrInvalidAccessToken = authn.ErrInvalidToken
+	// ErrTooManyAttempts is matched by the LockedError returned while an account or client
+	// address is locked out after repeated login failures.
+	ErrTooManyAttempts = errors.New("too many failed login attempts")
+	// ErrMFAAlreadyEnabled is returned when enrolling or confirming MFA for a user who already has it enabled.
+	ErrMFAAlreadyEnabled = errors.New("mfa is already enabled")
+	// ErrMFANotEnrolled is returned when confirming MFA before EnrollMFA has generated a secret.
+	ErrMFANotEnrolled = errors.New("mfa enrollment has not been started")
+	// ErrMFANotEnabled is returned when disabling MFA for a user who does not have it enabled.
+	ErrMFANotEnabled = errors.New("mfa is not enabled")
+	// ErrInvalidMFACode is returned for a wrong, expired, replayed or already-used TOTP or recovery code.
+	ErrInvalidMFACode = errors.New("invalid mfa code")
+	// ErrInvalidMFAToken is returned when an MFA challenge token is unknown, expired or already used.
+	ErrInvalidMFAToken = errors.New("invalid mfa token")
+	// ErrWrongPassword is returned when changing a password with a wrong current password.
+	ErrWrongPassword = errors.New("current password is incorrect")
+	// ErrInvalidResetToken is returned when a password reset token is unknown, expired or already used.
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	// ErrInvalidVerificationToken is returned when an email verification token is unknown, expired or already used.
+	ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+	// ErrEmailNotVerified is returned when a user with an unverified email logs in under the "deny" policy.
+	ErrEmailNotVerified = errors.New("email address is not verified")
+	// ErrInvalidPageToken is returned when a page token was not returned by a previous call of the same listing.
+	ErrInvalidPageToken = errors.New("invalid page token")
+	// ErrNoScopes is returned when creating an API key or service account without any scopes.
+	ErrNoScopes = errors.New("at least one scope is required")
+	// ErrScopeNotAllowed is returned when a credential asks for a scope its creator does not hold.
+	ErrScopeNotAllowed = errors.New("scope not allowed")
+	// ErrAPIKeyNotFound is returned when an API key does not exist or belongs to another user.
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrAPIKeyNotRevocable is returned when Logout or RevokeToken is given an API key, which only
+	// RevokeAPIKey can revoke.
+	ErrAPIKeyNotRevocable = errors.New("api keys are revoked with RevokeAPIKey")
+	// ErrServiceAccountNotFound is returned when a service account does not exist.
+	ErrServiceAccountNotFound = errors.New("service account not found")
+	// ErrInvalidClient is returned when client credentials are unknown, wrong or belong to a disabled account.
+	ErrInvalidClient = errors.New("invalid client credentials")
+	// ErrSessionNotFound is returned when a session does not exist or belongs to another user.
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrUserNotFound is returned when an administrative operation names a user that does not exist.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUnknownProvider is returned when starting a login with an identity provider that is not configured.
+	ErrUnknownProvider = errors.New("unknown identity provider")
+	// ErrInvalidOIDCState is returned when an OIDC login's state is unknown, expired or already used.
+	ErrInvalidOIDCState = errors.New("invalid or expired oidc login state")
+	// ErrOIDCLoginFailed is returned when the identity provider rejects the code or returns an invalid ID token.
+	ErrOIDCLoginFailed = errors.New("oidc login failed")
+	// ErrEmailDomainNotAllowed is returned when the identity provider's user has no verified email
+	// or one outside the provider's allowed domains.
+	ErrEmailDomainNotAllowed = errors.New("email is not allowed to sign in with this provider")
+	// ErrImpersonationNotAllowed is returned when an impersonation token is used to impersonate again
+	// or to mint long-lived credentials, or when a caller tries to impersonate themselves.
+	ErrImpersonationNotAllowed = errors.New("not allowed while impersonating")
+	// ErrAudienceNotAllowed is returned when exchanging a token for an audience it is not valid for.
+	ErrAudienceNotAllowed = errors.New("audience not allowed")
+	// ErrInvalidUserCode is returned when reviewing a device user code that is unknown, expired or already reviewed.
+	ErrInvalidUserCode = errors.New("invalid or expired user code")
+	// ErrInvalidDeviceCode is returned when a device polls with a code that is unknown, belongs to
+	// another client or was already redeemed.
+	ErrInvalidDeviceCode = errors.New("invalid device code")
+	// ErrNotOrganizationMember is returned when switching to an organization the user does not belong to.
+	ErrNotOrganizationMember = errors.New("not a member of the organization")
+	// ErrOrganizationSwitchNotAllowed is returned when a token that belongs to no login session,
+	// such as an API key or an impersonation token, tries to switch organization.
+	ErrOrganizationSwitchNotAllowed = errors.New("only signed-in sessions can switch organization")
+	// ErrOrganizationsUnavailable is returned when memberships cannot be checked, because no identity
+	// service is configured or it could not be reached.
+	ErrOrganizationsUnavailable = errors.New("organization memberships cannot be checked right now")
+
+	// The device flow's polling errors carry the RFC 8628 error codes as their messages,
+	// so clients can act on them over gRPC as they would over HTTP.
+
+	// ErrAuthorizationPending is returned while the user has not yet approved or denied the device.
+	ErrAuthorizationPending = errors.New("authorization_pending")
+	// ErrSlowDown is returned when a device polls sooner than its interval allows.
+	ErrSlowDown = errors.New("slow_down")
+	// ErrDeviceAccessDenied is returned when the user denied the device.
+	ErrDeviceAccessDenied = errors.New("access_denied")
+	// ErrDeviceCodeExpired is returned when the device code expired before the user approved it.
+	ErrDeviceCodeExpired = errors.New("expired_token")
+)
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"github.com/himakhaitan/noreboothq/services/auth/tokens"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+)
+
+// ImpersonationToken is an access token that lets an administrator act as another user.
+type ImpersonationToken struct {
+	AccessToken string
+	TokenType   string
+	ExpiresIn   time.Duration
+	Scopes      []string
+}
+
+// privilegedScopes are never carried by impersonation tokens, so impersonating an administrator
+// cannot be used to escalate or to chain impersonations.
+var privilegedScopes = []string{tokens.ScopeAuthAdmin, tokens.ScopeAuthImpersonate}
+
+// Impersonate issues a short-lived access token for the given user that names the caller in its
+// act claim. The token has the user's scopes minus administrative ones, belongs to no session and
+// comes without a refresh token. Revoking either the user's or the caller's sessions rejects it.
+func (c *AuthController) Impersonate(ctx context.Context, principal *authn.Principal, userID uint, reason string, client ClientInfo) (*ImpersonationToken, error) {
+	if principal.Impersonated() {
+		return nil, ErrImpersonationNotAllowed
+	}
+
+	user, err := c.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	subject := subjectFor(user.ID)
+	if subject == principal.Subject {
+		return nil, ErrImpersonationNotAllowed
+	}
+
+	scopes := slices.DeleteFunc(c.tokenScopes(user), func(scope string) bool {
+		return slices.Contains(privilegedScopes, scope)
+	})
+	ttl := c.impersonation.TokenTTL
+	accessToken, err := c.tokens.IssueImpersonation(subject, principal.Subject, scopes, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue impersonation token: %w", err)
+	}
+
+	c.recordEvent(ctx, client, &entities.AuthEvent{
+		Type:    entities.AuthEventImpersonation,
+		Outcome: entities.AuthEventSuccess,
+		UserID:  eventUser(user.ID),
+		Email:   user.Email,
+		Detail:  reason,
+	})
+
+	return &ImpersonationToken{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   ttl,
+		Scopes:      scopes,
+	}, nil
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/password"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+)
+
+// LoginResult is the outcome of a successful password check. For users without MFA, Tokens is set;
+// otherwise MFAToken is the challenge to complete with VerifyMFA before it expires.
+type LoginResult struct {
+	Tokens       *AuthTokens
+	MFAToken     string
+	MFAExpiresIn time.Duration
+}
+
+// AuthTokens is the set of tokens returned to a client after it authenticates successfully.
+type AuthTokens struct {
+	AccessToken      string
+	TokenType        string
+	ExpiresIn        time.Duration
+	RefreshToken     string
+	RefreshExpiresIn time.Duration
+}
+
+// Login authenticates a user by email and password and issues a signed access token
+// together with a refresh token that starts a new token family. Users with MFA enabled
+// get an MFA challenge instead, which VerifyMFA exchanges for the tokens.
+// Users who have not verified their email get restricted tokens or ErrEmailNotVerified, as configured.
+// A password hash made with outdated settings is replaced with one made with the current settings.
+// An unknown email and a wrong password both yield ErrInvalidCredentials and count towards
+// the lockout of the email and of the client's network address (if known).
+// While either is locked out, Login returns a LockedError without checking the password.
+// The client is recorded on the session the login starts, and every attempt is audited.
+func (c *AuthController) Login(ctx context.Context, email string, plainPassword string, client ClientInfo) (*LoginResult, error) {
+	email = normalizeEmail(email)
+	if err := c.checkLoginLockout(ctx, email, nil, client); err != nil {
+		return nil, err
+	}
+
+	user, err := c.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.hasher.VerifyDummy(plainPassword)
+			return nil, c.loginFailed(ctx, email, nil, "unknown email", client)
+		}
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+
+	// Users provisioned through an identity provider have no password and can only sign in there.
+	if user.PasswordHash == "" {
+		c.hasher.VerifyDummy(plainPassword)
+		return nil, c.loginFailed(ctx, email, eventUser(user.ID), "no password set", client)
+	}
+
+	if err := password.Verify(user.PasswordHash, plainPassword); err != nil {
+		if errors.Is(err, password.ErrMismatch) {
+			return nil, c.loginFailed(ctx, email, eventUser(user.ID), "wrong password", client)
+		}
+		return nil, fmt.Errorf("failed to verify password: %w", err)
+	}
+	c.upgradePasswordHash(ctx, user, plainPassword)
+	if err := c.checkEmailVerified(user); err != nil {
+		c.recordEvent(ctx, client, &entities.AuthEvent{
+			Type:    entities.AuthEventLogin,
+			Outcome: entities.AuthEventFailure,
+			UserID:  eventUser(user.ID),
+			Email:   email,
+			Detail:  "email not verified",
+		})
+		return nil, err
+	}
+
+	// With MFA the counter is only cleared once the second factor is verified too,
+	// so knowing the password does not reset the budget for guessing codes.
+	if user.MFAEnabled {
+		return c.newMFAChallenge(ctx, user)
+	}
+
+	// A successful login clears the account's counter; the address keeps its own
+	// so one valid account cannot be used to mask stuffing against others.
+	emailKey, _ := throttleKeys(email, "")
+	if err := c.throttleRepo.Reset(ctx, emailKey); err != nil {
+		return nil, fmt.Errorf("failed to reset login throttle: %w", err)
+	}
+
+	authTokens, err := c.startSession(ctx, user, "password", client)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Tokens: authTokens}, nil
+}
+
+// loginFailed records a failed attempt and returns the error to report to the caller.
+func (c *AuthController) loginFailed(ctx context.Context, email string, userID *uint, reason string, client ClientInfo) error {
+	if err := c.recordLoginFailure(ctx, email, userID, reason, client); err != nil {
+		return err
+	}
+	return ErrInvalidCredentials
+}
+
+// normalizeEmail trims surrounding whitespace and lower-cases the address
+// so lookups are not sensitive to how the user typed it.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"github.com/himakhaitan/noreboothq/services/auth/tokens"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+	"github.com/himakhaitan/noreboothq/shared/opaque"
+)
+
+// Logout revokes the caller's access token and, if given, the refresh token family it was issued with,
+// which ends that session.
+// A refresh token that is unknown or belongs to another user is ignored. Callers authenticated with
+// an API key get ErrAPIKeyNotRevocable, since the revocation list does not apply to keys.
+func (c *AuthController) Logout(ctx context.Context, principal *authn.Principal, rawRefreshToken string, client ClientInfo) error {
+	// An API key's jti is its prefix.
+	if tokens.IsAPIKey(principal.TokenID) {
+		return ErrAPIKeyNotRevocable
+	}
+	if err := c.revokeAccessToken(ctx, principal); err != nil {
+		return err
+	}
+
+	event := &entities.AuthEvent{
+		Type:      entities.AuthEventTokenRevocation,
+		Outcome:   entities.AuthEventSuccess,
+		UserID:    principalUser(principal),
+		SessionID: principal.SessionID,
+		Detail:    "logout",
+	}
+
+	if rawRefreshToken != "" {
+		refresh, err := c.refreshRepo.GetByHash(ctx, opaque.Hash(rawRefreshToken))
+		switch {
+		case err == nil && subjectFor(refresh.UserID) == principal.Subject:
+			if err := c.revokeSession(ctx, refresh.FamilyID); err != nil {
+				return err
+			}
+			event.SessionID = refresh.FamilyID
+		case err != nil && !errors.Is(err, repository.ErrNotFound):
+			return fmt.Errorf("failed to look up refresh token: %w", err)
+		}
+	}
+
+	c.recordEvent(ctx, client, event)
+	return nil
+}
+
+// RevokeToken puts an access token on the revocation list so it is rejected immediately.
+// The token may be given either in full or by its jti; a bare jti is kept on the list for
+// the maximum access token lifetime since its real expiry is unknown. API keys, given in full or
+// by prefix, get ErrAPIKeyNotRevocable.
+func (c *AuthController) RevokeToken(ctx context.Context, rawToken string, tokenID string, client ClientInfo) error {
+	if tokens.IsAPIKey(rawToken) || tokens.IsAPIKey(tokenID) {
+		return ErrAPIKeyNotRevocable
+	}
+	target := &authn.Principal{
+		TokenID:   tokenID,
+		ExpiresAt: time.Now().Add(c.tokens.TTL()),
+	}
+	if rawToken != "" {
+		claims, err := c.tokens.Parse(rawToken)
+		if err != nil {
+			return err
+		}
+		target = claims.Principal()
+	}
+
+	if err := c.revokeAccessToken(ctx, target); err != nil {
+		return err
+	}
+	c.recordEvent(ctx, client, &entities.AuthEvent{
+		Type:      entities.AuthEventTokenRevocation,
+		Outcome:   entities.AuthEventSuccess,
+		UserID:    principalUser(target),
+		SessionID: target.SessionID,
+		Detail:    "access token " + target.TokenID + " revoked",
+	})
+	return nil
+}
+
+// revokeAccessToken adds the principal's token to the revocation list until it expires.
+func (c *AuthController) revokeAccessToken(ctx context.Context, principal *authn.Principal) error {
+	entry := &entities.RevokedToken{
+		JTI:       principal.TokenID,
+		ExpiresAt: principal.ExpiresAt,
+	}
+	if userID := principalUser(principal); userID != nil {
+		entry.UserID = *userID
+	}
+
+	if err := c.revokedRepo.Revoke(ctx, entry); err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+
+	c.revoked.Set(principal.TokenID, true)
+	return nil
+}
+
+// principalUser returns the ID of the user a token was issued to, or nil for other subjects
+// such as service accounts and tokens only known by their jti.
+func principalUser(principal *authn.Principal) *uint {
+	userID, err := strconv.ParseUint(principal.Subject, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return eventUser(uint(userID))
+}
@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/mfa"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+	"github.com/himakhaitan/noreboothq/shared/opaque"
+)
+
+// EnrollMFA generates a new TOTP secret for the caller and stores it encrypted.
+// The secret is not enforced until ConfirmMFA proves the user's authenticator produces valid codes.
+// Enrolling again before confirming replaces the pending secret.
+func (c *AuthController) EnrollMFA(ctx context.Context, principal *authn.Principal) (*mfa.Enrollment, error) {
+	if err := checkMFAChangeAllowed(principal); err != nil {
+		return nil, err
+	}
+	user, err := c.userForPrincipal(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	enrollment, err := c.mfa.NewEnrollment(user.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.userRepo.SetTOTPSecret(ctx, user.ID, enrollment.SealedSecret); err != nil {
+		return nil, fmt.Errorf("failed to store totp secret: %w", err)
+	}
+	return enrollment, nil
+}
+
+// ConfirmMFA enables MFA for the caller once code matches the pending secret,
+// and returns a fresh set of recovery codes. The codes are only ever returned here.
+func (c *AuthController) ConfirmMFA(ctx context.Context, principal *authn.Principal, code string, client ClientInfo) ([]string, error) {
+	if err := checkMFAChangeAllowed(principal); err != nil {
+		return nil, err
+	}
+	user, err := c.userForPrincipal(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	step, ok, err := c.mfa.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate totp code: %w", err)
+	}
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := c.mfa.NewRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	recoveryCodes := make([]entities.RecoveryCode, len(hashes))
+	for i, hash := range hashes {
+		recoveryCodes[i] = entities.RecoveryCode{UserID: user.ID, CodeHash: hash}
+	}
+
+	if err := c.userRepo.EnableMFA(ctx, user.ID, user.TOTPSecret, step, recoveryCodes); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			// The secret was re-enrolled or confirmed by a concurrent request.
+			return nil, ErrInvalidMFACode
+		}
+		return nil, fmt.Errorf("failed to enable mfa: %w", err)
+	}
+	c.recordEvent(ctx, client, &entities.AuthEvent{
+		Type:    entities.AuthEventMFAChange,
+		Outcome: entities.AuthEventSuccess,
+		UserID:  eventUser(user.ID),
+		Email:   user.Email,
+		Detail:  "mfa enabled",
+	})
+	return codes, nil
+}
+
+// DisableMFA turns MFA off for the caller after checking a current TOTP or recovery code,
+// so a stolen access token alone cannot remove the second factor. Wrong codes count towards the
+// same lockout as wrong passwords, so the code cannot be guessed either.
+func (c *AuthController) DisableMFA(ctx context.Context, principal *authn.Principal, code string, client ClientInfo) error {
+	if err := checkMFAChangeAllowed(principal); err != nil {
+		return err
+	}
+	user, err := c.userForPrincipal(ctx, principal)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+	if err := c.checkLoginLockout(ctx, user.Email, eventUser(user.ID), client); err != nil {
+		return err
+	}
+
+	event := &entities.AuthEvent{
+		Type:   entities.AuthEventMFAChange,
+		UserID: eventUser(user.ID),
+		Email:  user.Email,
+	}
+	if err := c.verifySecondFactor(ctx, user, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			event.Outcome, event.Detail = entities.AuthEventFailure, "invalid mfa code"
+			c.recordEvent(ctx, client, event)
+			if err := c.recordLoginFailure(ctx, user.Email, eventUser(user.ID), "invalid mfa code while disabling mfa", client); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+
+	if err := c.userRepo.DisableMFA(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to disable mfa: %w", err)
+	}
+	event.Outcome, event.Detail = entities.AuthEventSuccess, "mfa disabled"
+	c.recordEvent(ctx, client, event)
+	return nil
+}
+
+// checkMFAChangeAllowed rejects impersonation and audience-restricted tokens, so only the user
+// themselves, signed in to the auth service, can change their second factor.
+func checkMFAChangeAllowed(principal *authn.Principal) error {
+	if principal.Impersonated() {
+		return ErrImpersonationNotAllowed
+	}
+	if len(principal.Audience) > 0 {
+		return fmt.Errorf("%w: audience-restricted tokens cannot change mfa", ErrScopeNotAllowed)
+	}
+	return nil
+}
+
+// VerifyMFA completes a login that returned an MFA challenge. The challenge token is single-use
+// and short-lived; wrong codes count towards the same lockout as wrong passwords.
+func (c *AuthController) VerifyMFA(ctx context.Context, rawChallenge string, code string, client ClientInfo) (*AuthTokens, error) {
+	challenge, err := c.challengeRepo.GetByHash(ctx, opaque.Hash(rawChallenge))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidMFAToken
+		}
+		return nil, fmt.Errorf("failed to look up mfa challenge: %w", err)
+	}
+	if challenge.UsedAt != nil || time.Now().After(challenge.ExpiresAt) {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := c.userRepo.GetByID(ctx, challenge.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidMFAToken
+		}
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+
+	if err := c.checkLoginLockout(ctx, user.Email, eventUser(user.ID), client); err != nil {
+		return nil, err
+	}
+
+	if err := c.verifySecondFactor(ctx, user, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			if err := c.recordLoginFailure(ctx, user.Email, eventUser(user.ID), "invalid mfa code", client); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	if err := c.challengeRepo.MarkUsed(ctx, challenge.ID); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, ErrInvalidMFAToken
+		}
+		return nil, fmt.Errorf("failed to complete mfa challenge: %w", err)
+	}
+
+	emailKey, _ := throttleKeys(user.Email, "")
+	if err := c.throttleRepo.Reset(ctx, emailKey); err != nil {
+		return nil, fmt.Errorf("failed to reset login throttle: %w", err)
+	}
+
+	return c.startSession(ctx, user, "mfa", client)
+}
+
+// newMFAChallenge starts the second step of a login for a user with MFA enabled.
+func (c *AuthController) newMFAChallenge(ctx context.Context, user *entities.User) (*LoginResult, error) {
+	raw, hash, err := opaque.New()
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := &entities.MFAChallenge{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(c.mfa.ChallengeTTL()),
+	}
+	if err := c.challengeRepo.Create(ctx, challenge); err != nil {
+		return nil, fmt.Errorf("failed to store mfa challenge: %w", err)
+	}
+
+	return &LoginResult{MFAToken: raw, MFAExpiresIn: c.mfa.ChallengeTTL()}, nil
+}
+
+// verifySecondFactor checks a TOTP code or, failing the TOTP shape, a recovery code.
+// Accepted TOTP steps and recovery codes are consumed so neither can be replayed.
+func (c *AuthController) verifySecondFactor(ctx context.Context, user *entities.User, code string) error {
+	if !mfa.IsTOTPCode(code) {
+		err := c.recoveryRepo.Consume(ctx, user.ID, mfa.HashRecoveryCode(code))
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidMFACode
+		}
+		if err != nil {
+			return fmt.Errorf("failed to consume recovery code: %w", err)
+		}
+		return nil
+	}
+
+	step, ok, err := c.mfa.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to validate totp code: %w", err)
+	}
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	if err := c.userRepo.AdvanceTOTPStep(ctx, user.ID, step); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return ErrInvalidMFACode
+		}
+		return fmt.Errorf("failed to record totp step: %w", err)
+	}
+	return nil
+}
+
+// userForPrincipal loads the user an access token was issued to.
+func (c *AuthController) userForPrincipal(ctx context.Context, principal *authn.Principal) (*entities.User, error) {
+	userID, err := strconv.ParseUint(principal.Subject, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidAccessToken
+	}
+
+	user, err := c.userRepo.GetByID(ctx, uint(userID))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidAccessToken
+		}
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	return user, nil
+}
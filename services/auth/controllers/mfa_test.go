@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/config"
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/mfa"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+)
+
+// totpUsers records the last accepted TOTP step like the Postgres repository does.
+type totpUsers struct {
+	repository.UserRepository
+	lastStep map[uint]int64
+}
+
+func (r *totpUsers) AdvanceTOTPStep(_ context.Context, id uint, step int64) error {
+	if step <= r.lastStep[id] {
+		return repository.ErrConflict
+	}
+	r.lastStep[id] = step
+	return nil
+}
+
+// recoveryCodes holds unused recovery code hashes per user.
+type recoveryCodes struct {
+	unused map[uint]map[string]bool
+}
+
+func (r *recoveryCodes) Consume(_ context.Context, userID uint, codeHash string) error {
+	if !r.unused[userID][codeHash] {
+		return repository.ErrNotFound
+	}
+	delete(r.unused[userID], codeHash)
+	return nil
+}
+
+func TestVerifySecondFactor(t *testing.T) {
+	manager, err := mfa.NewManager(config.MFAConfig{
+		Issuer:               "NoRebootHQ",
+		EncryptionKeyFile:    filepath.Join(t.TempDir(), "mfa.key"),
+		GenerateKeyIfMissing: true,
+		ChallengeTTL:         5 * time.Minute,
+		RecoveryCodes:        2,
+	})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	enrollment, err := manager.NewEnrollment("bob@example.com")
+	if err != nil {
+		t.Fatalf("NewEnrollment: %v", err)
+	}
+	codes, hashes, err := manager.NewRecoveryCodes()
+	if err != nil {
+		t.Fatalf("NewRecoveryCodes: %v", err)
+	}
+
+	user := &entities.User{TOTPSecret: enrollment.SealedSecret, MFAEnabled: true}
+	user.ID = 7
+	c := &AuthController{
+		mfa:          manager,
+		userRepo:     &totpUsers{lastStep: map[uint]int64{}},
+		recoveryRepo: &recoveryCodes{unused: map[uint]map[string]bool{7: {hashes[0]: true, hashes[1]: true}}},
+	}
+	ctx := context.Background()
+	code := totpCode(t, enrollment.Secret, time.Now())
+
+	steps := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{name: "current totp code", code: code},
+		{name: "same totp code replayed", code: code, wantErr: ErrInvalidMFACode},
+		{name: "wrong totp code", code: wrongCode(t, code), wantErr: ErrInvalidMFACode},
+		{name: "recovery code", code: codes[0]},
+		{name: "recovery code reused", code: codes[0], wantErr: ErrInvalidMFACode},
+		{name: "other recovery code typed loosely", code: strings.ToUpper(strings.ReplaceAll(codes[1], "-", ""))},
+		{name: "unknown recovery code", code: "abcde-fghjk", wantErr: ErrInvalidMFACode},
+	}
+	// The steps share state, so each depends on the ones before it.
+	for _, step := range steps {
+		err := c.verifySecondFactor(ctx, user, step.code)
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: verifySecondFactor() error = %v, want %v", step.name, err, step.wantErr)
+		}
+	}
+}
+
+// totpCode computes the RFC 6238 code of a base32 secret at the given time.
+func totpCode(t *testing.T, secret string, now time.Time) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatalf("failed to decode totp secret: %v", err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(now.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1_000_000)
+}
+
+// wrongCode returns a six-digit code that differs from code.
+func wrongCode(t *testing.T, code string) string {
+	t.Helper()
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		t.Fatalf("not a totp code: %q", code)
+	}
+	return fmt.Sprintf("%06d", (n+1)%1_000_000)
+}
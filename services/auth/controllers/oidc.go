@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/oidc"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"github.com/himakhaitan/noreboothq/shared/opaque"
+)
+
+// OIDCAuthorization is a started sign-in with an external identity provider.
+// The client sends the user to URL and, when the provider redirects back, passes the
+// code and state it received to CompleteOIDCLogin before ExpiresIn has passed.
+type OIDCAuthorization struct {
+	URL       string
+	State     string
+	ExpiresIn time.Duration
+}
+
+// StartOIDCLogin begins an authorization code login with PKCE at the named provider.
+// The state, nonce and code verifier are kept server-side so any replica can complete the login.
+func (c *AuthController) StartOIDCLogin(ctx context.Context, providerName string) (*OIDCAuthorization, error) {
+	provider, ok := c.oidcProviders[providerName]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	state, stateHash, err := opaque.New()
+	if err != nil {
+		return nil, err
+	}
+	nonce, _, err := opaque.New()
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build authorization url: %w", err)
+	}
+
+	login := &entities.OIDCLogin{
+		StateHash:    stateHash,
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(c.oidc.StateTTL),
+	}
+	if err := c.oidcLoginRepo.Create(ctx, login); err != nil {
+		return nil, fmt.Errorf("failed to store oidc login: %w", err)
+	}
+
+	return &OIDCAuthorization{URL: authURL, State: state, ExpiresIn: c.oidc.StateTTL}, nil
+}
+
+// CompleteOIDCLogin finishes a login started by StartOIDCLogin. It redeems the code at the provider,
+// verifies the ID token and signs in the linked user, provisioning one on first sign-in.
+// As with Login, users with MFA enabled get an MFA challenge instead of tokens.
+func (c *AuthController) CompleteOIDCLogin(ctx context.Context, state string, code string, client ClientInfo) (*LoginResult, error) {
+	login, err := c.oidcLoginRepo.GetByHash(ctx, opaque.Hash(state))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidOIDCState
+		}
+		return nil, fmt.Errorf("failed to look up oidc login: %w", err)
+	}
+	if login.UsedAt != nil || time.Now().After(login.ExpiresAt) {
+		return nil, ErrInvalidOIDCState
+	}
+	if err := c.oidcLoginRepo.MarkUsed(ctx, login.ID); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, ErrInvalidOIDCState
+		}
+		return nil, fmt.Errorf("failed to consume oidc login: %w", err)
+	}
+
+	provider, ok := c.oidcProviders[login.Provider]
+	if !ok {
+		// The provider was removed from the config since the login started.
+		return nil, ErrInvalidOIDCState
+	}
+
+	rawIDToken, err := provider.Exchange(ctx, code, login.CodeVerifier)
+	if err != nil {
+		return nil, c.oidcLoginFailed(ctx, provider, "", fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err), client)
+	}
+	idToken, err := provider.VerifyIDToken(ctx, rawIDToken, login.Nonce)
+	if err != nil {
+		return nil, c.oidcLoginFailed(ctx, provider, "", fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err), client)
+	}
+
+	// The email decides the domain policy and account linking, so it must be one the provider vouches for.
+	if idToken.Email == "" || !idToken.EmailVerified {
+		err := fmt.Errorf("%w: provider did not return a verified email", ErrEmailDomainNotAllowed)
+		return nil, c.oidcLoginFailed(ctx, provider, idToken.Email, err, client)
+	}
+	if !provider.AllowsEmail(idToken.Email) {
+		err := fmt.Errorf("%w: %s", ErrEmailDomainNotAllowed, idToken.Email)
+		return nil, c.oidcLoginFailed(ctx, provider, idToken.Email, err, client)
+	}
+
+	user, err := c.federatedUser(ctx, provider.Name(), idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MFAEnabled {
+		return c.newMFAChallenge(ctx, user)
+	}
+
+	authTokens, err := c.startSession(ctx, user, "oidc:"+provider.Name(), client)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Tokens: authTokens}, nil
+}
+
+// oidcLoginFailed audits a sign-in the identity provider did not vouch for and returns err.
+func (c *AuthController) oidcLoginFailed(ctx context.Context, provider *oidc.Provider, email string, err error, client ClientInfo) error {
+	c.recordEvent(ctx, client, &entities.AuthEvent{
+		Type:    entities.AuthEventLogin,
+		Outcome: entities.AuthEventFailure,
+		Email:   email,
+		Detail:  fmt.Sprintf("oidc:%s: %v", provider.Name(), err),
+	})
+	return err
+}
+
+// federatedUser returns the user linked to the external identity. An identity seen for the first time
+// is linked to the user with the same email or, if there is none, to a newly provisioned user.
+// Concurrent first sign-ins race on the unique indexes; the loser retries and finds the winner's records.
+func (c *AuthController) federatedUser(ctx context.Context, providerName string, idToken *oidc.IDToken) (*entities.User, error) {
+	for attempt := 0; ; attempt++ {
+		user, err := c.findOrProvisionUser(ctx, providerName, idToken)
+		if errors.Is(err, repository.ErrDuplicate) && attempt == 0 {
+			continue
+		}
+		return user, err
+	}
+}
+
+// findOrProvisionUser is one attempt of federatedUser. It returns repository.ErrDuplicate on a lost race.
+func (c *AuthController) findOrProvisionUser(ctx context.Context, providerName string, idToken *oidc.IDToken) (*entities.User, error) {
+	identity, err := c.identityRepo.GetByProviderSubject(ctx, providerName, idToken.Subject)
+	switch {
+	case err == nil:
+		if identity.Email != idToken.Email {
+			if err := c.identityRepo.UpdateEmail(ctx, identity.ID, idToken.Email); err != nil {
+				return nil, fmt.Errorf("failed to update external identity: %w", err)
+			}
+		}
+		user, err := c.userRepo.GetByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up user: %w", err)
+		}
+		return user, nil
+	case !errors.Is(err, repository.ErrNotFound):
+		return nil, fmt.Errorf("failed to look up external identity: %w", err)
+	}
+
+	identity = &entities.ExternalIdentity{
+		Provider: providerName,
+		Subject:  idToken.Subject,
+		Email:    idToken.Email,
+	}
+
+	user, err := c.userRepo.GetByEmail(ctx, idToken.Email)
+	switch {
+	case err == nil:
+		identity.UserID = user.ID
+		if err := c.identityRepo.Create(ctx, identity); err != nil {
+			return nil, fmt.Errorf("failed to link external identity: %w", err)
+		}
+		// Only verified emails get this far, so the provider vouches for the address.
+		if err := c.markEmailVerified(ctx, user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	case !errors.Is(err, repository.ErrNotFound):
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+
+	// Just-in-time provisioning. Federated users have no password, so Login always rejects them.
+	user = &entities.User{Email: idToken.Email, EmailVerified: true}
+	if err := c.userRepo.CreateWithIdentity(ctx, user, identity); err != nil {
+		return nil, fmt.Errorf("failed to provision user: %w", err)
+	}
+	return user, nil
+}
@@ -0,0 +1,298 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/config"
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/oidc"
+	"github.com/himakhaitan/noreboothq/services/auth/oidc/oidctest"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+)
+
+// memIdentities keeps external identities in memory, unique by provider and subject.
+type memIdentities struct {
+	byID   map[uint]*entities.ExternalIdentity
+	nextID uint
+}
+
+func (r *memIdentities) Create(_ context.Context, identity *entities.ExternalIdentity) error {
+	for _, existing := range r.byID {
+		if existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			return repository.ErrDuplicate
+		}
+	}
+	r.nextID++
+	identity.ID = r.nextID
+	stored := *identity
+	r.byID[identity.ID] = &stored
+	return nil
+}
+
+func (r *memIdentities) GetByProviderSubject(_ context.Context, provider string, subject string) (*entities.ExternalIdentity, error) {
+	for _, identity := range r.byID {
+		if identity.Provider == provider && identity.Subject == subject {
+			found := *identity
+			return &found, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memIdentities) UpdateEmail(_ context.Context, id uint, email string) error {
+	identity, ok := r.byID[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	identity.Email = email
+	return nil
+}
+
+// oidcUsers adds provisioning with a linked identity to memUsers.
+type oidcUsers struct {
+	*memUsers
+	identities *memIdentities
+}
+
+func (r *oidcUsers) CreateWithIdentity(ctx context.Context, user *entities.User, identity *entities.ExternalIdentity) error {
+	if _, err := r.GetByEmail(ctx, user.Email); err == nil {
+		return repository.ErrDuplicate
+	}
+	r.add(user)
+	identity.UserID = user.ID
+	return r.identities.Create(ctx, identity)
+}
+
+// memOIDCLogins keeps started OIDC logins in memory, keyed by state hash.
+type memOIDCLogins struct {
+	repository.OIDCLoginRepository
+	byHash map[string]*entities.OIDCLogin
+	nextID uint
+}
+
+func (r *memOIDCLogins) Create(_ context.Context, login *entities.OIDCLogin) error {
+	r.nextID++
+	login.ID = r.nextID
+	stored := *login
+	r.byHash[login.StateHash] = &stored
+	return nil
+}
+
+func (r *memOIDCLogins) GetByHash(_ context.Context, stateHash string) (*entities.OIDCLogin, error) {
+	login, ok := r.byHash[stateHash]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	found := *login
+	return &found, nil
+}
+
+func (r *memOIDCLogins) MarkUsed(_ context.Context, id uint) error {
+	for _, login := range r.byHash {
+		if login.ID == id {
+			if login.UsedAt != nil {
+				return repository.ErrConflict
+			}
+			now := time.Now()
+			login.UsedAt = &now
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+// oidcHarness is a controller signing users in through a mock issuer configured as provider "mock".
+type oidcHarness struct {
+	c          *AuthController
+	issuer     *oidctest.Issuer
+	users      *memUsers
+	identities *memIdentities
+	logins     *memOIDCLogins
+	events     *memAuthEvents
+}
+
+func newOIDCHarness(t *testing.T) *oidcHarness {
+	t.Helper()
+	issuer, err := oidctest.NewIssuer("noreboothq", "client-secret")
+	if err != nil {
+		t.Fatalf("NewIssuer: %v", err)
+	}
+	t.Cleanup(issuer.Close)
+
+	provider, err := oidc.NewProvider("mock", config.OIDCProviderConfig{
+		Issuer:         issuer.URL(),
+		ClientID:       issuer.ClientID,
+		ClientSecret:   issuer.ClientSecret,
+		RedirectURL:    "https://app.example.com/oidc/callback",
+		AllowedDomains: []string{"example.com"},
+	})
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+
+	c, users, _, _, events := sessionTestController(t)
+	identities := &memIdentities{byID: map[uint]*entities.ExternalIdentity{}}
+	logins := &memOIDCLogins{byHash: map[string]*entities.OIDCLogin{}}
+	c.userRepo = &oidcUsers{memUsers: users, identities: identities}
+	c.identityRepo = identities
+	c.oidcLoginRepo = logins
+	c.oidcProviders = map[string]*oidc.Provider{"mock": provider}
+	c.oidc = config.OIDCConfig{StateTTL: 10 * time.Minute}
+
+	return &oidcHarness{c: c, issuer: issuer, users: users, identities: identities, logins: logins, events: events}
+}
+
+// start begins a login and has the issuer sign the current user in, returning the code and state
+// the browser would bring back.
+func (h *oidcHarness) start(t *testing.T) (code string, state string) {
+	t.Helper()
+	authorization, err := h.c.StartOIDCLogin(context.Background(), "mock")
+	if err != nil {
+		t.Fatalf("StartOIDCLogin: %v", err)
+	}
+	code, state, err = h.issuer.Authorize(authorization.URL)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	if state != authorization.State {
+		t.Fatalf("issuer returned state %q, want %q", state, authorization.State)
+	}
+	return code, state
+}
+
+// signIn runs a whole login for the issuer's current user.
+func (h *oidcHarness) signIn(t *testing.T) (*LoginResult, error) {
+	t.Helper()
+	code, state := h.start(t)
+	return h.c.CompleteOIDCLogin(context.Background(), state, code, ClientInfo{})
+}
+
+func TestOIDCLoginProvisionsUser(t *testing.T) {
+	h := newOIDCHarness(t)
+	h.issuer.SetUser(oidctest.User{Subject: "sub-1", Email: "new@example.com", EmailVerified: true})
+
+	result, err := h.signIn(t)
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if result.Tokens == nil {
+		t.Fatal("no tokens for a provisioned user")
+	}
+
+	user, err := h.users.GetByEmail(context.Background(), "new@example.com")
+	if err != nil {
+		t.Fatalf("provisioned user not found: %v", err)
+	}
+	if !user.EmailVerified || user.PasswordHash != "" {
+		t.Errorf("provisioned user = %+v, want a verified user without a password", user)
+	}
+	identity, err := h.identities.GetByProviderSubject(context.Background(), "mock", "sub-1")
+	if err != nil || identity.UserID != user.ID {
+		t.Fatalf("identity = %+v, %v; want one linked to user %d", identity, err, user.ID)
+	}
+
+	// Signing in again finds the same user through the identity.
+	if _, err := h.signIn(t); err != nil {
+		t.Fatalf("second CompleteOIDCLogin: %v", err)
+	}
+	if len(h.users.byID) != 1 {
+		t.Errorf("%d users after signing in twice, want 1", len(h.users.byID))
+	}
+}
+
+func TestOIDCLoginLinksExistingUserByEmail(t *testing.T) {
+	h := newOIDCHarness(t)
+	existing := &entities.User{Email: "alice@example.com", PasswordHash: "hash"}
+	h.users.add(existing)
+	h.issuer.SetUser(oidctest.User{Subject: "sub-alice", Email: "alice@example.com", EmailVerified: true})
+
+	if _, err := h.signIn(t); err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+
+	identity, err := h.identities.GetByProviderSubject(context.Background(), "mock", "sub-alice")
+	if err != nil || identity.UserID != existing.ID {
+		t.Fatalf("identity = %+v, %v; want one linked to user %d", identity, err, existing.ID)
+	}
+	user := h.users.byID[existing.ID]
+	if !user.EmailVerified {
+		t.Error("linked user's email not marked verified")
+	}
+	if user.PasswordHash != "hash" {
+		t.Error("linking changed the user's password")
+	}
+	if len(h.users.byID) != 1 {
+		t.Errorf("%d users after linking, want 1", len(h.users.byID))
+	}
+}
+
+func TestOIDCLoginRejectsStateReplay(t *testing.T) {
+	h := newOIDCHarness(t)
+	code, state := h.start(t)
+	ctx := context.Background()
+
+	if _, err := h.c.CompleteOIDCLogin(ctx, state, code, ClientInfo{}); err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if _, err := h.c.CompleteOIDCLogin(ctx, state, code, ClientInfo{}); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("replayed CompleteOIDCLogin err = %v, want ErrInvalidOIDCState", err)
+	}
+	if _, err := h.c.CompleteOIDCLogin(ctx, "unknown-state", code, ClientInfo{}); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("CompleteOIDCLogin with an unknown state err = %v, want ErrInvalidOIDCState", err)
+	}
+}
+
+func TestOIDCLoginRejectsUntrustedIdentities(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    oidctest.User
+		tamper  func(login *entities.OIDCLogin)
+		wantErr error
+	}{
+		{
+			name:    "disallowed domain",
+			user:    oidctest.User{Subject: "sub-1", Email: "mallory@evil.example", EmailVerified: true},
+			wantErr: ErrEmailDomainNotAllowed,
+		},
+		{
+			name:    "unverified email",
+			user:    oidctest.User{Subject: "sub-1", Email: "bob@example.com", EmailVerified: false},
+			wantErr: ErrEmailDomainNotAllowed,
+		},
+		{
+			// The ID token carries the nonce of the authorization request, which no longer matches the login.
+			name: "nonce mismatch",
+			user: oidctest.User{Subject: "sub-1", Email: "bob@example.com", EmailVerified: true},
+			tamper: func(login *entities.OIDCLogin) {
+				login.Nonce = "another-nonce"
+			},
+			wantErr: ErrOIDCLoginFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newOIDCHarness(t)
+			h.issuer.SetUser(tt.user)
+			code, state := h.start(t)
+			if tt.tamper != nil {
+				for _, login := range h.logins.byHash {
+					tt.tamper(login)
+				}
+			}
+
+			result, err := h.c.CompleteOIDCLogin(context.Background(), state, code, ClientInfo{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CompleteOIDCLogin = %+v, %v; want %v", result, err, tt.wantErr)
+			}
+			if len(h.users.byID) != 0 || len(h.identities.byID) != 0 {
+				t.Errorf("rejected login left %d users and %d identities", len(h.users.byID), len(h.identities.byID))
+			}
+			if len(h.events.events) != 1 || h.events.events[0].Outcome != entities.AuthEventFailure {
+				t.Errorf("events = %+v, want one failed login", h.events.events)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+)
+
+// OrganizationDirectory tells whether a user belongs to an organization. The identity service,
+// which owns organizations and their memberships, answers through services/auth/organizations.
+type OrganizationDirectory interface {
+	// IsMember asks on behalf of the user's session. orgID is the organization's ID as carried in org_id claims.
+	IsMember(ctx context.Context, subject string, sessionID string, orgID string) (bool, error)
+}
+
+// OrganizationToken is an access token for a session that now acts within a different organization.
+type OrganizationToken struct {
+	AccessToken string
+	TokenType   string
+	ExpiresIn   time.Duration
+	OrgID       string
+}
+
+// SwitchOrganization makes orgID the active organization of the caller's session, after checking with
+// the identity service that the user belongs to it, and returns an access token carrying it as org_id.
+// Tokens refreshed in the session carry it too. An empty orgID leaves every organization.
+// Access tokens issued before the switch keep their organization until they expire.
+func (c *AuthController) SwitchOrganization(ctx context.Context, principal *authn.Principal, orgID string, client ClientInfo) (*OrganizationToken, error) {
+	// Only a session remembers its organization; other tokens would lose it on their next refresh.
+	if principal.SessionID == "" || principal.Impersonated() {
+		return nil, ErrOrganizationSwitchNotAllowed
+	}
+	if len(principal.Audience) > 0 {
+		return nil, fmt.Errorf("%w: audience-restricted tokens cannot switch organization", ErrScopeNotAllowed)
+	}
+	user, err := c.userForPrincipal(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &entities.AuthEvent{
+		Type:      entities.AuthEventOrganizationSwitch,
+		Outcome:   entities.AuthEventFailure,
+		UserID:    eventUser(user.ID),
+		SessionID: principal.SessionID,
+		Detail:    "organization " + orgID,
+	}
+	if orgID == "" {
+		event.Detail = "no organization"
+	}
+
+	if orgID != "" {
+		if c.organizations == nil {
+			return nil, fmt.Errorf("%w: no identity service is configured", ErrOrganizationsUnavailable)
+		}
+		member, err := c.organizations.IsMember(ctx, principal.Subject, principal.SessionID, orgID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrOrganizationsUnavailable, err)
+		}
+		if !member {
+			c.recordEvent(ctx, client, event)
+			return nil, ErrNotOrganizationMember
+		}
+	}
+
+	if err := c.sessionRepo.SetOrganization(ctx, principal.SessionID, orgID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidAccessToken
+		}
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+
+	accessToken, err := c.tokens.IssueForSession(principal.Subject, principal.SessionID, orgID, c.tokenScopes(user))
+	if err != nil {
+		return nil, err
+	}
+
+	event.Outcome = entities.AuthEventSuccess
+	c.recordEvent(ctx, client, event)
+
+	return &OrganizationToken{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   c.tokens.TTL(),
+		OrgID:       orgID,
+	}, nil
+}
+
+// sessionOrganization returns the organization the session's refreshed access tokens act within.
+// Membership is checked again on every refresh: an organization the user has left is dropped from
+// the session, so removing someone from an organization takes effect within one access token lifetime.
+func (c *AuthController) sessionOrganization(ctx context.Context, familyID string) (string, error) {
+	session, err := c.sessionRepo.GetByFamilyID(ctx, familyID)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up session: %w", err)
+	}
+	if session.OrgID == "" {
+		return "", nil
+	}
+
+	if c.organizations != nil {
+		member, err := c.organizations.IsMember(ctx, subjectFor(session.UserID), familyID, session.OrgID)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrOrganizationsUnavailable, err)
+		}
+		if member {
+			return session.OrgID, nil
+		}
+	}
+
+	if err := c.sessionRepo.SetOrganization(ctx, familyID, ""); err != nil {
+		return "", fmt.Errorf("failed to update session: %w", err)
+	}
+	return "", nil
+}
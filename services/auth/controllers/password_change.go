@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/password"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+)
+
+// ChangePassword replaces the caller's password after checking their current one. The new password
+// must pass the password policy, breached password check included. Like a reset, it signs the user
+// out of every session, the one making the change too. Wrong current passwords count towards the
+// login lockout, so a stolen access token cannot be used to guess the password.
+func (c *AuthController) ChangePassword(ctx context.Context, principal *authn.Principal, currentPassword string, newPassword string, client ClientInfo) error {
+	if principal.Impersonated() {
+		return ErrImpersonationNotAllowed
+	}
+	if len(principal.Audience) > 0 {
+		return fmt.Errorf("%w: audience-restricted tokens cannot change passwords", ErrScopeNotAllowed)
+	}
+
+	user, err := c.userForPrincipal(ctx, principal)
+	if err != nil {
+		return err
+	}
+	if err := c.checkLoginLockout(ctx, user.Email, eventUser(user.ID), client); err != nil {
+		return err
+	}
+
+	// Users provisioned through an identity provider have no password to change; a reset sets one.
+	if user.PasswordHash == "" {
+		c.hasher.VerifyDummy(currentPassword)
+		return c.passwordChangeFailed(ctx, user, "no password set", client)
+	}
+	if err := password.Verify(user.PasswordHash, currentPassword); err != nil {
+		if errors.Is(err, password.ErrMismatch) {
+			return c.passwordChangeFailed(ctx, user, "wrong current password", client)
+		}
+		return fmt.Errorf("failed to verify password: %w", err)
+	}
+
+	if err := c.policy.Validate(newPassword); err != nil {
+		return err
+	}
+	hash, err := c.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := c.setPasswordAndRevokeSessions(ctx, user, hash); err != nil {
+		return err
+	}
+	c.recordEvent(ctx, client, &entities.AuthEvent{
+		Type:    entities.AuthEventPasswordChange,
+		Outcome: entities.AuthEventSuccess,
+		UserID:  eventUser(user.ID),
+		Email:   user.Email,
+		Detail:  "password changed, all sessions revoked",
+	})
+	return nil
+}
+
+// passwordChangeFailed charges a failed password change to the login lockout and returns ErrWrongPassword.
+func (c *AuthController) passwordChangeFailed(ctx context.Context, user *entities.User, reason string, client ClientInfo) error {
+	if err := c.recordLoginFailure(ctx, user.Email, eventUser(user.ID), reason, client); err != nil {
+		return err
+	}
+	return ErrWrongPassword
+}
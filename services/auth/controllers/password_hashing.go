@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"go.uber.org/zap"
+)
+
+// PasswordHashReport describes how many users' password hashes were made with the current
+// hashing settings and how many are still on legacy ones, waiting for their next login.
+type PasswordHashReport struct {
+	// CurrentScheme is the algorithm-and-parameters prefix new hashes are made with.
+	CurrentScheme string
+	CurrentUsers  int64
+	LegacyUsers   int64
+	// Schemes breaks the users down by the scheme of their hash, most common first.
+	Schemes []repository.PasswordSchemeCount
+}
+
+// ReportPasswordHashes counts users by the hashing settings of their stored password.
+// Users without a password, provisioned through an identity provider, are not counted.
+func (c *AuthController) ReportPasswordHashes(ctx context.Context) (*PasswordHashReport, error) {
+	counts, err := c.userRepo.CountByPasswordScheme(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count password hashes: %w", err)
+	}
+
+	report := &PasswordHashReport{CurrentScheme: c.hasher.CurrentScheme(), Schemes: counts}
+	for _, count := range counts {
+		if count.Scheme == report.CurrentScheme {
+			report.CurrentUsers += count.Users
+		} else {
+			report.LegacyUsers += count.Users
+		}
+	}
+	return report, nil
+}
+
+// upgradePasswordHash rehashes a just-verified password if its stored hash was made with an older
+// algorithm or parameters. Losing a race with a password change is fine: the new hash is current.
+// Other failures are logged rather than returned: the password was verified, so the login goes
+// ahead and the upgrade is retried the next time the user signs in.
+func (c *AuthController) upgradePasswordHash(ctx context.Context, user *entities.User, plainPassword string) {
+	if !c.hasher.NeedsRehash(user.PasswordHash) {
+		return
+	}
+
+	hash, err := c.hasher.Hash(plainPassword)
+	if err != nil {
+		c.logger.Error("Failed to rehash password", zap.Uint("user_id", user.ID), zap.Error(err))
+		return
+	}
+	if err := c.userRepo.RehashPassword(ctx, user.ID, user.PasswordHash, hash); err != nil {
+		if !errors.Is(err, repository.ErrConflict) {
+			c.logger.Error("Failed to upgrade password hash", zap.Uint("user_id", user.ID), zap.Error(err))
+		}
+		return
+	}
+	user.PasswordHash = hash
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"github.com/himakhaitan/noreboothq/shared/mailer"
+	"github.com/himakhaitan/noreboothq/shared/opaque"
+)
+
+// RequestPasswordReset emails a single-use reset link to the user with the given email.
+// It succeeds without sending anything for unknown emails and for users who sign in through an
+// identity provider, so callers cannot tell which accounts exist, and while the last link was sent
+// less than password_reset.reset_cooldown ago, so the endpoint cannot be used to flood an inbox.
+func (c *AuthController) RequestPasswordReset(ctx context.Context, email string, client ClientInfo) error {
+	email = normalizeEmail(email)
+	event := &entities.AuthEvent{
+		Type:    entities.AuthEventPasswordReset,
+		Outcome: entities.AuthEventFailure,
+		Email:   email,
+	}
+
+	user, err := c.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			event.Detail = "reset requested for unknown email"
+			c.recordEvent(ctx, client, event)
+			return nil
+		}
+		return fmt.Errorf("failed to look up user: %w", err)
+	}
+	event.UserID = eventUser(user.ID)
+	// Users provisioned through an identity provider sign in there; a reset would give them a password.
+	if user.PasswordHash == "" {
+		event.Detail = "reset requested for user without a password"
+		c.recordEvent(ctx, client, event)
+		return nil
+	}
+
+	latest, err := c.resetRepo.GetLatestByUser(ctx, user.ID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("failed to look up password reset token: %w", err)
+	}
+	if latest != nil && time.Since(latest.CreatedAt) < c.passwordReset.ResetCooldown {
+		event.Detail = "reset requested again within the cooldown"
+		c.recordEvent(ctx, client, event)
+		return nil
+	}
+
+	raw, hash, err := opaque.New()
+	if err != nil {
+		return err
+	}
+
+	resetToken := &entities.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(c.passwordReset.TokenTTL),
+	}
+	if err := c.resetRepo.Create(ctx, resetToken); err != nil {
+		return fmt.Errorf("failed to store password reset token: %w", err)
+	}
+
+	link, err := opaque.Link(c.passwordReset.ResetURL, raw)
+	if err != nil {
+		return fmt.Errorf("invalid password_reset reset_url: %w", err)
+	}
+
+	event.Outcome, event.Detail = entities.AuthEventSuccess, "reset requested"
+	c.recordEvent(ctx, client, event)
+
+	return c.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your NoRebootHQ password",
+		Body: fmt.Sprintf("Someone asked to reset the password for your NoRebootHQ account.\n\n"+
+			"Use this link within %s to choose a new password:\n\n%s\n\n"+
+			"If it wasn't you, ignore this email; your password has not changed.\n",
+			c.passwordReset.TokenTTL, link),
+	})
+}
+
+// ResetPassword sets a new password using a token from a reset email. The token is consumed,
+// and every existing session of the user is revoked: all refresh tokens and all access tokens
+// issued before the reset. Redeeming the token also verifies the user's email.
+func (c *AuthController) ResetPassword(ctx context.Context, rawToken string, newPassword string, client ClientInfo) error {
+	resetToken, err := c.resetRepo.GetByHash(ctx, opaque.Hash(rawToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidResetToken
+		}
+		return fmt.Errorf("failed to look up password reset token: %w", err)
+	}
+	if resetToken.UsedAt != nil || time.Now().After(resetToken.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	if err := c.policy.Validate(newPassword); err != nil {
+		return err
+	}
+
+	hash, err := c.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user, err := c.userRepo.GetByID(ctx, resetToken.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidResetToken
+		}
+		return fmt.Errorf("failed to look up user: %w", err)
+	}
+
+	if err := c.resetRepo.MarkUsed(ctx, resetToken.ID); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return ErrInvalidResetToken
+		}
+		return fmt.Errorf("failed to redeem password reset token: %w", err)
+	}
+
+	// The reset link reached the user's inbox, which proves they own the address.
+	if err := c.markEmailVerified(ctx, user); err != nil {
+		return err
+	}
+
+	if err := c.setPasswordAndRevokeSessions(ctx, user, hash); err != nil {
+		return err
+	}
+	c.recordEvent(ctx, client, &entities.AuthEvent{
+		Type:    entities.AuthEventPasswordReset,
+		Outcome: entities.AuthEventSuccess,
+		UserID:  eventUser(user.ID),
+		Email:   user.Email,
+		Detail:  "password reset, all sessions revoked",
+	})
+	return nil
+}
+
+// setPasswordAndRevokeSessions stores the new password hash and signs the user out everywhere.
+// The user's login lockout is cleared too, since they just proved control of their email.
+func (c *AuthController) setPasswordAndRevokeSessions(ctx context.Context, user *entities.User, passwordHash string) error {
+	now := time.Now()
+	if err := c.userRepo.UpdatePassword(ctx, user.ID, passwordHash, now); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if err := c.signOutEverywhere(ctx, user.ID, now); err != nil {
+		return err
+	}
+
+	emailKey, _ := throttleKeys(user.Email, "")
+	if err := c.throttleRepo.Reset(ctx, emailKey); err != nil {
+		return fmt.Errorf("failed to reset login throttle: %w", err)
+	}
+	return nil
+}
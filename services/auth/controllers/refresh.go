@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"github.com/himakhaitan/noreboothq/shared/opaque"
+)
+
+// Refresh exchanges a refresh token for a new access token and a rotated refresh token.
+// Presenting a token that has already been rotated is treated as theft: the whole token
+// family is revoked and ErrRefreshTokenReused is returned. The session's last use is updated
+// with the client the refresh came from.
+func (c *AuthController) Refresh(ctx context.Context, rawToken string, client ClientInfo) (*AuthTokens, error) {
+	current, err := c.refreshRepo.GetByHash(ctx, opaque.Hash(rawToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("failed to look up refresh token: %w", err)
+	}
+
+	if current.RevokedAt != nil || time.Now().After(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if current.RotatedAt != nil {
+		return nil, c.revokeReusedFamily(ctx, current, client)
+	}
+
+	user, err := c.userRepo.GetByID(ctx, current.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	if err := c.checkEmailVerified(user); err != nil {
+		return nil, err
+	}
+
+	// Record the use first, so the session exists before an access token naming it is handed out.
+	if err := c.touchSession(ctx, current, client); err != nil {
+		return nil, err
+	}
+	orgID, err := c.sessionOrganization(ctx, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokensOut, err := c.issueTokens(ctx, user, current.FamilyID, orgID, current)
+	if errors.Is(err, repository.ErrConflict) {
+		// Another request rotated this token between our read and write.
+		return nil, c.revokeReusedFamily(ctx, current, client)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.recordEvent(ctx, client, &entities.AuthEvent{
+		Type:      entities.AuthEventTokenRefresh,
+		Outcome:   entities.AuthEventSuccess,
+		Actor:     subjectFor(user.ID),
+		UserID:    eventUser(user.ID),
+		SessionID: current.FamilyID,
+	})
+	return tokensOut, nil
+}
+
+// revokeReusedFamily revokes every token in the family of a reused refresh token, and the session it belongs to.
+func (c *AuthController) revokeReusedFamily(ctx context.Context, token *entities.RefreshToken, client ClientInfo) error {
+	if err := c.revokeSession(ctx, token.FamilyID); err != nil {
+		return err
+	}
+	c.recordEvent(ctx, client, &entities.AuthEvent{
+		Type:      entities.AuthEventTokenRefresh,
+		Outcome:   entities.AuthEventFailure,
+		UserID:    eventUser(token.UserID),
+		SessionID: token.FamilyID,
+		Detail:    "refresh token reused, session revoked",
+	})
+	return fmt.Errorf("%w: family %s revoked", ErrRefreshTokenReused, token.FamilyID)
+}
+
+// issueTokens signs an access token for the user and stores a new refresh token in the given family.
+// If previous is set it is rotated out atomically; otherwise the refresh token starts the family.
+// The family ID doubles as the session ID carried in the access token's sid claim, and orgID, if set,
+// is the organization the token acts within.
+// Until the user verifies their email, the access token only carries the scopes the policy allows.
+func (c *AuthController) issueTokens(ctx context.Context, user *entities.User, familyID string, orgID string, previous *entities.RefreshToken) (*AuthTokens, error) {
+	accessToken, err := c.tokens.IssueForSession(subjectFor(user.ID), familyID, orgID, c.tokenScopes(user))
+	if err != nil {
+		return nil, err
+	}
+
+	rawRefresh, refreshHash, err := opaque.New()
+	if err != nil {
+		return nil, err
+	}
+
+	next := &entities.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: refreshHash,
+		ExpiresAt: time.Now().Add(c.tokens.RefreshTTL()),
+	}
+
+	if previous == nil {
+		err = c.refreshRepo.Create(ctx, next)
+	} else {
+		err = c.refreshRepo.Rotate(ctx, previous, next)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return &AuthTokens{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        c.tokens.TTL(),
+		RefreshToken:     rawRefresh,
+		RefreshExpiresIn: c.tokens.RefreshTTL(),
+	}, nil
+}
+
+// subjectFor formats a user ID as the sub claim of a token.
+func subjectFor(userID uint) string {
+	return strconv.FormatUint(uint64(userID), 10)
+}
+
+// newFamilyID returns a random identifier for a new refresh token family.
+func newFamilyID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token family id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,356 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/audit"
+	"github.com/himakhaitan/noreboothq/services/auth/config"
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"github.com/himakhaitan/noreboothq/services/auth/tokens"
+	"github.com/himakhaitan/noreboothq/shared/cache"
+	"github.com/himakhaitan/noreboothq/shared/opaque"
+	"go.uber.org/zap"
+)
+
+// memUsers keeps users in memory. Like the Postgres repository it hands out copies.
+type memUsers struct {
+	repository.UserRepository
+	byID   map[uint]*entities.User
+	nextID uint
+}
+
+func (r *memUsers) add(user *entities.User) {
+	r.nextID++
+	user.ID = r.nextID
+	stored := *user
+	r.byID[user.ID] = &stored
+}
+
+func (r *memUsers) GetByID(_ context.Context, id uint) (*entities.User, error) {
+	user, ok := r.byID[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	found := *user
+	return &found, nil
+}
+
+func (r *memUsers) GetByEmail(_ context.Context, email string) (*entities.User, error) {
+	for _, user := range r.byID {
+		if user.Email == email {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memUsers) MarkEmailVerified(_ context.Context, id uint) error {
+	user, ok := r.byID[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	user.EmailVerified = true
+	return nil
+}
+
+// memRefreshTokens keeps refresh tokens in memory with the rotation semantics of the Postgres repository.
+type memRefreshTokens struct {
+	byHash map[string]*entities.RefreshToken
+	nextID uint
+}
+
+func (r *memRefreshTokens) Create(_ context.Context, token *entities.RefreshToken) error {
+	r.nextID++
+	token.ID = r.nextID
+	stored := *token
+	r.byHash[token.TokenHash] = &stored
+	return nil
+}
+
+func (r *memRefreshTokens) GetByHash(_ context.Context, tokenHash string) (*entities.RefreshToken, error) {
+	token, ok := r.byHash[tokenHash]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	found := *token
+	return &found, nil
+}
+
+func (r *memRefreshTokens) Rotate(ctx context.Context, old *entities.RefreshToken, next *entities.RefreshToken) error {
+	stored, ok := r.byHash[old.TokenHash]
+	if !ok || stored.RotatedAt != nil || stored.RevokedAt != nil {
+		return repository.ErrConflict
+	}
+	now := time.Now()
+	stored.RotatedAt = &now
+	return r.Create(ctx, next)
+}
+
+func (r *memRefreshTokens) RevokeFamily(_ context.Context, familyID string) error {
+	now := time.Now()
+	for _, token := range r.byHash {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *memRefreshTokens) RevokeAllForUser(_ context.Context, userID uint) error {
+	now := time.Now()
+	for _, token := range r.byHash {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// memSessions keeps sessions in memory, keyed by family ID.
+type memSessions struct {
+	repository.SessionRepository
+	byFamily map[string]*entities.Session
+}
+
+func (r *memSessions) Create(_ context.Context, session *entities.Session) error {
+	stored := *session
+	r.byFamily[session.FamilyID] = &stored
+	return nil
+}
+
+func (r *memSessions) GetByFamilyID(_ context.Context, familyID string) (*entities.Session, error) {
+	session, ok := r.byFamily[familyID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	found := *session
+	return &found, nil
+}
+
+func (r *memSessions) Touch(_ context.Context, familyID string, at time.Time, ipAddress string, userAgent string) error {
+	session, ok := r.byFamily[familyID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	session.LastUsedAt = at
+	session.IPAddress = ipAddress
+	session.UserAgent = userAgent
+	return nil
+}
+
+func (r *memSessions) Revoke(_ context.Context, familyID string, at time.Time) error {
+	if session, ok := r.byFamily[familyID]; ok && session.RevokedAt == nil {
+		session.RevokedAt = &at
+	}
+	return nil
+}
+
+// memAuthEvents collects the audit trail.
+type memAuthEvents struct {
+	repository.AuthEventRepository
+	events []entities.AuthEvent
+}
+
+func (r *memAuthEvents) Create(_ context.Context, event *entities.AuthEvent) error {
+	r.events = append(r.events, *event)
+	return nil
+}
+
+// sessionTestController returns a controller that issues and refreshes sessions against in-memory repositories.
+func sessionTestController(t *testing.T) (*AuthController, *memUsers, *memRefreshTokens, *memSessions, *memAuthEvents) {
+	t.Helper()
+	keysDir := t.TempDir()
+	keys, err := tokens.LoadKeySet(keysDir, true)
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	manager, err := tokens.NewManager(config.JWTConfig{
+		Issuer:               "noreboothq-auth",
+		AccessTokenTTL:       15 * time.Minute,
+		RefreshTokenTTL:      24 * time.Hour,
+		KeysDir:              keysDir,
+		GenerateKeyIfMissing: true,
+	}, keys)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	users := &memUsers{byID: map[uint]*entities.User{}}
+	refreshTokens := &memRefreshTokens{byHash: map[string]*entities.RefreshToken{}}
+	sessions := &memSessions{byFamily: map[string]*entities.Session{}}
+	events := &memAuthEvents{}
+	c := &AuthController{
+		userRepo:        users,
+		refreshRepo:     refreshTokens,
+		sessionRepo:     sessions,
+		tokens:          manager,
+		audit:           audit.NewRecorder(events, zap.NewNop()),
+		logger:          zap.NewNop(),
+		revokedSessions: cache.New[string, bool](time.Minute, 100),
+	}
+	return c, users, refreshTokens, sessions, events
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	c, users, refreshTokens, sessions, _ := sessionTestController(t)
+	user := &entities.User{Email: "alice@example.com", EmailVerified: true}
+	users.add(user)
+	ctx := context.Background()
+	client := ClientInfo{IPAddress: "203.0.113.7", UserAgent: "test"}
+
+	first, err := c.startSession(ctx, user, "password", client)
+	if err != nil {
+		t.Fatalf("startSession: %v", err)
+	}
+	second, err := c.Refresh(ctx, first.RefreshToken, client)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("Refresh returned the presented refresh token")
+	}
+
+	old := refreshTokens.byHash[opaque.Hash(first.RefreshToken)]
+	next := refreshTokens.byHash[opaque.Hash(second.RefreshToken)]
+	if old.RotatedAt == nil {
+		t.Error("presented token not marked rotated")
+	}
+	if next == nil || next.FamilyID != old.FamilyID || next.RotatedAt != nil || next.RevokedAt != nil {
+		t.Errorf("new token = %+v, want an active token in family %s", next, old.FamilyID)
+	}
+	if session := sessions.byFamily[old.FamilyID]; session.RevokedAt != nil {
+		t.Error("session revoked by a plain refresh")
+	}
+
+	if _, err := c.Refresh(ctx, second.RefreshToken, client); err != nil {
+		t.Errorf("Refresh with the rotated-in token: %v", err)
+	}
+}
+
+func TestRefreshRejectsInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(token *entities.RefreshToken)
+		raw    string
+	}{
+		{name: "unknown", raw: "not-a-refresh-token"},
+		{name: "expired", tamper: func(token *entities.RefreshToken) {
+			token.ExpiresAt = time.Now().Add(-time.Second)
+		}},
+		{name: "revoked", tamper: func(token *entities.RefreshToken) {
+			now := time.Now()
+			token.RevokedAt = &now
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, users, refreshTokens, sessions, _ := sessionTestController(t)
+			user := &entities.User{Email: "alice@example.com", EmailVerified: true}
+			users.add(user)
+			ctx := context.Background()
+
+			issued, err := c.startSession(ctx, user, "password", ClientInfo{})
+			if err != nil {
+				t.Fatalf("startSession: %v", err)
+			}
+			raw := tt.raw
+			if raw == "" {
+				raw = issued.RefreshToken
+				tt.tamper(refreshTokens.byHash[opaque.Hash(raw)])
+			}
+
+			if _, err := c.Refresh(ctx, raw, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Fatalf("Refresh err = %v, want ErrInvalidRefreshToken", err)
+			}
+			for familyID, session := range sessions.byFamily {
+				if session.RevokedAt != nil {
+					t.Errorf("session %s revoked; an invalid token is not a reuse", familyID)
+				}
+			}
+		})
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	c, users, refreshTokens, sessions, events := sessionTestController(t)
+	user := &entities.User{Email: "alice@example.com", EmailVerified: true}
+	users.add(user)
+	ctx := context.Background()
+
+	first, err := c.startSession(ctx, user, "password", ClientInfo{})
+	if err != nil {
+		t.Fatalf("startSession: %v", err)
+	}
+	second, err := c.Refresh(ctx, first.RefreshToken, ClientInfo{})
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	// An attacker replays the token the legitimate client already rotated out.
+	if _, err := c.Refresh(ctx, first.RefreshToken, ClientInfo{IPAddress: "198.51.100.9"}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("Refresh with a rotated token err = %v, want ErrRefreshTokenReused", err)
+	}
+
+	familyID := refreshTokens.byHash[opaque.Hash(first.RefreshToken)].FamilyID
+	for hash, token := range refreshTokens.byHash {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			t.Errorf("token %s of the reused family still active", hash)
+		}
+	}
+	if sessions.byFamily[familyID].RevokedAt == nil {
+		t.Error("session of the reused family not revoked")
+	}
+	if revoked, ok := c.revokedSessions.Get(familyID); !ok || !revoked {
+		t.Error("session revocation not cached, so its access tokens keep verifying")
+	}
+
+	// The legitimate client's current token dies with the family.
+	if _, err := c.Refresh(ctx, second.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Refresh with the family's latest token err = %v, want ErrInvalidRefreshToken", err)
+	}
+
+	last := events.events[len(events.events)-1]
+	if last.Type != entities.AuthEventTokenRefresh || last.Outcome != entities.AuthEventFailure || last.SessionID != familyID {
+		t.Errorf("last event = %+v, want a failed token refresh for session %s", last, familyID)
+	}
+}
+
+// racingRefreshTokens lets another request rotate each token right after it is read.
+type racingRefreshTokens struct {
+	*memRefreshTokens
+}
+
+func (r *racingRefreshTokens) GetByHash(ctx context.Context, tokenHash string) (*entities.RefreshToken, error) {
+	token, err := r.memRefreshTokens.GetByHash(ctx, tokenHash)
+	if err == nil {
+		now := time.Now()
+		r.byHash[tokenHash].RotatedAt = &now
+	}
+	return token, err
+}
+
+func TestRefreshConcurrentRotationRevokesFamily(t *testing.T) {
+	c, users, refreshTokens, sessions, _ := sessionTestController(t)
+	user := &entities.User{Email: "alice@example.com", EmailVerified: true}
+	users.add(user)
+	ctx := context.Background()
+
+	issued, err := c.startSession(ctx, user, "password", ClientInfo{})
+	if err != nil {
+		t.Fatalf("startSession: %v", err)
+	}
+	c.refreshRepo = &racingRefreshTokens{refreshTokens}
+
+	if _, err := c.Refresh(ctx, issued.RefreshToken, ClientInfo{}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("Refresh losing the rotation race err = %v, want ErrRefreshTokenReused", err)
+	}
+	familyID := refreshTokens.byHash[opaque.Hash(issued.RefreshToken)].FamilyID
+	if sessions.byFamily[familyID].RevokedAt == nil {
+		t.Error("session not revoked after a lost rotation race")
+	}
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+)
+
+// Register creates a new user after validating the email and enforcing the password policy.
+// The email starts unverified.
+func (c *AuthController) Register(ctx context.Context, email string, plainPassword string) (*entities.User, error) {
+	return c.createUser(ctx, email, plainPassword, false)
+}
+
+// ProvisionUser creates a user for a trusted service that already proved the caller owns email,
+// such as the identity service accepting an invite sent to it. It is Register with the email
+// marked verified, so no verification email is needed.
+func (c *AuthController) ProvisionUser(ctx context.Context, email string, plainPassword string) (*entities.User, error) {
+	return c.createUser(ctx, email, plainPassword, true)
+}
+
+// GetUser returns the user with the given ID, for services that need to know who a subject is.
+func (c *AuthController) GetUser(ctx context.Context, userID uint) (*entities.User, error) {
+	user, err := c.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	return user, nil
+}
+
+// createUser validates the email, enforces the password policy and stores the new user.
+func (c *AuthController) createUser(ctx context.Context, email string, plainPassword string, emailVerified bool) (*entities.User, error) {
+	email = normalizeEmail(email)
+	if err := validateEmail(email); err != nil {
+		return nil, err
+	}
+
+	if err := c.policy.Validate(plainPassword); err != nil {
+		return nil, err
+	}
+
+	hash, err := c.hasher.Hash(plainPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &entities.User{
+		Email:         email,
+		PasswordHash:  hash,
+		EmailVerified: emailVerified,
+	}
+	if err := c.userRepo.Create(ctx, user); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrEmailTaken
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return user, nil
+}
+
+// validateEmail accepts only a bare RFC 5322 address, rejecting display names such as "Bob <bob@example.com>".
+func validateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return ErrInvalidEmail
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"github.com/himakhaitan/noreboothq/shared/opaque"
+)
+
+// serviceAccountSubjectPrefix marks the sub claim of tokens issued to service accounts,
+// so they can never be confused with user IDs.
+const serviceAccountSubjectPrefix = "sa:"
+
+// ClientCredentialsToken is the result of a successful OAuth2 client-credentials grant.
+type ClientCredentialsToken struct {
+	AccessToken string
+	TokenType   string
+	ExpiresIn   time.Duration
+	Scopes      []string
+}
+
+// CreateServiceAccount creates a service account with the given scopes and returns it together
+// with its client secret. The secret is only returned here.
+func (c *AuthController) CreateServiceAccount(ctx context.Context, name string, scopes []string) (*entities.ServiceAccount, string, error) {
+	scopes = normalizeScopes(scopes)
+	if len(scopes) == 0 {
+		return nil, "", ErrNoScopes
+	}
+
+	clientID, err := newClientID()
+	if err != nil {
+		return nil, "", err
+	}
+	secret, secretHash, err := opaque.New()
+	if err != nil {
+		return nil, "", err
+	}
+
+	account := &entities.ServiceAccount{
+		Name:       name,
+		ClientID:   clientID,
+		SecretHash: secretHash,
+		Scopes:     strings.Join(scopes, " "),
+	}
+	if err := c.serviceAccountRepo.Create(ctx, account); err != nil {
+		return nil, "", fmt.Errorf("failed to create service account: %w", err)
+	}
+	return account, secret, nil
+}
+
+// ListServiceAccounts returns every service account. Secrets are never returned.
+func (c *AuthController) ListServiceAccounts(ctx context.Context) ([]entities.ServiceAccount, error) {
+	accounts, err := c.serviceAccountRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+// DisableServiceAccount stops the account from obtaining tokens and rejects every token
+// it was already issued.
+func (c *AuthController) DisableServiceAccount(ctx context.Context, id uint) error {
+	if _, err := c.serviceAccountRepo.GetByID(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrServiceAccountNotFound
+		}
+		return fmt.Errorf("failed to look up service account: %w", err)
+	}
+
+	now := time.Now()
+	if err := c.serviceAccountRepo.Disable(ctx, id, now); err != nil {
+		return fmt.Errorf("failed to disable service account: %w", err)
+	}
+	c.sessionsRevoked.Set(serviceAccountSubject(id), now)
+	return nil
+}
+
+// ClientCredentials implements the OAuth2 client-credentials grant (RFC 6749 section 4.4).
+// It authenticates the service account and issues a short-lived access token, without a refresh
+// token, carrying the requested scopes or, if none are requested, all of the account's scopes.
+func (c *AuthController) ClientCredentials(ctx context.Context, clientID string, clientSecret string, requestedScopes []string) (*ClientCredentialsToken, error) {
+	account, err := c.serviceAccountRepo.GetByClientID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidClient
+		}
+		return nil, fmt.Errorf("failed to look up service account: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(opaque.Hash(clientSecret)), []byte(account.SecretHash)) != 1 {
+		return nil, ErrInvalidClient
+	}
+	if account.DisabledAt != nil {
+		return nil, ErrInvalidClient
+	}
+
+	scopes := account.ScopeList()
+	if requested := normalizeScopes(requestedScopes); len(requested) > 0 {
+		for _, scope := range requested {
+			if !account.HasScope(scope) {
+				return nil, fmt.Errorf("%w: %s", ErrScopeNotAllowed, scope)
+			}
+		}
+		scopes = requested
+	}
+
+	ttl := c.serviceAccounts.AccessTokenTTL
+	accessToken, err := c.tokens.IssueWithTTL(serviceAccountSubject(account.ID), scopes, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClientCredentialsToken{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   ttl,
+		Scopes:      scopes,
+	}, nil
+}
+
+// serviceAccountRevokedAt returns the time before which the service account's tokens are rejected:
+// the zero time for an active account, or when it was disabled.
+func (c *AuthController) serviceAccountRevokedAt(ctx context.Context, subject string) (time.Time, error) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(subject, serviceAccountSubjectPrefix), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: malformed subject", ErrInvalidAccessToken)
+	}
+
+	account, err := c.serviceAccountRepo.GetByID(ctx, uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return time.Time{}, fmt.Errorf("%w: unknown subject", ErrInvalidAccessToken)
+		}
+		return time.Time{}, fmt.Errorf("failed to check service account: %w", err)
+	}
+
+	if account.DisabledAt != nil {
+		return *account.DisabledAt, nil
+	}
+	return time.Time{}, nil
+}
+
+// serviceAccountSubject formats a service account ID as the sub claim of a token.
+func serviceAccountSubject(id uint) string {
+	return serviceAccountSubjectPrefix + strconv.FormatUint(uint64(id), 10)
+}
+
+// isServiceAccountSubject reports whether a sub claim names a service account rather than a user.
+func isServiceAccountSubject(subject string) bool {
+	return strings.HasPrefix(subject, serviceAccountSubjectPrefix)
+}
+
+// newClientID returns a random, public OAuth2 client ID.
+func newClientID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate client id: %w", err)
+	}
+	return "sa_" + hex.EncodeToString(b), nil
+}
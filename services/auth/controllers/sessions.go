@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"github.com/himakhaitan/noreboothq/services/auth/tokens"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+)
+
+// maxUserAgentLength caps the stored user agent; clients control it, so it is not trusted to be short.
+const maxUserAgentLength = 512
+
+// ClientInfo describes the device a request came from, as reported by the transport.
+// Either field may be empty.
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+// ListSessions returns the active sessions of a user, most recently used first. A zero userID lists
+// the caller's own sessions; listing another user's sessions requires the "auth.admin" scope.
+func (c *AuthController) ListSessions(ctx context.Context, principal *authn.Principal, userID uint) ([]entities.Session, error) {
+	if userID == 0 {
+		user, err := c.userForPrincipal(ctx, principal)
+		if err != nil {
+			return nil, err
+		}
+		userID = user.ID
+	} else if subjectFor(userID) != principal.Subject && !principal.HasScope(tokens.ScopeAuthAdmin) {
+		return nil, fmt.Errorf("%w: %s", ErrScopeNotAllowed, tokens.ScopeAuthAdmin)
+	}
+
+	sessions, err := c.sessionRepo.ListActiveByUser(ctx, userID, time.Now().Add(-c.tokens.RefreshTTL()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs a device out: its refresh tokens stop working and access tokens issued to it
+// are rejected from then on. Users may revoke their own sessions; "auth.admin" may revoke any.
+func (c *AuthController) RevokeSession(ctx context.Context, principal *authn.Principal, sessionID uint, client ClientInfo) error {
+	session, err := c.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrSessionNotFound
+		}
+		return fmt.Errorf("failed to look up session: %w", err)
+	}
+	if subjectFor(session.UserID) != principal.Subject && !principal.HasScope(tokens.ScopeAuthAdmin) {
+		return ErrSessionNotFound
+	}
+
+	if err := c.revokeSession(ctx, session.FamilyID); err != nil {
+		return err
+	}
+	c.recordEvent(ctx, client, &entities.AuthEvent{
+		Type:      entities.AuthEventTokenRevocation,
+		Outcome:   entities.AuthEventSuccess,
+		UserID:    eventUser(session.UserID),
+		SessionID: session.FamilyID,
+		Detail:    "session revoked",
+	})
+	return nil
+}
+
+// RevokeAllSessions signs a user out of every device: all refresh tokens are revoked and every
+// access token issued so far is rejected.
+func (c *AuthController) RevokeAllSessions(ctx context.Context, userID uint, client ClientInfo) error {
+	if _, err := c.userRepo.GetByID(ctx, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to look up user: %w", err)
+	}
+
+	now := time.Now()
+	if err := c.userRepo.RevokeSessions(ctx, userID, now); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if err := c.signOutEverywhere(ctx, userID, now); err != nil {
+		return err
+	}
+	c.recordEvent(ctx, client, &entities.AuthEvent{
+		Type:    entities.AuthEventTokenRevocation,
+		Outcome: entities.AuthEventSuccess,
+		UserID:  eventUser(userID),
+		Detail:  "all sessions revoked",
+	})
+	return nil
+}
+
+// startSession records a new session for the user on the given device and issues its first tokens.
+// It completes a login, which is audited with method describing how the user signed in.
+func (c *AuthController) startSession(ctx context.Context, user *entities.User, method string, client ClientInfo) (*AuthTokens, error) {
+	familyID, err := newFamilyID()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &entities.Session{
+		UserID:     user.ID,
+		FamilyID:   familyID,
+		UserAgent:  truncate(client.UserAgent, maxUserAgentLength),
+		IPAddress:  client.IPAddress,
+		LastUsedAt: time.Now(),
+	}
+	if err := c.sessionRepo.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	authTokens, err := c.issueTokens(ctx, user, familyID, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	c.recordEvent(ctx, client, &entities.AuthEvent{
+		Type:      entities.AuthEventLogin,
+		Outcome:   entities.AuthEventSuccess,
+		Actor:     subjectFor(user.ID),
+		UserID:    eventUser(user.ID),
+		Email:     user.Email,
+		SessionID: familyID,
+		Detail:    method,
+	})
+	return authTokens, nil
+}
+
+// touchSession records a refresh on the token's session. Families started before sessions
+// were tracked get their session on their first refresh, so their new access tokens verify.
+func (c *AuthController) touchSession(ctx context.Context, token *entities.RefreshToken, client ClientInfo) error {
+	now := time.Now()
+	userAgent := truncate(client.UserAgent, maxUserAgentLength)
+
+	err := c.sessionRepo.Touch(ctx, token.FamilyID, now, client.IPAddress, userAgent)
+	if errors.Is(err, repository.ErrNotFound) {
+		err = c.sessionRepo.Create(ctx, &entities.Session{
+			UserID:     token.UserID,
+			FamilyID:   token.FamilyID,
+			UserAgent:  userAgent,
+			IPAddress:  client.IPAddress,
+			LastUsedAt: now,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+// revokeSession revokes the session's refresh token family and marks the session revoked,
+// so its access tokens fail verification too.
+func (c *AuthController) revokeSession(ctx context.Context, familyID string) error {
+	if err := c.refreshRepo.RevokeFamily(ctx, familyID); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+	if err := c.sessionRepo.Revoke(ctx, familyID, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	c.revokedSessions.Set(familyID, true)
+	return nil
+}
+
+// signOutEverywhere revokes every refresh token and session of the user. The caller has already
+// stored at as the user's SessionsRevokedAt, which rejects their outstanding access tokens.
+func (c *AuthController) signOutEverywhere(ctx context.Context, userID uint, at time.Time) error {
+	c.sessionsRevoked.Set(subjectFor(userID), at)
+
+	if err := c.refreshRepo.RevokeAllForUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	if err := c.sessionRepo.RevokeAllForUser(ctx, userID, at); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+// isSessionRevoked reports whether the session an access token belongs to has been revoked,
+// answering from the in-process cache when possible. Unknown sessions count as revoked.
+func (c *AuthController) isSessionRevoked(ctx context.Context, familyID string) (bool, error) {
+	if revoked, ok := c.revokedSessions.Get(familyID); ok {
+		return revoked, nil
+	}
+
+	session, err := c.sessionRepo.GetByFamilyID(ctx, familyID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return false, fmt.Errorf("failed to check session revocation: %w", err)
+	}
+
+	revoked := session == nil || session.RevokedAt != nil
+	c.revokedSessions.Set(familyID, revoked)
+	return revoked, nil
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+)
+
+// LockedError is returned by Login while the account or the caller's address is locked out
+// after too many failed attempts. It matches ErrTooManyAttempts with errors.Is.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", ErrTooManyAttempts, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LockedError) Is(target error) bool {
+	return target == ErrTooManyAttempts
+}
+
+// throttleKeys returns the counter keys a login attempt is charged to.
+// Attempts without a known peer address are only counted per email.
+func throttleKeys(email string, peerAddr string) (emailKey string, ipKey string) {
+	emailKey = "email:" + email
+	if peerAddr != "" {
+		ipKey = "ip:" + peerAddr
+	}
+	return emailKey, ipKey
+}
+
+// checkLockout returns a LockedError if any of the non-empty keys is currently locked,
+// with the longest remaining lockout as the retry delay.
+func (c *AuthController) checkLockout(ctx context.Context, keys ...string) error {
+	keys = slices.DeleteFunc(keys, func(key string) bool { return key == "" })
+	throttles, err := c.throttleRepo.GetByKeys(ctx, keys)
+	if err != nil {
+		return fmt.Errorf("failed to look up login throttles: %w", err)
+	}
+
+	now := time.Now()
+	var retryAfter time.Duration
+	for _, t := range throttles {
+		if t.LockedUntil != nil && t.LockedUntil.After(now) {
+			retryAfter = max(retryAfter, t.LockedUntil.Sub(now))
+		}
+	}
+	if retryAfter > 0 {
+		return &LockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// checkLoginLockout returns a LockedError if the email or the client's address is locked out,
+// auditing the rejected attempt.
+func (c *AuthController) checkLoginLockout(ctx context.Context, email string, userID *uint, client ClientInfo) error {
+	emailKey, ipKey := throttleKeys(email, client.IPAddress)
+	err := c.checkLockout(ctx, emailKey, ipKey)
+	if errors.Is(err, ErrTooManyAttempts) {
+		c.recordEvent(ctx, client, &entities.AuthEvent{
+			Type:    entities.AuthEventLogin,
+			Outcome: entities.AuthEventFailure,
+			UserID:  userID,
+			Email:   email,
+			Detail:  "locked out",
+		})
+	}
+	return err
+}
+
+// recordLoginFailure audits a failed attempt and charges it to the email and the client's address,
+// locking whichever counter crossed its threshold.
+func (c *AuthController) recordLoginFailure(ctx context.Context, email string, userID *uint, reason string, client ClientInfo) error {
+	c.recordEvent(ctx, client, &entities.AuthEvent{
+		Type:    entities.AuthEventLogin,
+		Outcome: entities.AuthEventFailure,
+		UserID:  userID,
+		Email:   email,
+		Detail:  reason,
+	})
+
+	emailKey, ipKey := throttleKeys(email, client.IPAddress)
+	locks := []struct {
+		key         string
+		what        string
+		maxFailures int
+	}{
+		{emailKey, "email", c.lockout.MaxFailuresPerEmail},
+		{ipKey, "ip address", c.lockout.MaxFailuresPerIP},
+	}
+	for _, lock := range locks {
+		if lock.key == "" {
+			continue
+		}
+		lockedFor, err := c.recordFailure(ctx, lock.key, lock.maxFailures)
+		if err != nil {
+			return err
+		}
+		if lockedFor > 0 {
+			c.recordEvent(ctx, client, &entities.AuthEvent{
+				Type:    entities.AuthEventLockout,
+				Outcome: entities.AuthEventSuccess,
+				UserID:  userID,
+				Email:   email,
+				Detail:  fmt.Sprintf("%s locked for %s", lock.what, lockedFor),
+			})
+		}
+	}
+	return nil
+}
+
+// recordFailure increments the counter for key and locks it once maxFailures is reached,
+// returning how long it was locked for, or zero if it was not.
+// A zero maxFailures disables the counter.
+func (c *AuthController) recordFailure(ctx context.Context, key string, maxFailures int) (time.Duration, error) {
+	if maxFailures <= 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	throttle, err := c.throttleRepo.RecordFailure(ctx, key, now, c.lockout.FailureWindow)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %w", err)
+	}
+	if throttle.Failures < maxFailures {
+		return 0, nil
+	}
+
+	lockedFor := c.lockoutDuration(throttle.Failures - maxFailures)
+	if err := c.throttleRepo.Lock(ctx, key, now.Add(lockedFor)); err != nil {
+		return 0, fmt.Errorf("failed to lock %s: %w", key, err)
+	}
+	return lockedFor, nil
+}
+
+// lockoutDuration returns how long to lock after the given number of failures past the threshold:
+// the base duration doubled for each extra failure, capped at the maximum.
+func (c *AuthController) lockoutDuration(extraFailures int) time.Duration {
+	d := c.lockout.BaseDuration
+	for i := 0; i < extraFailures && d < c.lockout.MaxDuration; i++ {
+		d *= 2
+	}
+	if c.lockout.MaxDuration > 0 {
+		d = min(d, c.lockout.MaxDuration)
+	}
+	return d
+}
+
+// UnlockAccount clears the failed login counter and any lockout for the given email.
+// Unlocking an account that is not locked is a no-op.
+func (c *AuthController) UnlockAccount(ctx context.Context, email string, client ClientInfo) error {
+	email = normalizeEmail(email)
+	emailKey, _ := throttleKeys(email, "")
+	if err := c.throttleRepo.Reset(ctx, emailKey); err != nil {
+		return fmt.Errorf("failed to unlock account: %w", err)
+	}
+	c.recordEvent(ctx, client, &entities.AuthEvent{
+		Type:    entities.AuthEventLockout,
+		Outcome: entities.AuthEventSuccess,
+		Email:   email,
+		Detail:  "unlocked",
+	})
+	return nil
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/config"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	tests := []struct {
+		name          string
+		base, max     time.Duration
+		extraFailures int
+		want          time.Duration
+	}{
+		{name: "first lockout", base: 30 * time.Second, max: time.Hour, extraFailures: 0, want: 30 * time.Second},
+		{name: "one extra failure doubles", base: 30 * time.Second, max: time.Hour, extraFailures: 1, want: time.Minute},
+		{name: "three extra failures", base: 30 * time.Second, max: time.Hour, extraFailures: 3, want: 4 * time.Minute},
+		{name: "capped at max", base: 30 * time.Second, max: time.Hour, extraFailures: 7, want: time.Hour},
+		{name: "stays capped far past max", base: 30 * time.Second, max: time.Hour, extraFailures: 1000, want: time.Hour},
+		{name: "base above max is capped", base: 2 * time.Hour, max: time.Hour, extraFailures: 0, want: time.Hour},
+		{name: "no max keeps base", base: 30 * time.Second, max: 0, extraFailures: 5, want: 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &AuthController{lockout: config.LockoutConfig{BaseDuration: tt.base, MaxDuration: tt.max}}
+			if got := c.lockoutDuration(tt.extraFailures); got != tt.want {
+				t.Errorf("lockoutDuration(%d) = %s, want %s", tt.extraFailures, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+)
+
+// TokenTypeAccessToken is the RFC 8693 identifier of access tokens, the only token type
+// ExchangeToken accepts and issues.
+const TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+
+// ExchangedToken is the result of a successful token exchange.
+type ExchangedToken struct {
+	AccessToken     string
+	IssuedTokenType string
+	TokenType       string
+	ExpiresIn       time.Duration
+	Scopes          []string
+	Audience        string
+}
+
+// ExchangeToken trades a valid access token or API key for a down-scoped token restricted to
+// audience (RFC 8693). The new token keeps the original's subject, session and actor, carries
+// requestedScopes, which must be a subset of the original's and default to all of them, and lives
+// for requestedTTL, capped at token_exchange.max_ttl and at the original's remaining lifetime.
+// A token already restricted to some audiences can only be exchanged for one of them.
+func (c *AuthController) ExchangeToken(ctx context.Context, subjectToken string, audience string, requestedScopes []string, requestedTTL time.Duration, client ClientInfo) (*ExchangedToken, error) {
+	original, err := c.VerifyAccessToken(ctx, subjectToken)
+	if err != nil {
+		return nil, err
+	}
+	principal := original.Principal()
+
+	event := &entities.AuthEvent{
+		Type:         entities.AuthEventTokenExchange,
+		Outcome:      entities.AuthEventFailure,
+		Actor:        principal.Subject,
+		Impersonator: principal.Actor,
+		UserID:       principalUser(principal),
+		SessionID:    principal.SessionID,
+	}
+
+	if !principal.AllowsAudience(audience) {
+		event.Detail = "audience " + audience + " not allowed"
+		c.recordEvent(ctx, client, event)
+		return nil, fmt.Errorf("%w: %s", ErrAudienceNotAllowed, audience)
+	}
+
+	scopes := normalizeScopes(requestedScopes)
+	if len(scopes) == 0 {
+		scopes = normalizeScopes(principal.Scopes)
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(principal.Scopes, scope) {
+			event.Detail = "scope " + scope + " not allowed"
+			c.recordEvent(ctx, client, event)
+			return nil, fmt.Errorf("%w: %s", ErrScopeNotAllowed, scope)
+		}
+	}
+
+	ttl := c.tokenExchange.MaxTTL
+	if requestedTTL > 0 && requestedTTL < ttl {
+		ttl = requestedTTL
+	}
+	if !principal.ExpiresAt.IsZero() {
+		ttl = min(ttl, time.Until(principal.ExpiresAt).Truncate(time.Second))
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("%w: token is about to expire", ErrInvalidAccessToken)
+	}
+
+	accessToken, err := c.tokens.IssueExchanged(original, audience, scopes, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue exchanged token: %w", err)
+	}
+
+	event.Outcome = entities.AuthEventSuccess
+	event.Detail = fmt.Sprintf("audience %s, scope %q", audience, strings.Join(scopes, " "))
+	c.recordEvent(ctx, client, event)
+
+	return &ExchangedToken{
+		AccessToken:     accessToken,
+		IssuedTokenType: TokenTypeAccessToken,
+		TokenType:       "Bearer",
+		ExpiresIn:       ttl,
+		Scopes:          scopes,
+		Audience:        audience,
+	}, nil
+}
@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"github.com/himakhaitan/noreboothq/services/auth/tokens"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+)
+
+// VerifyAccessToken checks the token's signature and validity window and consults
+// the revocation list, the token's session and the session revocation time of the user and of any actor. It returns ErrInvalidAccessToken
+// if the token must not be trusted. API keys are accepted in place of a JWT and yield equivalent claims.
+func (c *AuthController) VerifyAccessToken(ctx context.Context, rawToken string) (*tokens.Claims, error) {
+	if tokens.IsAPIKey(rawToken) {
+		return c.verifyAPIKey(ctx, rawToken)
+	}
+
+	claims, err := c.tokens.Parse(rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := c.isRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, fmt.Errorf("%w: token %s has been revoked", ErrInvalidAccessToken, claims.ID)
+	}
+
+	if claims.SessionID != "" {
+		revoked, err := c.isSessionRevoked(ctx, claims.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, fmt.Errorf("%w: session of token %s has been revoked", ErrInvalidAccessToken, claims.ID)
+		}
+	}
+
+	revokedAt, err := c.sessionsRevokedAt(ctx, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	// iat has second precision, so compare against the revocation time truncated the same way.
+	if claims.IssuedAt == nil || claims.IssuedAt.Time.Before(revokedAt.Truncate(time.Second)) {
+		return nil, fmt.Errorf("%w: sessions of subject %s have been revoked", ErrInvalidAccessToken, claims.Subject)
+	}
+
+	// An impersonation token also dies with the administrator's sessions.
+	if claims.Actor != nil {
+		actorRevokedAt, err := c.sessionsRevokedAt(ctx, claims.Actor.Subject)
+		if err != nil {
+			return nil, err
+		}
+		if claims.IssuedAt.Time.Before(actorRevokedAt.Truncate(time.Second)) {
+			return nil, fmt.Errorf("%w: sessions of actor %s have been revoked", ErrInvalidAccessToken, claims.Actor.Subject)
+		}
+	}
+
+	return claims, nil
+}
+
+// Verify implements authn.Verifier so the gRPC interceptor can authenticate calls to the auth service
+// itself. Unlike JWKS-based verification in other services, it also honours the revocation list.
+func (c *AuthController) Verify(ctx context.Context, rawToken string) (*authn.Principal, error) {
+	claims, err := c.VerifyAccessToken(ctx, rawToken)
+	if err != nil {
+		return nil, err
+	}
+	return claims.Principal(), nil
+}
+
+// Introspect reports whether the token is currently active and, if so, returns its claims.
+// Invalid, expired and revoked tokens are reported as inactive rather than as errors.
+func (c *AuthController) Introspect(ctx context.Context, rawToken string) (*tokens.Claims, bool, error) {
+	claims, err := c.VerifyAccessToken(ctx, rawToken)
+	if err != nil {
+		if errors.Is(err, ErrInvalidAccessToken) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return claims, true, nil
+}
+
+// isRevoked checks the revocation list for the jti, answering from the in-process cache when possible.
+func (c *AuthController) isRevoked(ctx context.Context, jti string) (bool, error) {
+	if revoked, ok := c.revoked.Get(jti); ok {
+		return revoked, nil
+	}
+
+	revoked, err := c.revokedRepo.IsRevoked(ctx, jti)
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+
+	c.revoked.Set(jti, revoked)
+	return revoked, nil
+}
+
+// sessionsRevokedAt returns the time before which the subject's tokens are rejected,
+// or the zero time if their sessions were never revoked. Unknown subjects are treated as revoked.
+// For service accounts this is the time the account was disabled.
+func (c *AuthController) sessionsRevokedAt(ctx context.Context, subject string) (time.Time, error) {
+	if revokedAt, ok := c.sessionsRevoked.Get(subject); ok {
+		return revokedAt, nil
+	}
+
+	if isServiceAccountSubject(subject) {
+		revokedAt, err := c.serviceAccountRevokedAt(ctx, subject)
+		if err != nil {
+			return time.Time{}, err
+		}
+		c.sessionsRevoked.Set(subject, revokedAt)
+		return revokedAt, nil
+	}
+
+	userID, err := strconv.ParseUint(subject, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: malformed subject", ErrInvalidAccessToken)
+	}
+
+	user, err := c.userRepo.GetByID(ctx, uint(userID))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return time.Time{}, fmt.Errorf("%w: unknown subject", ErrInvalidAccessToken)
+		}
+		return time.Time{}, fmt.Errorf("failed to check session revocation: %w", err)
+	}
+
+	var revokedAt time.Time
+	if user.SessionsRevokedAt != nil {
+		revokedAt = *user.SessionsRevokedAt
+	}
+	c.sessionsRevoked.Set(subject, revokedAt)
+	return revokedAt, nil
+}
+
+// JWKS returns the public keys that verify access tokens issued by this service.
+func (c *AuthController) JWKS() tokens.JWKS {
+	return c.tokens.Keys().JWKS()
+}
@@ -0,0 +1,35 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey is a long-lived credential for machine clients such as CI pipelines.
+// The key authenticates as its owner, a user or a service account, but only with its own,
+// explicitly granted scopes. Exactly one of UserID and ServiceAccountID is set.
+// Prefix is the public part of the key used for lookup; only the SHA-256 hash of the full key is stored.
+type APIKey struct {
+	gorm.Model
+	UserID           *uint  `gorm:"index"`
+	ServiceAccountID *uint  `gorm:"index"`
+	Name             string `gorm:"not null"`
+	Prefix           string `gorm:"uniqueIndex;not null"`
+	KeyHash          string `gorm:"not null"`
+	Scopes           string `gorm:"not null;default:''"` // space-separated
+	ExpiresAt        *time.Time
+	LastUsedAt       *time.Time
+	RevokedAt        *time.Time
+}
+
+// ScopeList returns the key's scopes as a slice.
+func (k *APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+// Active reports whether the key is neither revoked nor expired at the given time.
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
@@ -0,0 +1,45 @@
+package entities
+
+import "gorm.io/gorm"
+
+// Types of AuthEvent.
+const (
+	AuthEventLogin               = "login"
+	AuthEventLockout             = "lockout"
+	AuthEventTokenRefresh        = "token_refresh"
+	AuthEventTokenRevocation     = "token_revocation"
+	AuthEventMFAChange           = "mfa_change"
+	AuthEventPasswordReset       = "password_reset"
+	AuthEventPasswordChange      = "password_change"
+	AuthEventImpersonation       = "impersonation"
+	AuthEventTokenExchange       = "token_exchange"
+	AuthEventDeviceAuthorization = "device_authorization"
+	AuthEventOrganizationSwitch  = "organization_switch"
+)
+
+// Outcomes of an AuthEvent.
+const (
+	AuthEventSuccess = "success"
+	AuthEventFailure = "failure"
+)
+
+// AuthEvent is one entry of the authentication audit trail, e.g. a login or a failed attempt.
+// Events are only ever inserted; CreatedAt is when it happened.
+type AuthEvent struct {
+	gorm.Model
+	Type    string `gorm:"index;not null"`
+	Outcome string `gorm:"index;not null"`
+	// Actor is the subject of the caller's token, or empty for unauthenticated requests such as logins.
+	Actor string `gorm:"index;not null;default:''"`
+	// Impersonator is the administrator behind the caller's token when it is an impersonation token.
+	Impersonator string `gorm:"index;not null;default:''"`
+	// UserID is the account the event is about, if known.
+	UserID *uint `gorm:"index"`
+	// Email is the address given at login or password reset, recorded even when no account has it.
+	Email     string `gorm:"index;not null;default:''"`
+	IPAddress string `gorm:"index;not null;default:''"`
+	UserAgent string `gorm:"not null;default:''"`
+	SessionID string `gorm:"not null;default:''"`
+	// Detail says why the event failed, how a login was made or what changed, e.g. "wrong password" or "mfa disabled".
+	Detail string `gorm:"not null;default:''"`
+}
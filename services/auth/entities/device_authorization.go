@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Statuses of a DeviceAuthorization.
+const (
+	DeviceAuthorizationPending  = "pending"
+	DeviceAuthorizationApproved = "approved"
+	DeviceAuthorizationDenied   = "denied"
+)
+
+// DeviceAuthorization is a sign-in started by a device without a browser (RFC 8628), such as a CLI
+// on a headless box. The device holds the raw device code and polls with it; only its SHA-256 hash
+// is stored. The user types the short user code into a signed-in browser to approve or deny it.
+type DeviceAuthorization struct {
+	gorm.Model
+	DeviceCodeHash string `gorm:"uniqueIndex;not null"`
+	// UserCode is stored normalized: upper case, without the separator shown to the user.
+	UserCode string `gorm:"uniqueIndex;not null"`
+	ClientID string `gorm:"not null"`
+	Status   string `gorm:"not null;default:'pending'"`
+	// UserID is the user who approved or denied the request.
+	UserID *uint
+	// PollInterval is how many seconds the device must wait between polls; slow_down responses raise it.
+	PollInterval int `gorm:"not null"`
+	LastPolledAt *time.Time
+	ExpiresAt    time.Time `gorm:"index;not null"`
+	// UsedAt is when the device redeemed an approval for tokens.
+	UsedAt *time.Time
+}
@@ -0,0 +1,13 @@
+package entities
+
+import "gorm.io/gorm"
+
+// ExternalIdentity links a user to their account at an external OIDC identity provider.
+// A provider's subject identifies the account for good, even if its email changes.
+type ExternalIdentity struct {
+	gorm.Model
+	UserID   uint   `gorm:"index;not null"`
+	Provider string `gorm:"uniqueIndex:idx_external_identity_subject;not null"`
+	Subject  string `gorm:"uniqueIndex:idx_external_identity_subject;not null"`
+	Email    string `gorm:"not null;default:''"` // email reported by the provider at the last sign-in
+}
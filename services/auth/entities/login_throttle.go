@@ -0,0 +1,18 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoginThrottle counts recent failed logins for a single key, either an email
+// ("email:<address>") or a client address ("ip:<address>"). Keeping the counters
+// in the database lets every auth replica enforce the same lockout.
+type LoginThrottle struct {
+	gorm.Model
+	Key           string    `gorm:"uniqueIndex;not null"`
+	Failures      int       `gorm:"not null;default:0"`
+	LastFailureAt time.Time `gorm:"not null"`
+	LockedUntil   *time.Time
+}
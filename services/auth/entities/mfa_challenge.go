@@ -0,0 +1,17 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MFAChallenge records a login whose password was verified but which still has to present a
+// second factor. The client holds the raw challenge token; only its SHA-256 hash is stored.
+type MFAChallenge struct {
+	gorm.Model
+	UserID    uint      `gorm:"index;not null"`
+	TokenHash string    `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	UsedAt    *time.Time
+}
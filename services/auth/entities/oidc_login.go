@@ -0,0 +1,21 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OIDCLogin records a sign-in started with an external identity provider until the provider
+// redirects back. The client holds the raw state; only its SHA-256 hash is stored.
+type OIDCLogin struct {
+	gorm.Model
+	StateHash string `gorm:"uniqueIndex;not null"`
+	Provider  string `gorm:"not null"`
+	// Nonce is echoed in the ID token, binding it to this login.
+	Nonce string `gorm:"not null"`
+	// CodeVerifier is the PKCE secret sent with the code exchange.
+	CodeVerifier string    `gorm:"not null"`
+	ExpiresAt    time.Time `gorm:"index;not null"`
+	UsedAt       *time.Time
+}
@@ -0,0 +1,16 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RevokedToken is an entry in the access token revocation list, keyed by the token's jti.
+// Entries are only needed until the token would have expired anyway and are pruned after ExpiresAt.
+type RevokedToken struct {
+	gorm.Model
+	JTI       string    `gorm:"column:jti;uniqueIndex;not null"`
+	UserID    uint      `gorm:"index"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+}
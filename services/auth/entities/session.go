@@ -0,0 +1,23 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session is a signed-in device: one login and the refresh token family it started.
+// Access tokens carry the FamilyID as their sid claim, so revoking the session rejects them too.
+type Session struct {
+	gorm.Model
+	UserID    uint   `gorm:"index;not null"`
+	FamilyID  string `gorm:"uniqueIndex;not null"`
+	UserAgent string `gorm:"not null;default:''"`
+	IPAddress string `gorm:"not null;default:''"`
+	// LastUsedAt is when the session last logged in or refreshed its tokens.
+	LastUsedAt time.Time `gorm:"index;not null"`
+	RevokedAt  *time.Time
+	// OrgID is the organization the session's access tokens act within, carried as their org_id claim.
+	// Empty until the user switches to one.
+	OrgID string `gorm:"not null;default:''"`
+}
@@ -1,6 +1,9 @@
 package entities
 
 import (
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -9,4 +12,21 @@ type User struct {
 	gorm.Model
 	Email        string `gorm:"uniqueIndex;not null"`
 	PasswordHash string `gorm:"not null"`
+	// EmailVerified is set once the user proves they own Email, through a verification link,
+	// a password reset or an identity provider that verified it.
+	EmailVerified bool   `gorm:"not null;default:false"`
+	Scopes        string `gorm:"not null;default:''"` // space-separated scopes granted to the user's tokens
+	// TOTPSecret is the AES-GCM encrypted TOTP secret; empty when the user has not enrolled.
+	TOTPSecret string `gorm:"not null;default:''"`
+	// MFAEnabled is set once enrollment is confirmed with a valid code; from then on Login requires a second factor.
+	MFAEnabled bool `gorm:"not null;default:false"`
+	// TOTPLastStep is the time step of the last accepted code, so a code cannot be used twice.
+	TOTPLastStep int64 `gorm:"not null;default:0"`
+	// SessionsRevokedAt rejects every access token issued before it, e.g. after a password reset.
+	SessionsRevokedAt *time.Time
+}
+
+// ScopeList returns the user's scopes as a slice.
+func (u *User) ScopeList() []string {
+	return strings.Fields(u.Scopes)
 }
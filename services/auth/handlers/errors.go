@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/himakhaitan/noreboothq/services/auth/controllers"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// toStatusError maps controller errors to gRPC status errors.
+// Unexpected errors are logged and returned as Internal without leaking details to the client.
+func (h *AuthHandler) toStatusError(err error) error {
+	var locked *controllers.LockedError
+	switch {
+	case errors.As(err, &locked):
+		return lockedStatusError(locked)
+	case errors.Is(err, controllers.ErrInvalidCredentials):
+		return status.Error(codes.Unauthenticated, controllers.ErrInvalidCredentials.Error())
+	case errors.Is(err, controllers.ErrInvalidEmail), errors.Is(err, controllers.ErrWeakPassword):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, controllers.ErrEmailTaken):
+		return status.Error(codes.AlreadyExists, controllers.ErrEmailTaken.Error())
+	case errors.Is(err, controllers.ErrInvalidRefreshToken):
+		return status.Error(codes.Unauthenticated, controllers.ErrInvalidRefreshToken.Error())
+	case errors.Is(err, controllers.ErrInvalidAccessToken):
+		return status.Error(codes.Unauthenticated, controllers.ErrInvalidAccessToken.Error())
+	case errors.Is(err, controllers.ErrInvalidPageToken):
+		return status.Error(codes.InvalidArgument, controllers.ErrInvalidPageToken.Error())
+	case errors.Is(err, controllers.ErrNoScopes):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, controllers.ErrScopeNotAllowed):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, controllers.ErrImpersonationNotAllowed):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, controllers.ErrAudienceNotAllowed):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, controllers.ErrAPIKeyNotFound):
+		return status.Error(codes.NotFound, controllers.ErrAPIKeyNotFound.Error())
+	case errors.Is(err, controllers.ErrAPIKeyNotRevocable):
+		return status.Error(codes.FailedPrecondition, controllers.ErrAPIKeyNotRevocable.Error())
+	case errors.Is(err, controllers.ErrServiceAccountNotFound):
+		return status.Error(codes.NotFound, controllers.ErrServiceAccountNotFound.Error())
+	case errors.Is(err, controllers.ErrInvalidClient):
+		return status.Error(codes.Unauthenticated, controllers.ErrInvalidClient.Error())
+	case errors.Is(err, controllers.ErrSessionNotFound):
+		return status.Error(codes.NotFound, controllers.ErrSessionNotFound.Error())
+	case errors.Is(err, controllers.ErrUserNotFound):
+		return status.Error(codes.NotFound, controllers.ErrUserNotFound.Error())
+	case errors.Is(err, controllers.ErrUnknownProvider):
+		return status.Error(codes.NotFound, controllers.ErrUnknownProvider.Error())
+	case errors.Is(err, controllers.ErrInvalidOIDCState):
+		return status.Error(codes.InvalidArgument, controllers.ErrInvalidOIDCState.Error())
+	case errors.Is(err, controllers.ErrOIDCLoginFailed):
+		h.logger.Warn("OIDC login failed", zap.Error(err))
+		return status.Error(codes.Unauthenticated, controllers.ErrOIDCLoginFailed.Error())
+	case errors.Is(err, controllers.ErrEmailDomainNotAllowed):
+		h.logger.Warn("OIDC login rejected", zap.Error(err))
+		return status.Error(codes.PermissionDenied, controllers.ErrEmailDomainNotAllowed.Error())
+	case errors.Is(err, controllers.ErrWrongPassword):
+		return status.Error(codes.PermissionDenied, controllers.ErrWrongPassword.Error())
+	case errors.Is(err, controllers.ErrInvalidResetToken):
+		return status.Error(codes.InvalidArgument, controllers.ErrInvalidResetToken.Error())
+	case errors.Is(err, controllers.ErrInvalidVerificationToken):
+		return status.Error(codes.InvalidArgument, controllers.ErrInvalidVerificationToken.Error())
+	case errors.Is(err, controllers.ErrEmailNotVerified):
+		return status.Error(codes.FailedPrecondition, controllers.ErrEmailNotVerified.Error())
+	case errors.Is(err, controllers.ErrInvalidMFACode), errors.Is(err, controllers.ErrInvalidMFAToken):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, controllers.ErrMFAAlreadyEnabled), errors.Is(err, controllers.ErrMFANotEnrolled),
+		errors.Is(err, controllers.ErrMFANotEnabled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, controllers.ErrInvalidUserCode), errors.Is(err, controllers.ErrInvalidDeviceCode):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, controllers.ErrAuthorizationPending):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, controllers.ErrSlowDown):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, controllers.ErrDeviceAccessDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, controllers.ErrDeviceCodeExpired):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, controllers.ErrNotOrganizationMember):
+		return status.Error(codes.PermissionDenied, controllers.ErrNotOrganizationMember.Error())
+	case errors.Is(err, controllers.ErrOrganizationSwitchNotAllowed):
+		return status.Error(codes.FailedPrecondition, controllers.ErrOrganizationSwitchNotAllowed.Error())
+	case errors.Is(err, controllers.ErrOrganizationsUnavailable):
+		h.logger.Warn("Organization membership check failed", zap.Error(err))
+		return status.Error(codes.Unavailable, controllers.ErrOrganizationsUnavailable.Error())
+	case errors.Is(err, controllers.ErrRefreshTokenReused):
+		h.logger.Warn("Refresh token reuse detected", zap.Error(err))
+		return status.Error(codes.Unauthenticated, controllers.ErrInvalidRefreshToken.Error())
+	default:
+		h.logger.Error("Request failed", zap.Error(err))
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+// lockedStatusError reports a lockout as ResourceExhausted with a RetryInfo detail
+// telling the client how long to wait before trying again.
+func lockedStatusError(locked *controllers.LockedError) error {
+	st := status.New(codes.ResourceExhausted, locked.Error())
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(locked.RetryAfter)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
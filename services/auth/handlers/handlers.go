@@ -1,9 +1,19 @@
 package handlers
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	authpb "github.com/himakhaitan/noreboothq/proto/auth"
 	"github.com/himakhaitan/noreboothq/services/auth/controllers"
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"github.com/himakhaitan/noreboothq/services/auth/repository"
+	"github.com/himakhaitan/noreboothq/services/auth/tokens"
+	"github.com/himakhaitan/noreboothq/shared/authn"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Package handlers provides the HTTP handlers for the authentication service.
@@ -13,6 +23,31 @@ type AuthHandler struct {
 	logger *zap.Logger
 }
 
+// PublicMethods are the AuthService RPCs callable without a bearer token.
+// Every other method is authenticated by the authn interceptor.
+var PublicMethods = []string{
+	authpb.AuthService_Login_FullMethodName,
+	authpb.AuthService_Register_FullMethodName,
+	authpb.AuthService_Refresh_FullMethodName,
+	authpb.AuthService_GetJWKS_FullMethodName,
+	authpb.AuthService_VerifyMFA_FullMethodName,
+	authpb.AuthService_RequestPasswordReset_FullMethodName,
+	authpb.AuthService_ResetPassword_FullMethodName,
+	authpb.AuthService_VerifyEmail_FullMethodName,
+	authpb.AuthService_ResendVerificationEmail_FullMethodName,
+	authpb.AuthService_Token_FullMethodName,
+	authpb.AuthService_ExchangeToken_FullMethodName,
+	authpb.AuthService_StartDeviceAuthorization_FullMethodName,
+	authpb.AuthService_StartOIDCLogin_FullMethodName,
+	authpb.AuthService_CompleteOIDCLogin_FullMethodName,
+}
+
+// OAuth2 grant types the token endpoint supports.
+const (
+	grantTypeClientCredentials = "client_credentials"
+	grantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
 // NewAuthHandler creates a new instance of AuthHandler with the provided AuthController and logger.
 func NewAuthHandler(ctrl *controllers.AuthController, logger *zap.Logger) *AuthHandler {
 	return &AuthHandler{
@@ -21,8 +56,961 @@ func NewAuthHandler(ctrl *controllers.AuthController, logger *zap.Logger) *AuthH
 	}
 }
 
-// func (h *AuthHandler) Login(ctx context.Context, req *authpb.LoginRequest) (*authpb.LoginResponse, error) {
-// 	h.logger.Info("Login request received", zap.String("email", req.Email))
-// 	token, err := h.ctrl.Login(ctx, req.Email, req.Password)
+// Login authenticates the user and returns a signed access token,
+// or an MFA challenge if the user has multi-factor authentication enabled.
+func (h *AuthHandler) Login(ctx context.Context, req *authpb.LoginRequest) (*authpb.LoginResponse, error) {
+	h.logger.Info("Login request received", zap.String("email", req.Email))
+
+	if req.Email == "" || req.Password == "" {
+		return nil, status.Error(codes.InvalidArgument, "email and password are required")
+	}
+
+	result, err := h.ctrl.Login(ctx, req.Email, req.Password, clientInfo(ctx))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	if result.Tokens == nil {
+		return &authpb.LoginResponse{
+			MfaRequired:  true,
+			MfaToken:     result.MFAToken,
+			MfaExpiresIn: int64(result.MFAExpiresIn.Seconds()),
+		}, nil
+	}
+
+	authTokens := result.Tokens
+	return &authpb.LoginResponse{
+		AccessToken:      authTokens.AccessToken,
+		TokenType:        authTokens.TokenType,
+		ExpiresIn:        int64(authTokens.ExpiresIn.Seconds()),
+		RefreshToken:     authTokens.RefreshToken,
+		RefreshExpiresIn: int64(authTokens.RefreshExpiresIn.Seconds()),
+	}, nil
+}
+
+// Register creates a new user account.
+func (h *AuthHandler) Register(ctx context.Context, req *authpb.RegisterRequest) (*authpb.RegisterResponse, error) {
+	h.logger.Info("Register request received", zap.String("email", req.Email))
+
+	if req.Email == "" || req.Password == "" {
+		return nil, status.Error(codes.InvalidArgument, "email and password are required")
+	}
+
+	user, err := h.ctrl.Register(ctx, req.Email, req.Password)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	// The account exists either way; the user can ask for another link with ResendVerificationEmail.
+	if err := h.ctrl.SendVerificationEmail(ctx, user); err != nil {
+		h.logger.Warn("Failed to send verification email", zap.Uint("user_id", user.ID), zap.Error(err))
+	}
+
+	return &authpb.RegisterResponse{
+		UserId: uint64(user.ID),
+		Email:  user.Email,
+	}, nil
+}
+
+// ProvisionUser creates an account with a verified email for a service holding the "auth.users"
+// scope. Unlike Register it sends no verification email.
+func (h *AuthHandler) ProvisionUser(ctx context.Context, req *authpb.ProvisionUserRequest) (*authpb.ProvisionUserResponse, error) {
+	principal, err := authn.RequireScope(ctx, tokens.ScopeAuthUsers)
+	if err != nil {
+		return nil, err
+	}
+	h.logger.Info("ProvisionUser request received", zap.String("email", req.Email), zap.String("caller", principal.Subject))
+
+	if req.Email == "" || req.Password == "" {
+		return nil, status.Error(codes.InvalidArgument, "email and password are required")
+	}
+
+	user, err := h.ctrl.ProvisionUser(ctx, req.Email, req.Password)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	return &authpb.ProvisionUserResponse{
+		UserId: uint64(user.ID),
+		Email:  user.Email,
+	}, nil
+}
+
+// GetUser returns a user's email and whether it is verified to a service holding the "auth.users" scope.
+func (h *AuthHandler) GetUser(ctx context.Context, req *authpb.GetUserRequest) (*authpb.GetUserResponse, error) {
+	if _, err := authn.RequireScope(ctx, tokens.ScopeAuthUsers); err != nil {
+		return nil, err
+	}
+	if req.UserId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	user, err := h.ctrl.GetUser(ctx, uint(req.UserId))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.GetUserResponse{
+		UserId:        uint64(user.ID),
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+	}, nil
+}
+
+// Refresh rotates a refresh token and returns a fresh access token.
+func (h *AuthHandler) Refresh(ctx context.Context, req *authpb.RefreshRequest) (*authpb.RefreshResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh_token is required")
+	}
+
+	authTokens, err := h.ctrl.Refresh(ctx, req.RefreshToken, clientInfo(ctx))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	return &authpb.RefreshResponse{
+		AccessToken:      authTokens.AccessToken,
+		TokenType:        authTokens.TokenType,
+		ExpiresIn:        int64(authTokens.ExpiresIn.Seconds()),
+		RefreshToken:     authTokens.RefreshToken,
+		RefreshExpiresIn: int64(authTokens.RefreshExpiresIn.Seconds()),
+	}, nil
+}
+
+// Logout revokes the caller's access token and, if provided, its refresh token.
+func (h *AuthHandler) Logout(ctx context.Context, req *authpb.LogoutRequest) (*authpb.LogoutResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.Info("Logout request received", zap.String("user_id", principal.Subject))
+
+	if err := h.ctrl.Logout(ctx, principal, req.RefreshToken, clientInfo(ctx)); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.LogoutResponse{}, nil
+}
+
+// RevokeToken lets an administrator revoke any access token before it expires.
+func (h *AuthHandler) RevokeToken(ctx context.Context, req *authpb.RevokeTokenRequest) (*authpb.RevokeTokenResponse, error) {
+	principal, err := authn.RequireScope(ctx, tokens.ScopeAuthAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if (req.Token == "") == (req.TokenId == "") {
+		return nil, status.Error(codes.InvalidArgument, "exactly one of token or token_id is required")
+	}
+
+	h.logger.Info("RevokeToken request received",
+		zap.String("admin_id", principal.Subject),
+		zap.String("token_id", req.TokenId),
+	)
+
+	if err := h.ctrl.RevokeToken(ctx, req.Token, req.TokenId, clientInfo(ctx)); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.RevokeTokenResponse{}, nil
+}
+
+// IntrospectToken reports whether a token is active and returns its claims, following RFC 7662.
+// Only callers with the introspection scope may ask, so tokens cannot be probed anonymously.
+func (h *AuthHandler) IntrospectToken(ctx context.Context, req *authpb.IntrospectTokenRequest) (*authpb.IntrospectTokenResponse, error) {
+	if _, err := authn.RequireScope(ctx, tokens.ScopeAuthIntrospect); err != nil {
+		return nil, err
+	}
+	if req.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	claims, active, err := h.ctrl.Introspect(ctx, req.Token)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	if !active {
+		return &authpb.IntrospectTokenResponse{Active: false}, nil
+	}
+
+	resp := &authpb.IntrospectTokenResponse{
+		Active:    true,
+		Sub:       claims.Subject,
+		Scopes:    claims.Scopes(),
+		OrgId:     claims.OrgID,
+		Iss:       claims.Issuer,
+		Jti:       claims.ID,
+		Sid:       claims.SessionID,
+		TokenType: "Bearer",
+	}
+	if claims.ExpiresAt != nil {
+		resp.Exp = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		resp.Iat = claims.IssuedAt.Unix()
+	}
+	if claims.Actor != nil {
+		resp.Act = claims.Actor.Subject
+	}
+	resp.Aud = claims.Audience
+	if tokens.IsAPIKey(req.Token) {
+		resp.TokenType = "api_key"
+	}
+	return resp, nil
+}
+
+// GetJWKS returns the public keys that verify access tokens.
+func (h *AuthHandler) GetJWKS(ctx context.Context, req *authpb.GetJWKSRequest) (*authpb.GetJWKSResponse, error) {
+	jwks := h.ctrl.JWKS()
+
+	resp := &authpb.GetJWKSResponse{Keys: make([]*authpb.JWK, 0, len(jwks.Keys))}
+	for _, key := range jwks.Keys {
+		resp.Keys = append(resp.Keys, &authpb.JWK{
+			Kty: key.KeyType,
+			Kid: key.KeyID,
+			Use: key.Use,
+			Alg: key.Algorithm,
+			N:   key.N,
+			E:   key.E,
+			Crv: key.Curve,
+			X:   key.X,
+		})
+	}
+	return resp, nil
+}
+
+// UnlockAccount lets an administrator clear the lockout of an account after repeated login failures.
+func (h *AuthHandler) UnlockAccount(ctx context.Context, req *authpb.UnlockAccountRequest) (*authpb.UnlockAccountResponse, error) {
+	principal, err := authn.RequireScope(ctx, tokens.ScopeAuthAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Email == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	h.logger.Info("UnlockAccount request received",
+		zap.String("admin_id", principal.Subject),
+		zap.String("email", req.Email),
+	)
+
+	if err := h.ctrl.UnlockAccount(ctx, req.Email, clientInfo(ctx)); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.UnlockAccountResponse{}, nil
+}
+
+// EnrollMFA starts TOTP enrollment for the caller.
+func (h *AuthHandler) EnrollMFA(ctx context.Context, req *authpb.EnrollMFARequest) (*authpb.EnrollMFAResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.Info("EnrollMFA request received", zap.String("user_id", principal.Subject))
+
+	enrollment, err := h.ctrl.EnrollMFA(ctx, principal)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.EnrollMFAResponse{
+		Secret:     enrollment.Secret,
+		OtpauthUri: enrollment.URI,
+	}, nil
+}
+
+// ConfirmMFA enables MFA for the caller and returns their recovery codes.
+func (h *AuthHandler) ConfirmMFA(ctx context.Context, req *authpb.ConfirmMFARequest) (*authpb.ConfirmMFAResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	h.logger.Info("ConfirmMFA request received", zap.String("user_id", principal.Subject))
+
+	recoveryCodes, err := h.ctrl.ConfirmMFA(ctx, principal, req.Code, clientInfo(ctx))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.ConfirmMFAResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// DisableMFA turns MFA off for the caller.
+func (h *AuthHandler) DisableMFA(ctx context.Context, req *authpb.DisableMFARequest) (*authpb.DisableMFAResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	h.logger.Info("DisableMFA request received", zap.String("user_id", principal.Subject))
+
+	if err := h.ctrl.DisableMFA(ctx, principal, req.Code, clientInfo(ctx)); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.DisableMFAResponse{}, nil
+}
+
+// VerifyMFA completes a two-step login and returns the access and refresh tokens.
+func (h *AuthHandler) VerifyMFA(ctx context.Context, req *authpb.VerifyMFARequest) (*authpb.VerifyMFAResponse, error) {
+	if req.MfaToken == "" || req.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, "mfa_token and code are required")
+	}
+
+	authTokens, err := h.ctrl.VerifyMFA(ctx, req.MfaToken, req.Code, clientInfo(ctx))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	return &authpb.VerifyMFAResponse{
+		AccessToken:      authTokens.AccessToken,
+		TokenType:        authTokens.TokenType,
+		ExpiresIn:        int64(authTokens.ExpiresIn.Seconds()),
+		RefreshToken:     authTokens.RefreshToken,
+		RefreshExpiresIn: int64(authTokens.RefreshExpiresIn.Seconds()),
+	}, nil
+}
+
+// RequestPasswordReset emails a password reset link to the given address if it belongs to a user.
+func (h *AuthHandler) RequestPasswordReset(ctx context.Context, req *authpb.RequestPasswordResetRequest) (*authpb.RequestPasswordResetResponse, error) {
+	h.logger.Info("RequestPasswordReset request received", zap.String("email", req.Email))
+
+	if req.Email == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	if err := h.ctrl.RequestPasswordReset(ctx, req.Email, clientInfo(ctx)); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.RequestPasswordResetResponse{}, nil
+}
+
+// ResetPassword sets a new password using a token from a reset email.
+func (h *AuthHandler) ResetPassword(ctx context.Context, req *authpb.ResetPasswordRequest) (*authpb.ResetPasswordResponse, error) {
+	if req.Token == "" || req.NewPassword == "" {
+		return nil, status.Error(codes.InvalidArgument, "token and new_password are required")
+	}
+
+	if err := h.ctrl.ResetPassword(ctx, req.Token, req.NewPassword, clientInfo(ctx)); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.ResetPasswordResponse{}, nil
+}
+
+// ChangePassword sets a new password for the caller after checking their current one.
+func (h *AuthHandler) ChangePassword(ctx context.Context, req *authpb.ChangePasswordRequest) (*authpb.ChangePasswordResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		return nil, status.Error(codes.InvalidArgument, "current_password and new_password are required")
+	}
+
+	h.logger.Info("ChangePassword request received", zap.String("user_id", principal.Subject))
+
+	if err := h.ctrl.ChangePassword(ctx, principal, req.CurrentPassword, req.NewPassword, clientInfo(ctx)); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.ChangePasswordResponse{}, nil
+}
+
+// VerifyEmail marks the user's email as verified with a token from a verification email.
+func (h *AuthHandler) VerifyEmail(ctx context.Context, req *authpb.VerifyEmailRequest) (*authpb.VerifyEmailResponse, error) {
+	if req.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	if err := h.ctrl.VerifyEmail(ctx, req.Token); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.VerifyEmailResponse{}, nil
+}
+
+// ResendVerificationEmail emails a new verification link if the account exists and is unverified.
+func (h *AuthHandler) ResendVerificationEmail(ctx context.Context, req *authpb.ResendVerificationEmailRequest) (*authpb.ResendVerificationEmailResponse, error) {
+	h.logger.Info("ResendVerificationEmail request received", zap.String("email", req.Email))
+
+	if req.Email == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	if err := h.ctrl.ResendVerificationEmail(ctx, req.Email); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.ResendVerificationEmailResponse{}, nil
+}
+
+// CreateAPIKey issues an API key for the caller and returns it once.
+func (h *AuthHandler) CreateAPIKey(ctx context.Context, req *authpb.CreateAPIKeyRequest) (*authpb.CreateAPIKeyResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	if req.ExpiresIn < 0 {
+		return nil, status.Error(codes.InvalidArgument, "expires_in cannot be negative")
+	}
+
+	h.logger.Info("CreateAPIKey request received",
+		zap.String("user_id", principal.Subject),
+		zap.String("name", req.Name),
+		zap.Strings("scopes", req.Scopes),
+	)
+
+	key, raw, err := h.ctrl.CreateAPIKey(ctx, principal, req.Name, req.Scopes, time.Duration(req.ExpiresIn)*time.Second)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.CreateAPIKeyResponse{
+		ApiKey: toAPIKeyProto(key),
+		Key:    raw,
+	}, nil
+}
+
+// ListAPIKeys returns the caller's API keys.
+func (h *AuthHandler) ListAPIKeys(ctx context.Context, req *authpb.ListAPIKeysRequest) (*authpb.ListAPIKeysResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := h.ctrl.ListAPIKeys(ctx, principal)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	resp := &authpb.ListAPIKeysResponse{ApiKeys: make([]*authpb.APIKey, 0, len(keys))}
+	for i := range keys {
+		resp.ApiKeys = append(resp.ApiKeys, toAPIKeyProto(&keys[i]))
+	}
+	return resp, nil
+}
+
+// RevokeAPIKey revokes an API key so it is rejected from then on.
+func (h *AuthHandler) RevokeAPIKey(ctx context.Context, req *authpb.RevokeAPIKeyRequest) (*authpb.RevokeAPIKeyResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-// }
+	if req.Id == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	h.logger.Info("RevokeAPIKey request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint64("api_key_id", req.Id),
+	)
+
+	if err := h.ctrl.RevokeAPIKey(ctx, principal, uint(req.Id), clientInfo(ctx)); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.RevokeAPIKeyResponse{}, nil
+}
+
+// Token implements the OAuth2 token endpoint for service accounts and for devices polling
+// after StartDeviceAuthorization.
+func (h *AuthHandler) Token(ctx context.Context, req *authpb.TokenRequest) (*authpb.TokenResponse, error) {
+	switch req.GrantType {
+	case grantTypeClientCredentials:
+		return h.clientCredentialsToken(ctx, req)
+	case grantTypeDeviceCode:
+		return h.deviceCodeToken(ctx, req)
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported grant_type %q", req.GrantType)
+	}
+}
+
+// clientCredentialsToken handles the client_credentials grant of Token.
+func (h *AuthHandler) clientCredentialsToken(ctx context.Context, req *authpb.TokenRequest) (*authpb.TokenResponse, error) {
+	if req.ClientId == "" || req.ClientSecret == "" {
+		return nil, status.Error(codes.InvalidArgument, "client_id and client_secret are required")
+	}
+
+	h.logger.Info("Token request received",
+		zap.String("client_id", req.ClientId),
+		zap.String("scope", req.Scope),
+	)
+
+	token, err := h.ctrl.ClientCredentials(ctx, req.ClientId, req.ClientSecret, strings.Fields(req.Scope))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.TokenResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		ExpiresIn:   int64(token.ExpiresIn.Seconds()),
+		Scope:       strings.Join(token.Scopes, " "),
+	}, nil
+}
+
+// deviceCodeToken handles the device_code grant of Token. Until the user approves, it fails with
+// the RFC 8628 error codes authorization_pending and slow_down as status messages.
+func (h *AuthHandler) deviceCodeToken(ctx context.Context, req *authpb.TokenRequest) (*authpb.TokenResponse, error) {
+	if req.ClientId == "" || req.DeviceCode == "" {
+		return nil, status.Error(codes.InvalidArgument, "client_id and device_code are required")
+	}
+
+	h.logger.Debug("Device token poll received", zap.String("client_id", req.ClientId))
+
+	authTokens, err := h.ctrl.PollDeviceToken(ctx, req.ClientId, req.DeviceCode, clientInfo(ctx))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.TokenResponse{
+		AccessToken:      authTokens.AccessToken,
+		TokenType:        authTokens.TokenType,
+		ExpiresIn:        int64(authTokens.ExpiresIn.Seconds()),
+		RefreshToken:     authTokens.RefreshToken,
+		RefreshExpiresIn: int64(authTokens.RefreshExpiresIn.Seconds()),
+	}, nil
+}
+
+// StartDeviceAuthorization begins the RFC 8628 device flow for a client without a browser.
+func (h *AuthHandler) StartDeviceAuthorization(ctx context.Context, req *authpb.StartDeviceAuthorizationRequest) (*authpb.StartDeviceAuthorizationResponse, error) {
+	if req.ClientId == "" {
+		return nil, status.Error(codes.InvalidArgument, "client_id is required")
+	}
+
+	h.logger.Info("StartDeviceAuthorization request received", zap.String("client_id", req.ClientId))
+
+	code, err := h.ctrl.StartDeviceAuthorization(ctx, req.ClientId)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.StartDeviceAuthorizationResponse{
+		DeviceCode:              code.DeviceCode,
+		UserCode:                code.UserCode,
+		VerificationUri:         code.VerificationURI,
+		VerificationUriComplete: code.VerificationURIComplete,
+		ExpiresIn:               int64(code.ExpiresIn.Seconds()),
+		Interval:                int64(code.Interval.Seconds()),
+	}, nil
+}
+
+// ApproveDevice signs the device showing the user code in as the caller.
+func (h *AuthHandler) ApproveDevice(ctx context.Context, req *authpb.ApproveDeviceRequest) (*authpb.ApproveDeviceResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.UserCode == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_code is required")
+	}
+
+	h.logger.Info("ApproveDevice request received", zap.String("user_id", principal.Subject))
+
+	authorization, err := h.ctrl.ApproveDevice(ctx, principal, req.UserCode, clientInfo(ctx))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.ApproveDeviceResponse{ClientId: authorization.ClientID}, nil
+}
+
+// DenyDevice refuses the sign-in of the device showing the user code.
+func (h *AuthHandler) DenyDevice(ctx context.Context, req *authpb.DenyDeviceRequest) (*authpb.DenyDeviceResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.UserCode == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_code is required")
+	}
+
+	h.logger.Info("DenyDevice request received", zap.String("user_id", principal.Subject))
+
+	if _, err := h.ctrl.DenyDevice(ctx, principal, req.UserCode, clientInfo(ctx)); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.DenyDeviceResponse{}, nil
+}
+
+// SwitchOrganization makes an organization the caller belongs to the active one of their session.
+func (h *AuthHandler) SwitchOrganization(ctx context.Context, req *authpb.SwitchOrganizationRequest) (*authpb.SwitchOrganizationResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.Info("SwitchOrganization request received",
+		zap.String("user_id", principal.Subject),
+		zap.String("org_id", req.OrgId),
+	)
+
+	token, err := h.ctrl.SwitchOrganization(ctx, principal, req.OrgId, clientInfo(ctx))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.SwitchOrganizationResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		ExpiresIn:   int64(token.ExpiresIn.Seconds()),
+		OrgId:       token.OrgID,
+	}, nil
+}
+
+// ExchangeToken implements the RFC 8693 token exchange for down-scoped, audience-restricted tokens.
+func (h *AuthHandler) ExchangeToken(ctx context.Context, req *authpb.ExchangeTokenRequest) (*authpb.ExchangeTokenResponse, error) {
+	if req.SubjectToken == "" {
+		return nil, status.Error(codes.InvalidArgument, "subject_token is required")
+	}
+	if req.SubjectTokenType != "" && req.SubjectTokenType != controllers.TokenTypeAccessToken {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported subject_token_type %q", req.SubjectTokenType)
+	}
+	if req.Audience == "" {
+		return nil, status.Error(codes.InvalidArgument, "audience is required")
+	}
+	if req.RequestedTtl < 0 {
+		return nil, status.Error(codes.InvalidArgument, "requested_ttl cannot be negative")
+	}
+
+	h.logger.Info("ExchangeToken request received",
+		zap.String("audience", req.Audience),
+		zap.String("scope", req.Scope),
+	)
+
+	ttl := time.Duration(req.RequestedTtl) * time.Second
+	token, err := h.ctrl.ExchangeToken(ctx, req.SubjectToken, req.Audience, strings.Fields(req.Scope), ttl, clientInfo(ctx))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.ExchangeTokenResponse{
+		AccessToken:     token.AccessToken,
+		IssuedTokenType: token.IssuedTokenType,
+		TokenType:       token.TokenType,
+		ExpiresIn:       int64(token.ExpiresIn.Seconds()),
+		Scope:           strings.Join(token.Scopes, " "),
+		Audience:        token.Audience,
+	}, nil
+}
+
+// CreateServiceAccount creates a service account and returns its client secret once.
+func (h *AuthHandler) CreateServiceAccount(ctx context.Context, req *authpb.CreateServiceAccountRequest) (*authpb.CreateServiceAccountResponse, error) {
+	principal, err := authn.RequireScope(ctx, tokens.ScopeAuthAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	h.logger.Info("CreateServiceAccount request received",
+		zap.String("admin_id", principal.Subject),
+		zap.String("name", req.Name),
+		zap.Strings("scopes", req.Scopes),
+	)
+
+	account, secret, err := h.ctrl.CreateServiceAccount(ctx, req.Name, req.Scopes)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.CreateServiceAccountResponse{
+		ServiceAccount: toServiceAccountProto(account),
+		ClientSecret:   secret,
+	}, nil
+}
+
+// ListServiceAccounts returns every service account.
+func (h *AuthHandler) ListServiceAccounts(ctx context.Context, req *authpb.ListServiceAccountsRequest) (*authpb.ListServiceAccountsResponse, error) {
+	if _, err := authn.RequireScope(ctx, tokens.ScopeAuthAdmin); err != nil {
+		return nil, err
+	}
+
+	accounts, err := h.ctrl.ListServiceAccounts(ctx)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	resp := &authpb.ListServiceAccountsResponse{ServiceAccounts: make([]*authpb.ServiceAccount, 0, len(accounts))}
+	for i := range accounts {
+		resp.ServiceAccounts = append(resp.ServiceAccounts, toServiceAccountProto(&accounts[i]))
+	}
+	return resp, nil
+}
+
+// DisableServiceAccount disables a service account and invalidates its tokens.
+func (h *AuthHandler) DisableServiceAccount(ctx context.Context, req *authpb.DisableServiceAccountRequest) (*authpb.DisableServiceAccountResponse, error) {
+	principal, err := authn.RequireScope(ctx, tokens.ScopeAuthAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Id == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	h.logger.Info("DisableServiceAccount request received",
+		zap.String("admin_id", principal.Subject),
+		zap.Uint64("service_account_id", req.Id),
+	)
+
+	if err := h.ctrl.DisableServiceAccount(ctx, uint(req.Id)); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.DisableServiceAccountResponse{}, nil
+}
+
+// StartOIDCLogin returns the URL that signs the user in at an external identity provider.
+func (h *AuthHandler) StartOIDCLogin(ctx context.Context, req *authpb.StartOIDCLoginRequest) (*authpb.StartOIDCLoginResponse, error) {
+	if req.Provider == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider is required")
+	}
+
+	h.logger.Info("StartOIDCLogin request received", zap.String("provider", req.Provider))
+
+	authz, err := h.ctrl.StartOIDCLogin(ctx, req.Provider)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.StartOIDCLoginResponse{
+		AuthorizationUrl: authz.URL,
+		State:            authz.State,
+		ExpiresIn:        int64(authz.ExpiresIn.Seconds()),
+	}, nil
+}
+
+// CompleteOIDCLogin exchanges the code an identity provider redirected back with for tokens,
+// or an MFA challenge if the user has multi-factor authentication enabled.
+func (h *AuthHandler) CompleteOIDCLogin(ctx context.Context, req *authpb.CompleteOIDCLoginRequest) (*authpb.CompleteOIDCLoginResponse, error) {
+	if req.State == "" || req.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, "state and code are required")
+	}
+
+	result, err := h.ctrl.CompleteOIDCLogin(ctx, req.State, req.Code, clientInfo(ctx))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	if result.Tokens == nil {
+		return &authpb.CompleteOIDCLoginResponse{
+			MfaRequired:  true,
+			MfaToken:     result.MFAToken,
+			MfaExpiresIn: int64(result.MFAExpiresIn.Seconds()),
+		}, nil
+	}
+
+	authTokens := result.Tokens
+	return &authpb.CompleteOIDCLoginResponse{
+		AccessToken:      authTokens.AccessToken,
+		TokenType:        authTokens.TokenType,
+		ExpiresIn:        int64(authTokens.ExpiresIn.Seconds()),
+		RefreshToken:     authTokens.RefreshToken,
+		RefreshExpiresIn: int64(authTokens.RefreshExpiresIn.Seconds()),
+	}, nil
+}
+
+// ListSessions returns the devices a user is signed in on.
+func (h *AuthHandler) ListSessions(ctx context.Context, req *authpb.ListSessionsRequest) (*authpb.ListSessionsResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := h.ctrl.ListSessions(ctx, principal, uint(req.UserId))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	resp := &authpb.ListSessionsResponse{Sessions: make([]*authpb.Session, 0, len(sessions))}
+	for i := range sessions {
+		resp.Sessions = append(resp.Sessions, toSessionProto(&sessions[i], principal))
+	}
+	return resp, nil
+}
+
+// RevokeSession signs a single device out.
+func (h *AuthHandler) RevokeSession(ctx context.Context, req *authpb.RevokeSessionRequest) (*authpb.RevokeSessionResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Id == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	h.logger.Info("RevokeSession request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint64("session_id", req.Id),
+	)
+
+	if err := h.ctrl.RevokeSession(ctx, principal, uint(req.Id), clientInfo(ctx)); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.RevokeSessionResponse{}, nil
+}
+
+// RevokeAllSessions lets an administrator sign a user out of every device.
+func (h *AuthHandler) RevokeAllSessions(ctx context.Context, req *authpb.RevokeAllSessionsRequest) (*authpb.RevokeAllSessionsResponse, error) {
+	principal, err := authn.RequireScope(ctx, tokens.ScopeAuthAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.UserId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	h.logger.Info("RevokeAllSessions request received",
+		zap.String("admin_id", principal.Subject),
+		zap.Uint64("target_user_id", req.UserId),
+	)
+
+	if err := h.ctrl.RevokeAllSessions(ctx, uint(req.UserId), clientInfo(ctx)); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.RevokeAllSessionsResponse{}, nil
+}
+
+// Impersonate lets an administrator act as another user through a short-lived token.
+func (h *AuthHandler) Impersonate(ctx context.Context, req *authpb.ImpersonateRequest) (*authpb.ImpersonateResponse, error) {
+	principal, err := authn.RequireScope(ctx, tokens.ScopeAuthImpersonate)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.UserId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if req.Reason == "" {
+		return nil, status.Error(codes.InvalidArgument, "reason is required")
+	}
+
+	h.logger.Info("Impersonate request received",
+		zap.String("admin_id", principal.Subject),
+		zap.Uint64("target_user_id", req.UserId),
+		zap.String("reason", req.Reason),
+	)
+
+	token, err := h.ctrl.Impersonate(ctx, principal, uint(req.UserId), req.Reason, clientInfo(ctx))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &authpb.ImpersonateResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		ExpiresIn:   int64(token.ExpiresIn.Seconds()),
+		Scopes:      token.Scopes,
+	}, nil
+}
+
+// ListAuthEvents pages through the authentication audit trail.
+func (h *AuthHandler) ListAuthEvents(ctx context.Context, req *authpb.ListAuthEventsRequest) (*authpb.ListAuthEventsResponse, error) {
+	if _, err := authn.RequireScope(ctx, tokens.ScopeAuthAdmin); err != nil {
+		return nil, err
+	}
+
+	if req.PageSize < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size cannot be negative")
+	}
+
+	filter := repository.AuthEventFilter{
+		Type:      req.Type,
+		Outcome:   req.Outcome,
+		Actor:     req.Actor,
+		UserID:    uint(req.UserId),
+		Email:     req.Email,
+		IPAddress: req.IpAddress,
+	}
+	if req.Since != 0 {
+		filter.Since = time.Unix(req.Since, 0)
+	}
+	if req.Until != 0 {
+		filter.Until = time.Unix(req.Until, 0)
+	}
+
+	events, nextPageToken, err := h.ctrl.ListAuthEvents(ctx, filter, int(req.PageSize), req.PageToken)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	resp := &authpb.ListAuthEventsResponse{
+		Events:        make([]*authpb.AuthEvent, 0, len(events)),
+		NextPageToken: nextPageToken,
+	}
+	for i := range events {
+		resp.Events = append(resp.Events, toAuthEventProto(&events[i]))
+	}
+	return resp, nil
+}
+
+// toSessionProto converts a session to its wire form, flagging the one the caller's token belongs to.
+func toSessionProto(session *entities.Session, principal *authn.Principal) *authpb.Session {
+	return &authpb.Session{
+		Id:         uint64(session.ID),
+		CreatedAt:  session.CreatedAt.Unix(),
+		LastUsedAt: session.LastUsedAt.Unix(),
+		UserAgent:  session.UserAgent,
+		IpAddress:  session.IPAddress,
+		Current:    principal.SessionID != "" && session.FamilyID == principal.SessionID,
+	}
+}
+
+// toAuthEventProto converts an audit event to its wire form.
+func toAuthEventProto(event *entities.AuthEvent) *authpb.AuthEvent {
+	out := &authpb.AuthEvent{
+		Id:           uint64(event.ID),
+		CreatedAt:    event.CreatedAt.Unix(),
+		Type:         event.Type,
+		Outcome:      event.Outcome,
+		Actor:        event.Actor,
+		Email:        event.Email,
+		IpAddress:    event.IPAddress,
+		UserAgent:    event.UserAgent,
+		SessionId:    event.SessionID,
+		Detail:       event.Detail,
+		Impersonator: event.Impersonator,
+	}
+	if event.UserID != nil {
+		out.UserId = uint64(*event.UserID)
+	}
+	return out
+}
+
+// toServiceAccountProto converts a service account to its wire form.
+func toServiceAccountProto(account *entities.ServiceAccount) *authpb.ServiceAccount {
+	return &authpb.ServiceAccount{
+		Id:         uint64(account.ID),
+		Name:       account.Name,
+		ClientId:   account.ClientID,
+		Scopes:     account.ScopeList(),
+		CreatedAt:  account.CreatedAt.Unix(),
+		DisabledAt: unixOrZero(account.DisabledAt),
+	}
+}
+
+// toAPIKeyProto converts an API key to its wire form. Unset times are reported as 0.
+func toAPIKeyProto(key *entities.APIKey) *authpb.APIKey {
+	return &authpb.APIKey{
+		Id:         uint64(key.ID),
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.ScopeList(),
+		CreatedAt:  key.CreatedAt.Unix(),
+		ExpiresAt:  unixOrZero(key.ExpiresAt),
+		LastUsedAt: unixOrZero(key.LastUsedAt),
+		RevokedAt:  unixOrZero(key.RevokedAt),
+	}
+}
+
+// unixOrZero returns t as seconds since the Unix epoch, or 0 if t is nil.
+func unixOrZero(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/himakhaitan/noreboothq/services/auth/controllers"
+	"go.uber.org/zap"
+)
+
+// HTTPHandler serves the auth endpoints that standard clients expect over plain HTTP,
+// such as the JWKS document and the OAuth2 token endpoint.
+type HTTPHandler struct {
+	ctrl   *controllers.AuthController
+	logger *zap.Logger
+}
+
+// NewHTTPHandler creates a new instance of HTTPHandler with the provided AuthController and logger.
+func NewHTTPHandler(ctrl *controllers.AuthController, logger *zap.Logger) *HTTPHandler {
+	return &HTTPHandler{
+		ctrl:   ctrl,
+		logger: logger,
+	}
+}
+
+// Routes returns the HTTP routes served by the auth service.
+func (h *HTTPHandler) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/jwks.json", h.jwks)
+	mux.HandleFunc("POST /oauth2/token", h.token)
+	mux.HandleFunc("POST /oauth2/device_authorization", h.deviceAuthorization)
+	return mux
+}
+
+// jwks serves the public signing keys as a JSON Web Key Set.
+// Verifiers may cache it briefly and should refetch when they meet an unknown kid.
+func (h *HTTPHandler) jwks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	h.writeJSON(w, http.StatusOK, h.ctrl.JWKS())
+}
+
+// oauthError is the error body defined by RFC 6749 section 5.2.
+type oauthError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// tokenResponse is the successful token response defined by RFC 6749 section 5.1.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// deviceAuthorizationResponse is the device authorization response defined by RFC 8628 section 3.2.
+type deviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+// token implements the OAuth2 token endpoint for the client-credentials and device code grants.
+func (h *HTTPHandler) token(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if err := r.ParseForm(); err != nil {
+		h.writeOAuthError(w, http.StatusBadRequest, "invalid_request", "malformed request body")
+		return
+	}
+	switch r.PostForm.Get("grant_type") {
+	case grantTypeClientCredentials:
+		h.clientCredentialsGrant(w, r)
+	case grantTypeDeviceCode:
+		h.deviceCodeGrant(w, r)
+	default:
+		h.writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "only client_credentials and device_code are supported")
+	}
+}
+
+// clientCredentialsGrant implements the OAuth2 client-credentials grant. Clients authenticate with HTTP Basic
+// (client_secret_basic) or with client_id and client_secret form fields (client_secret_post).
+func (h *HTTPHandler) clientCredentialsGrant(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, usedBasic, err := clientCredentials(r)
+	if err != nil {
+		h.writeOAuthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	h.logger.Info("Token request received",
+		zap.String("client_id", clientID),
+		zap.String("scope", r.PostForm.Get("scope")),
+	)
+
+	token, err := h.ctrl.ClientCredentials(r.Context(), clientID, clientSecret, strings.Fields(r.PostForm.Get("scope")))
+	switch {
+	case err == nil:
+	case errors.Is(err, controllers.ErrInvalidClient):
+		if usedBasic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+		}
+		h.writeOAuthError(w, http.StatusUnauthorized, "invalid_client", controllers.ErrInvalidClient.Error())
+		return
+	case errors.Is(err, controllers.ErrScopeNotAllowed):
+		h.writeOAuthError(w, http.StatusBadRequest, "invalid_scope", err.Error())
+		return
+	default:
+		h.logger.Error("Token request failed", zap.Error(err))
+		h.writeOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		ExpiresIn:   int64(token.ExpiresIn.Seconds()),
+		Scope:       strings.Join(token.Scopes, " "),
+	})
+}
+
+// deviceCodeGrant implements the device code grant of RFC 8628 section 3.4 for public clients,
+// which identify themselves with the client_id form field alone.
+func (h *HTTPHandler) deviceCodeGrant(w http.ResponseWriter, r *http.Request) {
+	clientID, deviceCode := r.PostForm.Get("client_id"), r.PostForm.Get("device_code")
+	if clientID == "" || deviceCode == "" {
+		h.writeOAuthError(w, http.StatusBadRequest, "invalid_request", "client_id and device_code are required")
+		return
+	}
+
+	authTokens, err := h.ctrl.PollDeviceToken(r.Context(), clientID, deviceCode, httpClientInfo(r))
+	switch {
+	case err == nil:
+	case errors.Is(err, controllers.ErrAuthorizationPending), errors.Is(err, controllers.ErrSlowDown),
+		errors.Is(err, controllers.ErrDeviceAccessDenied), errors.Is(err, controllers.ErrDeviceCodeExpired):
+		// These errors carry their RFC 8628 error code as the message.
+		h.writeOAuthError(w, http.StatusBadRequest, err.Error(), "")
+		return
+	case errors.Is(err, controllers.ErrInvalidDeviceCode):
+		h.writeOAuthError(w, http.StatusBadRequest, "invalid_grant", err.Error())
+		return
+	default:
+		h.logger.Error("Token request failed", zap.Error(err))
+		h.writeOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken:  authTokens.AccessToken,
+		TokenType:    authTokens.TokenType,
+		ExpiresIn:    int64(authTokens.ExpiresIn.Seconds()),
+		RefreshToken: authTokens.RefreshToken,
+	})
+}
+
+// deviceAuthorization implements the device authorization endpoint of RFC 8628 section 3.1.
+func (h *HTTPHandler) deviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := r.ParseForm(); err != nil {
+		h.writeOAuthError(w, http.StatusBadRequest, "invalid_request", "malformed request body")
+		return
+	}
+	clientID := r.PostForm.Get("client_id")
+	if clientID == "" {
+		h.writeOAuthError(w, http.StatusBadRequest, "invalid_request", "client_id is required")
+		return
+	}
+
+	h.logger.Info("Device authorization request received", zap.String("client_id", clientID))
+
+	code, err := h.ctrl.StartDeviceAuthorization(r.Context(), clientID)
+	switch {
+	case err == nil:
+	case errors.Is(err, controllers.ErrInvalidClient):
+		h.writeOAuthError(w, http.StatusUnauthorized, "invalid_client", controllers.ErrInvalidClient.Error())
+		return
+	default:
+		h.logger.Error("Device authorization request failed", zap.Error(err))
+		h.writeOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, deviceAuthorizationResponse{
+		DeviceCode:              code.DeviceCode,
+		UserCode:                code.UserCode,
+		VerificationURI:         code.VerificationURI,
+		VerificationURIComplete: code.VerificationURIComplete,
+		ExpiresIn:               int64(code.ExpiresIn.Seconds()),
+		Interval:                int64(code.Interval.Seconds()),
+	})
+}
+
+// clientCredentials extracts the client ID and secret from the Authorization header or the form.
+// Using both methods at once is rejected, as RFC 6749 section 2.3 requires.
+func clientCredentials(r *http.Request) (clientID, clientSecret string, usedBasic bool, err error) {
+	if user, pass, ok := r.BasicAuth(); ok {
+		if r.PostForm.Get("client_secret") != "" {
+			return "", "", false, errors.New("use only one client authentication method")
+		}
+		// Credentials are form-encoded before being placed in the Basic header (RFC 6749 section 2.3.1).
+		if clientID, err = url.QueryUnescape(user); err != nil {
+			return "", "", false, errors.New("malformed client_id")
+		}
+		if clientSecret, err = url.QueryUnescape(pass); err != nil {
+			return "", "", false, errors.New("malformed client_secret")
+		}
+		return clientID, clientSecret, true, nil
+	}
+
+	clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	if clientID == "" || clientSecret == "" {
+		return "", "", false, errors.New("client_id and client_secret are required")
+	}
+	return clientID, clientSecret, false, nil
+}
+
+// writeOAuthError writes an RFC 6749 error response.
+func (h *HTTPHandler) writeOAuthError(w http.ResponseWriter, statusCode int, code, description string) {
+	h.writeJSON(w, statusCode, oauthError{Error: code, ErrorDescription: description})
+}
+
+// writeJSON encodes body as the JSON response with the given status code.
+func (h *HTTPHandler) writeJSON(w http.ResponseWriter, statusCode int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Error("Failed to write HTTP response", zap.Error(err))
+	}
+}
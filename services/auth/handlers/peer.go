@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/himakhaitan/noreboothq/services/auth/controllers"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// peerAddress returns the IP address of the client that opened the connection,
+// or an empty string if it is unknown. Behind a proxy this is the proxy's address.
+func peerAddress(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// clientInfo describes the calling device from the gRPC peer and the user-agent metadata
+// that gRPC clients send with every call.
+func clientInfo(ctx context.Context) controllers.ClientInfo {
+	info := controllers.ClientInfo{IPAddress: peerAddress(ctx)}
+	if userAgents := metadata.ValueFromIncomingContext(ctx, "user-agent"); len(userAgents) > 0 {
+		info.UserAgent = userAgents[0]
+	}
+	return info
+}
+
+// httpClientInfo describes the calling device of a plain-HTTP request from its remote address
+// and User-Agent header.
+func httpClientInfo(r *http.Request) controllers.ClientInfo {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return controllers.ClientInfo{IPAddress: host, UserAgent: r.UserAgent()}
+}
@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Run calls fn every interval until ctx is canceled.
+// Failures are logged and do not stop the job. A non-positive interval disables the job.
+func Run(ctx context.Context, logger *zap.Logger, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	if interval <= 0 {
+		logger.Info("Background job disabled", zap.String("job", name))
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := fn(ctx); err != nil {
+				logger.Error("Background job failed", zap.String("job", name), zap.Error(err))
+			}
+		}
+	}
+}
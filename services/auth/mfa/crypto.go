@@ -0,0 +1,96 @@
+package mfa
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// encryptionKeySize is the AES-256 key length in bytes.
+const encryptionKeySize = 32
+
+// secretBox encrypts TOTP secrets at rest with AES-256-GCM.
+// Sealed values are base64(nonce || ciphertext).
+type secretBox struct {
+	aead cipher.AEAD
+}
+
+// loadSecretBox reads a base64-encoded 32-byte key from path. If the file does not exist and
+// generateIfMissing is set, a new key is generated and written to it, which is convenient for
+// local development.
+func loadSecretBox(path string, generateIfMissing bool) (*secretBox, error) {
+	if path == "" {
+		return nil, fmt.Errorf("mfa encryption_key_file cannot be empty")
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && generateIfMissing {
+		data, err = generateEncryptionKey(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mfa encryption key %s: %w", path, err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != encryptionKeySize {
+		return nil, fmt.Errorf("mfa encryption key %s must be %d base64-encoded bytes", path, encryptionKeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize mfa cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize mfa cipher: %w", err)
+	}
+	return &secretBox{aead: aead}, nil
+}
+
+// seal encrypts plaintext with a fresh random nonce.
+func (b *secretBox) seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts a value produced by seal.
+func (b *secretBox) open(sealed string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < b.aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted secret")
+	}
+	nonce, ciphertext := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// generateEncryptionKey writes a new random key to path and returns its encoded contents.
+func generateEncryptionKey(path string) ([]byte, error) {
+	key := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate mfa encryption key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+
+	encoded := []byte(base64.StdEncoding.EncodeToString(key) + "\n")
+	if err := os.WriteFile(path, encoded, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write mfa encryption key %s: %w", path, err)
+	}
+	return encoded, nil
+}
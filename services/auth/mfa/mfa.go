@@ -0,0 +1,105 @@
+package mfa
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/config"
+)
+
+// Manager generates and validates TOTP (RFC 6238) secrets and recovery codes.
+// Secrets are only ever handed out in the clear once, at enrollment; the value stored
+// on the user is encrypted with the configured key.
+type Manager struct {
+	box           *secretBox
+	issuer        string
+	challengeTTL  time.Duration
+	recoveryCodes int
+}
+
+// Enrollment is a freshly generated TOTP secret awaiting confirmation.
+type Enrollment struct {
+	// Secret is the base32 secret to show the user.
+	Secret string
+	// URI is the otpauth:// provisioning URI for QR codes.
+	URI string
+	// SealedSecret is the encrypted secret to store on the user.
+	SealedSecret string
+}
+
+// NewManager creates a Manager from the MFA configuration, loading the secret encryption key.
+func NewManager(cfg config.MFAConfig) (*Manager, error) {
+	if cfg.ChallengeTTL <= 0 {
+		return nil, fmt.Errorf("mfa challenge_ttl must be positive")
+	}
+	if cfg.RecoveryCodes <= 0 {
+		return nil, fmt.Errorf("mfa recovery_codes must be positive")
+	}
+
+	box, err := loadSecretBox(cfg.EncryptionKeyFile, cfg.GenerateKeyIfMissing)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manager{
+		box:           box,
+		issuer:        cfg.Issuer,
+		challengeTTL:  cfg.ChallengeTTL,
+		recoveryCodes: cfg.RecoveryCodes,
+	}, nil
+}
+
+// ChallengeTTL returns how long a login has to complete the second factor.
+func (m *Manager) ChallengeTTL() time.Duration {
+	return m.challengeTTL
+}
+
+// NewEnrollment generates a TOTP secret for the given account name (usually the email).
+func (m *Manager) NewEnrollment(account string) (*Enrollment, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := m.box.seal(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Enrollment{
+		Secret:       secret,
+		URI:          provisioningURI(m.issuer, account, secret),
+		SealedSecret: sealed,
+	}, nil
+}
+
+// ValidateTOTP checks a code against an encrypted secret. On success it returns the time step
+// the code belongs to, which callers persist so the same code cannot be replayed.
+func (m *Manager) ValidateTOTP(sealedSecret string, code string, now time.Time) (int64, bool, error) {
+	secret, err := m.box.open(sealedSecret)
+	if err != nil {
+		return 0, false, err
+	}
+	step, ok := validateTOTP(secret, code, now)
+	return step, ok, nil
+}
+
+// NewRecoveryCodes generates the configured number of recovery codes. It returns the codes
+// to show the user once and their hashes to store.
+func (m *Manager) NewRecoveryCodes() (codes []string, hashes []string, err error) {
+	codes = make([]string, m.recoveryCodes)
+	hashes = make([]string, m.recoveryCodes)
+	for i := range codes {
+		if codes[i], err = newRecoveryCode(); err != nil {
+			return nil, nil, err
+		}
+		hashes[i] = HashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// IsTOTPCode reports whether code has the shape of a TOTP code (six digits)
+// rather than a recovery code.
+func IsTOTPCode(code string) bool {
+	return isTOTPCode(code)
+}
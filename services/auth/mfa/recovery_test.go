@@ -0,0 +1,82 @@
+package mfa
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashRecoveryCodeNormalizes(t *testing.T) {
+	want := HashRecoveryCode("abcde-fghjk")
+	tests := []struct {
+		name string
+		code string
+	}{
+		{name: "as shown", code: "abcde-fghjk"},
+		{name: "upper case", code: "ABCDE-FGHJK"},
+		{name: "mixed case", code: "AbCdE-fGhJk"},
+		{name: "without dash", code: "abcdefghjk"},
+		{name: "spaces instead of dash", code: "abcde fghjk"},
+		{name: "grouped differently", code: "ab-cd-ef gh jk"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HashRecoveryCode(tt.code); got != want {
+				t.Errorf("HashRecoveryCode(%q) does not match the hash of the code as shown", tt.code)
+			}
+		})
+	}
+}
+
+func TestHashRecoveryCodeDistinguishesCodes(t *testing.T) {
+	for _, code := range []string{"abcde-fghjm", "abcde-fghj", "abcde-fghjkk", ""} {
+		if HashRecoveryCode(code) == HashRecoveryCode("abcde-fghjk") {
+			t.Errorf("HashRecoveryCode(%q) matches a different code", code)
+		}
+	}
+}
+
+func TestNewRecoveryCode(t *testing.T) {
+	seen := make(map[string]bool)
+	for range 100 {
+		code, err := newRecoveryCode()
+		if err != nil {
+			t.Fatalf("newRecoveryCode: %v", err)
+		}
+		first, second, ok := strings.Cut(code, "-")
+		if !ok || len(first) != 5 || len(second) != 5 {
+			t.Fatalf("newRecoveryCode() = %q, want xxxxx-xxxxx", code)
+		}
+		for _, r := range first + second {
+			if !strings.ContainsRune(recoveryAlphabet, r) {
+				t.Fatalf("newRecoveryCode() = %q, has %q outside the alphabet", code, r)
+			}
+		}
+		if isTOTPCode(code) {
+			t.Fatalf("newRecoveryCode() = %q, would be taken for a TOTP code", code)
+		}
+		if seen[code] {
+			t.Fatalf("newRecoveryCode() repeated %q", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestIsTOTPCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"123456", true},
+		{"000000", true},
+		{"12345", false},
+		{"1234567", false},
+		{"12345a", false},
+		{"abcde-fghjk", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isTOTPCode(tt.code); got != tt.want {
+			t.Errorf("isTOTPCode(%q) = %t, want %t", tt.code, got, tt.want)
+		}
+	}
+}
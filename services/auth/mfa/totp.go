@@ -0,0 +1,81 @@
+package mfa
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// totpPeriod is the RFC 6238 time step.
+	totpPeriod = 30 * time.Second
+	// totpDigits is the length of generated codes.
+	totpDigits = 6
+	// totpSkew is how many steps before and after the current one are accepted to tolerate clock drift.
+	totpSkew = 1
+	// secretSize is the length of generated secrets in bytes (160 bits, as recommended by RFC 4226).
+	secretSize = 20
+)
+
+// b32 encodes secrets the way authenticator apps expect them: upper-case base32 without padding.
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newSecret returns a random base32-encoded TOTP secret.
+func newSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+	return b32.EncodeToString(b), nil
+}
+
+// provisioningURI returns the otpauth:// URI authenticator apps import, usually via a QR code.
+func provisioningURI(issuer string, account string, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	params := url.Values{
+		"secret":    {secret},
+		"issuer":    {issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(totpDigits)},
+		"period":    {fmt.Sprint(int(totpPeriod.Seconds()))},
+	}
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// validateTOTP checks code against secret at time now, allowing totpSkew steps of drift.
+// It returns the matching time step so callers can reject a code that was already used.
+func validateTOTP(secret string, code string, now time.Time) (int64, bool) {
+	key, err := b32.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := now.Unix() / int64(totpPeriod.Seconds())
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(hotp(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// hotp computes the RFC 4226 HOTP value of key for the given counter.
+func hotp(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1_000_000)
+}
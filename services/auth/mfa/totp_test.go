@@ -0,0 +1,84 @@
+package mfa
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors, "12345678901234567890", in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTP(t *testing.T) {
+	// RFC 6238 appendix B codes, truncated to six digits, and the time steps they belong to.
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		now      time.Time
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "rfc vector at 59", secret: rfcSecret, code: "287082", now: time.Unix(59, 0), wantStep: 1, wantOK: true},
+		{name: "rfc vector at 1111111109", secret: rfcSecret, code: "081804", now: time.Unix(1111111109, 0), wantStep: 37037036, wantOK: true},
+		{name: "rfc vector at 1234567890", secret: rfcSecret, code: "005924", now: time.Unix(1234567890, 0), wantStep: 41152263, wantOK: true},
+		{name: "lower-case secret", secret: "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code: "005924", now: time.Unix(1234567890, 0), wantStep: 41152263, wantOK: true},
+		{name: "one step late", secret: rfcSecret, code: "005924", now: time.Unix(1234567890+30, 0), wantStep: 41152263, wantOK: true},
+		{name: "one step early", secret: rfcSecret, code: "005924", now: time.Unix(1234567890-30, 0), wantStep: 41152263, wantOK: true},
+		{name: "two steps late", secret: rfcSecret, code: "005924", now: time.Unix(1234567890+60, 0)},
+		{name: "two steps early", secret: rfcSecret, code: "005924", now: time.Unix(1234567890-60, 0)},
+		{name: "wrong code", secret: rfcSecret, code: "005925", now: time.Unix(1234567890, 0)},
+		{name: "eight digits", secret: rfcSecret, code: "89005924", now: time.Unix(1234567890, 0)},
+		{name: "too short", secret: rfcSecret, code: "05924", now: time.Unix(1234567890, 0)},
+		{name: "empty code", secret: rfcSecret, code: "", now: time.Unix(1234567890, 0)},
+		{name: "malformed secret", secret: "not base32!", code: "005924", now: time.Unix(1234567890, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := validateTOTP(tt.secret, tt.code, tt.now)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("validateTOTP(%q, %q, %d) = %d, %t, want %d, %t", tt.secret, tt.code, tt.now.Unix(), step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+// A code stays valid for the skew window, but always reports the step it was generated for, so
+// callers storing the last used step can reject it when it is presented again.
+func TestValidateTOTPReportsTheCodesStepForReplayChecks(t *testing.T) {
+	issued := time.Unix(1234567890, 0)
+	code := hotp(mustDecode(t, rfcSecret), issued.Unix()/30)
+
+	var steps []int64
+	for _, now := range []time.Time{issued, issued.Add(15 * time.Second), issued.Add(30 * time.Second)} {
+		step, ok := validateTOTP(rfcSecret, code, now)
+		if !ok {
+			t.Fatalf("validateTOTP at %d rejected a code within the skew window", now.Unix())
+		}
+		steps = append(steps, step)
+	}
+	for _, step := range steps[1:] {
+		if step != steps[0] {
+			t.Errorf("replayed code reported step %d, first use reported %d", step, steps[0])
+		}
+	}
+}
+
+func TestHOTP(t *testing.T) {
+	// RFC 4226 appendix D values for counters 0 to 9.
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	key := mustDecode(t, rfcSecret)
+	for counter, code := range want {
+		if got := hotp(key, int64(counter)); got != code {
+			t.Errorf("hotp(counter %d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func mustDecode(t *testing.T, secret string) []byte {
+	t.Helper()
+	key, err := b32.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("failed to decode secret: %v", err)
+	}
+	return key
+}
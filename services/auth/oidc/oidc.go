@@ -0,0 +1,279 @@
+// Package oidc signs users in through external OpenID Connect identity providers
+// using the authorization code flow with PKCE.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/himakhaitan/noreboothq/services/auth/config"
+)
+
+var (
+	// ErrInvalidIDToken is returned when an ID token fails signature, issuer, audience, expiry or nonce checks.
+	ErrInvalidIDToken = errors.New("invalid id token")
+	// ErrTokenExchange is returned when the provider refuses to exchange an authorization code.
+	ErrTokenExchange = errors.New("authorization code exchange failed")
+)
+
+// defaultScopes are always requested; they make the provider return the user's email in the ID token.
+var defaultScopes = []string{"openid", "email", "profile"}
+
+// Provider is an external OpenID Connect identity provider. Its endpoints are discovered from the
+// issuer on first use and its signing keys are cached, so providers can be created before they are reachable.
+type Provider struct {
+	name   string
+	cfg    config.OIDCProviderConfig
+	client *http.Client
+
+	mu            sync.Mutex
+	meta          *metadata
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// metadata is the subset of the provider's discovery document (OpenID Connect Discovery 1.0) in use.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// IDToken holds the verified claims of an ID token that identify the user.
+type IDToken struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// idTokenClaims are the ID token claims as they appear on the wire.
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified flexBool `json:"email_verified"`
+	Name          string   `json:"name"`
+}
+
+// flexBool decodes a JSON boolean, also accepting the quoted "true" some providers send.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", `"true"`:
+		*b = true
+	case "false", `"false"`, "null":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+	return nil
+}
+
+// NewProvider creates a provider from its configuration. No network calls are made until it is used.
+func NewProvider(name string, cfg config.OIDCProviderConfig) (*Provider, error) {
+	switch {
+	case cfg.Issuer == "":
+		return nil, fmt.Errorf("oidc provider %q: issuer is required", name)
+	case cfg.ClientID == "":
+		return nil, fmt.Errorf("oidc provider %q: client_id is required", name)
+	case cfg.RedirectURL == "":
+		return nil, fmt.Errorf("oidc provider %q: redirect_url is required", name)
+	}
+
+	return &Provider{
+		name:   name,
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// NewProviders creates every provider configured in the oidc block, keyed by name.
+func NewProviders(cfg config.OIDCConfig) (map[string]*Provider, error) {
+	providers := make(map[string]*Provider, len(cfg.Providers))
+	for name, providerCfg := range cfg.Providers {
+		provider, err := NewProvider(name, providerCfg)
+		if err != nil {
+			return nil, err
+		}
+		providers[name] = provider
+	}
+	return providers, nil
+}
+
+// Name returns the name the provider is configured under.
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// AuthCodeURL returns the provider URL to send the user's browser to. The provider redirects back
+// to the configured redirect URL with a code and the given state once the user has signed in.
+func (p *Provider) AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(meta.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.scopes(), " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+	return authURL.String(), nil
+}
+
+// Exchange redeems an authorization code at the provider's token endpoint and returns the raw ID token.
+// The code verifier proves this is the client that started the flow.
+func (p *Provider) Exchange(ctx context.Context, code string, codeVerifier string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// client_secret_basic: credentials are form-encoded before going into the header (RFC 6749 section 2.3.1).
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach token endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("%w: malformed response (status %d)", ErrTokenExchange, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return "", fmt.Errorf("%w: %s", ErrTokenExchange, strings.TrimSpace(body.Error+" "+body.ErrorDescription))
+	}
+	if body.IDToken == "" {
+		return "", fmt.Errorf("%w: response has no id_token", ErrTokenExchange)
+	}
+	return body.IDToken, nil
+}
+
+// VerifyIDToken checks the ID token's signature against the provider's published keys, its issuer,
+// audience and expiry, and that it carries the nonce sent with the authorization request.
+func (p *Provider) VerifyIDToken(ctx context.Context, rawToken string, nonce string) (*IDToken, error) {
+	var claims idTokenClaims
+	_, err := jwt.ParseWithClaims(rawToken, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidIDToken)
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	return &IDToken{
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(claims.Email),
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+	}, nil
+}
+
+// AllowsEmail reports whether the email's domain may sign in through this provider.
+func (p *Provider) AllowsEmail(email string) bool {
+	if len(p.cfg.AllowedDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return false
+	}
+	domain := email[at+1:]
+	return slices.ContainsFunc(p.cfg.AllowedDomains, func(allowed string) bool {
+		return strings.EqualFold(allowed, domain)
+	})
+}
+
+// scopes returns the scopes requested from the provider.
+func (p *Provider) scopes() []string {
+	scopes := slices.Clone(defaultScopes)
+	for _, scope := range p.cfg.Scopes {
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// metadata returns the provider's discovery document, fetching it on first use.
+func (p *Provider) metadata(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.metadataLocked(ctx)
+}
+
+// metadataLocked is metadata for callers already holding p.mu. A failed fetch is retried on the next call.
+func (p *Provider) metadataLocked(ctx context.Context) (*metadata, error) {
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	var meta metadata
+	discoveryURL := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, discoveryURL, &meta); err != nil {
+		return nil, fmt.Errorf("failed to discover oidc provider %q: %w", p.name, err)
+	}
+	// OpenID Connect Discovery section 4.3: the document must describe the configured issuer.
+	if meta.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc provider %q: discovery document is for issuer %q", p.name, meta.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc provider %q: discovery document is missing endpoints", p.name)
+	}
+
+	p.meta = &meta
+	return p.meta, nil
+}
@@ -0,0 +1,59 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	identitypb "github.com/himakhaitan/noreboothq/proto/identity"
+	"github.com/himakhaitan/noreboothq/services/auth/tokens"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// callTokenTTL is the lifetime of the token each membership check is made with; it only has to
+// outlive a single call.
+const callTokenTTL = time.Minute
+
+// Directory asks the identity service whether users belong to organizations.
+// It implements controllers.OrganizationDirectory.
+type Directory struct {
+	client   identitypb.IdentityServiceClient
+	tokens   *tokens.Manager
+	audience string
+}
+
+// NewDirectory creates a Directory that calls the identity service through client, with tokens
+// restricted to audience, the name the identity service accepts in aud claims.
+func NewDirectory(client identitypb.IdentityServiceClient, tokens *tokens.Manager, audience string) *Directory {
+	return &Directory{client: client, tokens: tokens, audience: audience}
+}
+
+// IsMember looks the organization up as the user, with a short-lived token for their session, so
+// the identity service applies its usual visibility rules and a revoked session gets no answer.
+// Organizations that do not exist, or that the user cannot see, count as not belonging.
+func (d *Directory) IsMember(ctx context.Context, subject string, sessionID string, orgID string) (bool, error) {
+	id, err := strconv.ParseUint(orgID, 10, 64)
+	if err != nil || id == 0 {
+		return false, nil
+	}
+
+	token, err := d.tokens.IssueForService(subject, sessionID, d.audience, callTokenTTL)
+	if err != nil {
+		return false, err
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+
+	resp, err := d.client.GetOrganization(ctx, &identitypb.GetOrganizationRequest{Id: id})
+	switch status.Code(err) {
+	case codes.OK:
+		// Identity admins see organizations they do not belong to; only members have a role.
+		return resp.Organization.GetRole() != "", nil
+	case codes.NotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to look up organization %d: %w", id, err)
+	}
+}
@@ -0,0 +1,85 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2Params are the settings argon2id hashes are made with (RFC 9106).
+type argon2Params struct {
+	memory      uint32 // KiB
+	iterations  uint32
+	parallelism uint8
+	saltLength  uint32
+	keyLength   uint32
+}
+
+func (p argon2Params) validate() error {
+	switch {
+	case p.iterations < 1:
+		return fmt.Errorf("password_hashing argon2 iterations must be at least 1")
+	case p.parallelism < 1:
+		return fmt.Errorf("password_hashing argon2 parallelism must be at least 1")
+	case p.memory < 8*uint32(p.parallelism):
+		return fmt.Errorf("password_hashing argon2 memory must be at least 8 KiB per lane")
+	case p.saltLength < 16:
+		return fmt.Errorf("password_hashing argon2 salt_length must be at least 16 bytes")
+	case p.keyLength < 16:
+		return fmt.Errorf("password_hashing argon2 key_length must be at least 16 bytes")
+	}
+	return nil
+}
+
+// hash returns the hash in the PHC string format: $argon2id$v=19$m=<KiB>,t=<iterations>,p=<lanes>$<salt>$<digest>.
+func (p argon2Params) hash(plain string) (string, error) {
+	salt := make([]byte, p.saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(plain), salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		AlgorithmArgon2id, argon2.Version, p.memory, p.iterations, p.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// verifyArgon2id recomputes the digest with the salt and parameters encoded in the hash.
+func verifyArgon2id(hash string, plain string) error {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return fmt.Errorf("%w: malformed argon2id hash", ErrUnknownAlgorithm)
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return fmt.Errorf("%w: unsupported argon2 version %q", ErrUnknownAlgorithm, parts[2])
+	}
+
+	var p argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return fmt.Errorf("%w: malformed argon2id parameters", ErrUnknownAlgorithm)
+	}
+	if p.iterations < 1 || p.parallelism < 1 {
+		return fmt.Errorf("%w: invalid argon2id parameters", ErrUnknownAlgorithm)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return fmt.Errorf("%w: malformed argon2id salt", ErrUnknownAlgorithm)
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(want) == 0 {
+		return fmt.Errorf("%w: malformed argon2id digest", ErrUnknownAlgorithm)
+	}
+
+	got := argon2.IDKey([]byte(plain), salt, p.iterations, p.memory, p.parallelism, uint32(len(want)))
+	if subtle.ConstantTimeCompare(got, want) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
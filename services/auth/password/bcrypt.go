@@ -0,0 +1,44 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcryptHashPattern matches the modular crypt format of bcrypt: $2a$<cost>$<22-char salt><31-char digest>.
+var bcryptHashPattern = regexp.MustCompile(`^\$2[aby]?\$\d{2}\$[./A-Za-z0-9]{53}$`)
+
+// bcryptParams are the settings bcrypt hashes are made with.
+type bcryptParams struct {
+	cost int
+}
+
+func (p bcryptParams) validate() error {
+	if p.cost < bcrypt.MinCost || p.cost > bcrypt.MaxCost {
+		return fmt.Errorf("password_hashing bcrypt_cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return nil
+}
+
+func (p bcryptParams) hash(plain string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(plain), p.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func isBcrypt(hash string) bool {
+	return bcryptHashPattern.MatchString(hash)
+}
+
+func verifyBcrypt(hash string, plain string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(plain))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatch
+	}
+	return err
+}
@@ -0,0 +1,140 @@
+package password
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// sha1HexLength is the length of a hex-encoded SHA-1 digest, the key of every line in the list.
+const sha1HexLength = 40
+
+// maxBreachedLineLength bounds a line of the list; real lines ("<hash>:<count>\r\n") are under 60 bytes.
+const maxBreachedLineLength = 256
+
+// BreachedList looks passwords up in a local copy of the Have I Been Pwned Pwned Passwords list,
+// so no password or hash prefix ever leaves the service. The file holds one "<SHA-1>:<count>" line
+// per password, upper-case hex and sorted by hash, as the official downloader produces it.
+// Lookups binary-search the file with positioned reads, so it is never loaded into memory and the
+// operating system's page cache keeps the hot parts fast. It is safe for concurrent use.
+type BreachedList struct {
+	file     *os.File
+	size     int64
+	minCount int
+}
+
+// OpenBreachedList opens the sorted SHA-1 list at path. Passwords seen in fewer than minCount
+// breaches are let through; values below 1 count every listed password.
+// The file stays open for the lifetime of the list.
+func OpenBreachedList(path string, minCount int) (*BreachedList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached passwords file %s: %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat breached passwords file %s: %w", path, err)
+	}
+
+	list := &BreachedList{file: file, size: info.Size(), minCount: max(minCount, 1)}
+
+	// Catch a wrong file, such as the NTLM edition of the list, at startup rather than on every lookup.
+	first, err := list.lineAt(0)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read breached passwords file %s: %w", path, err)
+	}
+	if _, _, ok := parseBreachedLine(first); !ok {
+		file.Close()
+		return nil, fmt.Errorf("breached passwords file %s is not a sorted SHA-1 list: unexpected line %q", path, first)
+	}
+	return list, nil
+}
+
+// Contains reports whether the password appears in the list at least minCount times.
+func (l *BreachedList) Contains(plain string) (bool, error) {
+	sum := sha1.Sum([]byte(plain))
+	target := []byte(hex.EncodeToString(sum[:]))
+	// The list uses upper-case hex.
+	target = bytes.ToUpper(target)
+
+	// Find the smallest offset whose next line has a hash >= target. The line starting there,
+	// if any, is the only one that can hold the password.
+	lo, hi := int64(0), l.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		line, err := l.lineAt(mid)
+		if err != nil {
+			return false, err
+		}
+		hash, _, ok := parseBreachedLine(line)
+		if ok && bytes.Compare(hash, target) < 0 {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	line, err := l.lineAt(lo)
+	if err != nil {
+		return false, err
+	}
+	hash, count, ok := parseBreachedLine(line)
+	return ok && bytes.Equal(hash, target) && count >= l.minCount, nil
+}
+
+// lineAt returns the first line starting at or after offset, without its line ending.
+// At the end of the file it returns an empty line.
+func (l *BreachedList) lineAt(offset int64) ([]byte, error) {
+	start := offset
+	if offset > 0 {
+		// Unless offset is the start of the file, skip to the character after the next newline,
+		// looking from offset-1 so a line starting exactly at offset is found.
+		buf := make([]byte, maxBreachedLineLength)
+		n, err := l.file.ReadAt(buf, offset-1)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read breached passwords file: %w", err)
+		}
+		i := bytes.IndexByte(buf[:n], '\n')
+		if i < 0 {
+			return nil, nil
+		}
+		start = offset + int64(i)
+	}
+
+	buf := make([]byte, maxBreachedLineLength)
+	n, err := l.file.ReadAt(buf, start)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read breached passwords file: %w", err)
+	}
+	line := buf[:n]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	return bytes.TrimRight(line, "\r"), nil
+}
+
+// parseBreachedLine splits a "<SHA-1>:<count>" line. Lines without a count are taken to count once.
+func parseBreachedLine(line []byte) (hash []byte, count int, ok bool) {
+	hash, rest, found := bytes.Cut(line, []byte(":"))
+	if len(hash) != sha1HexLength {
+		return nil, 0, false
+	}
+	if _, err := hex.DecodeString(string(hash)); err != nil {
+		return nil, 0, false
+	}
+	if !found {
+		return hash, 1, true
+	}
+	count, err := strconv.Atoi(string(bytes.TrimSpace(rest)))
+	if err != nil {
+		return nil, 0, false
+	}
+	return hash, count, true
+}
@@ -0,0 +1,190 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// breachedLine returns the list line of a password seen count times.
+func breachedLine(plain string, count string) string {
+	sum := sha1.Sum([]byte(plain))
+	line := strings.ToUpper(hex.EncodeToString(sum[:]))
+	if count != "" {
+		line += ":" + count
+	}
+	return line
+}
+
+// writeBreachedList writes lines, sorted as the real list is, joined by newline, and opens it.
+func writeBreachedList(t *testing.T, lines []string, newline string, trailing bool, minCount int) *BreachedList {
+	t.Helper()
+	slices.Sort(lines)
+	content := strings.Join(lines, newline)
+	if trailing {
+		content += newline
+	}
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write list: %v", err)
+	}
+	list, err := OpenBreachedList(path, minCount)
+	if err != nil {
+		t.Fatalf("OpenBreachedList: %v", err)
+	}
+	t.Cleanup(func() { list.file.Close() })
+	return list
+}
+
+func TestBreachedListContains(t *testing.T) {
+	listed := []string{"password", "123456", "qwerty", "letmein", "dragon", "monkey", "hunter2", "trustno1"}
+	lines := make([]string, 0, len(listed)+1)
+	for i, plain := range listed {
+		lines = append(lines, breachedLine(plain, strings.Repeat("9", i+1)))
+	}
+	lines = append(lines, breachedLine("rarely-seen", "2"))
+	slices.Sort(lines)
+
+	// The passwords whose lines come first and last in the sorted list.
+	var first, last string
+	for _, plain := range append(listed, "rarely-seen") {
+		switch breachedLine(plain, "") {
+		case lines[0][:sha1HexLength]:
+			first = plain
+		case lines[len(lines)-1][:sha1HexLength]:
+			last = plain
+		}
+	}
+
+	formats := []struct {
+		name     string
+		newline  string
+		trailing bool
+	}{
+		{name: "lf", newline: "\n", trailing: true},
+		{name: "crlf", newline: "\r\n", trailing: true},
+		{name: "no trailing newline", newline: "\n", trailing: false},
+		{name: "crlf without trailing newline", newline: "\r\n", trailing: false},
+	}
+	for _, format := range formats {
+		t.Run(format.name, func(t *testing.T) {
+			list := writeBreachedList(t, slices.Clone(lines), format.newline, format.trailing, 1)
+			tests := []struct {
+				plain string
+				want  bool
+			}{
+				{first, true},
+				{last, true},
+				{"hunter2", true},
+				{"rarely-seen", true},
+				{"correct horse battery staple", false},
+				{"", false},
+				{"Password", false},
+			}
+			for _, tt := range tests {
+				got, err := list.Contains(tt.plain)
+				if err != nil {
+					t.Fatalf("Contains(%q): %v", tt.plain, err)
+				}
+				if got != tt.want {
+					t.Errorf("Contains(%q) = %t, want %t", tt.plain, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBreachedListContainsOutsideTheList(t *testing.T) {
+	list := writeBreachedList(t, []string{breachedLine("password", "5"), breachedLine("hunter2", "5")}, "\n", true, 1)
+	first, last := breachedLine("password", ""), breachedLine("hunter2", "")
+	if first > last {
+		first, last = last, first
+	}
+
+	// Lookups sorting before the first or after the last line must not fail or match a neighbour.
+	var before, after int
+	for i := range 50 {
+		plain := "unlisted-" + strings.Repeat("x", i)
+		switch hash := breachedLine(plain, ""); {
+		case hash < first:
+			before++
+		case hash > last:
+			after++
+		}
+		if got, err := list.Contains(plain); err != nil || got {
+			t.Errorf("Contains(%q) = %t, %v, want false", plain, got, err)
+		}
+	}
+	if before == 0 || after == 0 {
+		t.Fatalf("lookups did not cover both ends of the list: %d before, %d after", before, after)
+	}
+}
+
+func TestBreachedListMinCount(t *testing.T) {
+	lines := []string{
+		breachedLine("seen-once", "1"),
+		breachedLine("seen-nine-times", "9"),
+		breachedLine("seen-ten-times", "10"),
+		breachedLine("no-count", ""),
+	}
+	tests := []struct {
+		minCount int
+		plain    string
+		want     bool
+	}{
+		{0, "seen-once", true},
+		{1, "seen-once", true},
+		{1, "no-count", true},
+		{2, "no-count", false},
+		{10, "seen-nine-times", false},
+		{10, "seen-ten-times", true},
+		{11, "seen-ten-times", false},
+	}
+	for _, tt := range tests {
+		list := writeBreachedList(t, slices.Clone(lines), "\n", true, tt.minCount)
+		got, err := list.Contains(tt.plain)
+		if err != nil {
+			t.Fatalf("Contains(%q): %v", tt.plain, err)
+		}
+		if got != tt.want {
+			t.Errorf("minCount %d: Contains(%q) = %t, want %t", tt.minCount, tt.plain, got, tt.want)
+		}
+	}
+}
+
+func TestBreachedListSingleLine(t *testing.T) {
+	list := writeBreachedList(t, []string{breachedLine("only", "3")}, "\n", false, 1)
+	for plain, want := range map[string]bool{"only": true, "other": false} {
+		if got, err := list.Contains(plain); err != nil || got != want {
+			t.Errorf("Contains(%q) = %t, %v, want %t", plain, got, err, want)
+		}
+	}
+}
+
+func TestOpenBreachedListRejectsOtherFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		// The NTLM edition of the list has 32-character hashes.
+		{name: "ntlm list", content: strings.Repeat("A", 32) + ":3\n"},
+		{name: "plain text", content: "password\n123456\n"},
+		{name: "empty", content: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "pwned.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("failed to write list: %v", err)
+			}
+			if list, err := OpenBreachedList(path, 1); err == nil {
+				list.file.Close()
+				t.Errorf("OpenBreachedList accepted a %s file", tt.name)
+			}
+		})
+	}
+}
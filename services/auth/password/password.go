@@ -0,0 +1,140 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/himakhaitan/noreboothq/services/auth/config"
+)
+
+// Supported hashing algorithms, as named in the password_hashing config.
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var (
+	// ErrMismatch is returned when a plaintext password does not match the stored hash.
+	ErrMismatch = errors.New("password does not match")
+	// ErrUnknownAlgorithm is returned when a stored hash is in no supported format.
+	ErrUnknownAlgorithm = errors.New("unknown password hash format")
+)
+
+// Hasher hashes new passwords with the configured algorithm and parameters. Every stored hash
+// describes its own algorithm and parameters, so hashes made under older settings still verify
+// and NeedsRehash can tell when one should be upgraded.
+type Hasher struct {
+	algorithm string
+	bcrypt    bcryptParams
+	argon2    argon2Params
+	// scheme is the algorithm-and-parameters prefix of hashes made with the current settings.
+	scheme string
+	// dummyHash is a hash of a random value with the current settings. It is compared against when a user
+	// does not exist so that unknown accounts take as long to reject as wrong passwords.
+	dummyHash string
+}
+
+// NewHasher creates a Hasher from the password_hashing configuration.
+func NewHasher(cfg config.PasswordHashingConfig) (*Hasher, error) {
+	h := &Hasher{
+		algorithm: cfg.Algorithm,
+		bcrypt:    bcryptParams{cost: cfg.BcryptCost},
+		argon2: argon2Params{
+			memory:      cfg.Argon2.Memory,
+			iterations:  cfg.Argon2.Iterations,
+			parallelism: cfg.Argon2.Parallelism,
+			saltLength:  cfg.Argon2.SaltLength,
+			keyLength:   cfg.Argon2.KeyLength,
+		},
+	}
+
+	switch h.algorithm {
+	case AlgorithmBcrypt:
+		if err := h.bcrypt.validate(); err != nil {
+			return nil, err
+		}
+	case AlgorithmArgon2id:
+		if err := h.argon2.validate(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported password hashing algorithm %q", h.algorithm)
+	}
+
+	dummy, err := h.Hash("dummy password for timing equalization")
+	if err != nil {
+		return nil, err
+	}
+	h.dummyHash = dummy
+	h.scheme = Scheme(dummy)
+	return h, nil
+}
+
+// Hash returns a self-describing hash of the plaintext password, made with the configured algorithm.
+func (h *Hasher) Hash(plain string) (string, error) {
+	var (
+		hash string
+		err  error
+	)
+	switch h.algorithm {
+	case AlgorithmArgon2id:
+		hash, err = h.argon2.hash(plain)
+	default:
+		hash, err = h.bcrypt.hash(plain)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return hash, nil
+}
+
+// NeedsRehash reports whether a stored hash was made with a different algorithm or parameters
+// than the current settings, and should be replaced next time the plaintext is known.
+func (h *Hasher) NeedsRehash(hash string) bool {
+	return Scheme(hash) != h.scheme
+}
+
+// CurrentScheme returns the algorithm-and-parameters prefix of hashes made with the current settings.
+func (h *Hasher) CurrentScheme() string {
+	return h.scheme
+}
+
+// VerifyDummy burns the same amount of CPU as Verify without checking anything.
+// Call it on the "user not found" path to keep response times uniform.
+func (h *Hasher) VerifyDummy(plain string) {
+	_ = Verify(h.dummyHash, plain)
+}
+
+// Verify checks the plaintext password against a stored hash of any supported algorithm.
+// It returns ErrMismatch if the password is wrong, or another error if the hash is malformed.
+func Verify(hash string, plain string) error {
+	switch {
+	case isBcrypt(hash):
+		return verifyBcrypt(hash, plain)
+	case strings.HasPrefix(hash, "$"+AlgorithmArgon2id+"$"):
+		return verifyArgon2id(hash, plain)
+	default:
+		return ErrUnknownAlgorithm
+	}
+}
+
+// Scheme returns the part of a hash that names its algorithm and parameters, without the salt
+// and digest, e.g. "$2a$10$" or "$argon2id$v=19$m=19456,t=2,p=1$". Hashes with equal schemes were
+// made with the same settings. It returns "" for an unrecognised hash.
+func Scheme(hash string) string {
+	switch {
+	case isBcrypt(hash):
+		// The salt and digest are a fixed 53 characters.
+		return hash[:len(hash)-53]
+	case strings.HasPrefix(hash, "$"+AlgorithmArgon2id+"$"):
+		// $argon2id$v=19$m=...,t=...,p=...$<salt>$<digest>
+		parts := strings.SplitAfterN(hash, "$", 5)
+		if len(parts) != 5 {
+			return ""
+		}
+		return strings.Join(parts[:4], "")
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,130 @@
+package password
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/himakhaitan/noreboothq/services/auth/config"
+)
+
+// ErrPolicyViolation is wrapped by every error returned from Policy.Validate.
+// The wrapping error's message describes which rule was broken.
+var ErrPolicyViolation = errors.New("password does not meet policy")
+
+// Policy enforces the password rules configured under password_policy.
+type Policy struct {
+	cfg    config.PasswordPolicyConfig
+	banned map[string]struct{}
+	// breached is nil unless a breached passwords file is configured.
+	breached *BreachedList
+}
+
+// NewPolicy builds a Policy from configuration, loading the banned-password list and opening
+// the breached-password list if they are configured.
+func NewPolicy(cfg config.PasswordPolicyConfig) (*Policy, error) {
+	if cfg.MinLength < 0 || cfg.MaxLength < 0 {
+		return nil, fmt.Errorf("password policy lengths cannot be negative")
+	}
+	if cfg.MaxLength > 0 && cfg.MaxLength < cfg.MinLength {
+		return nil, fmt.Errorf("password policy max_length (%d) is lower than min_length (%d)", cfg.MaxLength, cfg.MinLength)
+	}
+
+	policy := &Policy{cfg: cfg, banned: map[string]struct{}{}}
+	if cfg.BannedPasswordsFile != "" {
+		if err := policy.loadBanned(cfg.BannedPasswordsFile); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.BreachedPasswordsFile != "" {
+		breached, err := OpenBreachedList(cfg.BreachedPasswordsFile, cfg.BreachedMinCount)
+		if err != nil {
+			return nil, err
+		}
+		policy.breached = breached
+	}
+	return policy, nil
+}
+
+// Validate checks the plaintext password against every configured rule and
+// returns the first violation found, wrapping ErrPolicyViolation. Any other error
+// means the breached-password list could not be read.
+func (p *Policy) Validate(plain string) error {
+	length := utf8.RuneCountInString(plain)
+	if length < p.cfg.MinLength {
+		return violation("must be at least %d characters long", p.cfg.MinLength)
+	}
+	if p.cfg.MaxLength > 0 && len(plain) > p.cfg.MaxLength {
+		return violation("must be at most %d bytes long", p.cfg.MaxLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range plain {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	switch {
+	case p.cfg.RequireUpper && !hasUpper:
+		return violation("must contain an upper-case letter")
+	case p.cfg.RequireLower && !hasLower:
+		return violation("must contain a lower-case letter")
+	case p.cfg.RequireDigit && !hasDigit:
+		return violation("must contain a digit")
+	case p.cfg.RequireSymbol && !hasSymbol:
+		return violation("must contain a symbol")
+	}
+
+	if _, ok := p.banned[strings.ToLower(plain)]; ok {
+		return violation("is too common")
+	}
+	if p.breached != nil {
+		breached, err := p.breached.Contains(plain)
+		if err != nil {
+			return err
+		}
+		if breached {
+			return violation("has appeared in a data breach; choose a different one")
+		}
+	}
+	return nil
+}
+
+// loadBanned reads the banned-password file into memory.
+// Blank lines and lines starting with '#' are skipped.
+func (p *Policy) loadBanned(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open banned passwords file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.banned[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read banned passwords file %s: %w", path, err)
+	}
+	return nil
+}
+
+func violation(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrPolicyViolation, fmt.Sprintf(format, args...))
+}
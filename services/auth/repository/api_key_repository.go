@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *entities.APIKey) error
+	GetByID(ctx context.Context, id uint) (*entities.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*entities.APIKey, error)
+	ListByUser(ctx context.Context, userID uint) ([]entities.APIKey, error)
+	ListByServiceAccount(ctx context.Context, serviceAccountID uint) ([]entities.APIKey, error)
+	Revoke(ctx context.Context, id uint) error
+	TouchLastUsed(ctx context.Context, id uint, at time.Time) error
+}
+
+// apiKeyRepository implements APIKeyRepository for machine client credentials.
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+// Create stores a new API key.
+// It returns ErrDuplicate in the unlikely event the generated prefix is already taken.
+func (r *apiKeyRepository) Create(ctx context.Context, key *entities.APIKey) error {
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+// GetByID retrieves an API key by primary key.
+// It returns ErrNotFound if no key matches.
+func (r *apiKeyRepository) GetByID(ctx context.Context, id uint) (*entities.APIKey, error) {
+	var key entities.APIKey
+	if err := r.db.WithContext(ctx).First(&key, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByPrefix retrieves an API key by its public prefix.
+// It returns ErrNotFound if no key matches.
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*entities.APIKey, error) {
+	var key entities.APIKey
+	if err := r.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListByUser returns every API key owned by the user, including revoked and expired ones, newest first.
+func (r *apiKeyRepository) ListByUser(ctx context.Context, userID uint) ([]entities.APIKey, error) {
+	var keys []entities.APIKey
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// ListByServiceAccount returns every API key owned by the service account, including revoked and expired ones, newest first.
+func (r *apiKeyRepository) ListByServiceAccount(ctx context.Context, serviceAccountID uint) ([]entities.APIKey, error) {
+	var keys []entities.APIKey
+	if err := r.db.WithContext(ctx).Where("service_account_id = ?", serviceAccountID).Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Revoke marks an API key as revoked. Revoking an already-revoked key is a no-op.
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&entities.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+// TouchLastUsed records when the key was last used to authenticate.
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&entities.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", at).Error
+}
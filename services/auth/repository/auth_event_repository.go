@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"gorm.io/gorm"
+)
+
+type AuthEventRepository interface {
+	Create(ctx context.Context, event *entities.AuthEvent) error
+	List(ctx context.Context, filter AuthEventFilter, beforeID uint, limit int) ([]entities.AuthEvent, error)
+}
+
+// AuthEventFilter narrows down ListAuthEvents. Zero fields match every event.
+type AuthEventFilter struct {
+	Type      string
+	Outcome   string
+	Actor     string
+	UserID    uint
+	Email     string
+	IPAddress string
+	Since     time.Time // inclusive
+	Until     time.Time // exclusive
+}
+
+// authEventRepository implements AuthEventRepository for the authentication audit trail.
+type authEventRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthEventRepository(db *gorm.DB) AuthEventRepository {
+	return &authEventRepository{db: db}
+}
+
+// Create appends an event to the audit trail.
+func (r *authEventRepository) Create(ctx context.Context, event *entities.AuthEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// List returns up to limit events matching the filter, newest first.
+// A non-zero beforeID continues a listing after the event with that ID.
+func (r *authEventRepository) List(ctx context.Context, filter AuthEventFilter, beforeID uint, limit int) ([]entities.AuthEvent, error) {
+	query := r.db.WithContext(ctx).Model(&entities.AuthEvent{})
+	if beforeID != 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Email != "" {
+		query = query.Where("email = ?", filter.Email)
+	}
+	if filter.IPAddress != "" {
+		query = query.Where("ip_address = ?", filter.IPAddress)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at < ?", filter.Until)
+	}
+
+	var events []entities.AuthEvent
+	err := query.Order("id DESC").Limit(limit).Find(&events).Error
+	return events, err
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"gorm.io/gorm"
+)
+
+type DeviceAuthorizationRepository interface {
+	Create(ctx context.Context, authorization *entities.DeviceAuthorization) error
+	GetByDeviceCodeHash(ctx context.Context, deviceCodeHash string) (*entities.DeviceAuthorization, error)
+	GetByUserCode(ctx context.Context, userCode string) (*entities.DeviceAuthorization, error)
+	Review(ctx context.Context, id uint, userID uint, status string) error
+	RecordPoll(ctx context.Context, id uint, lastPolledAt *time.Time, polledAt time.Time, pollInterval int) error
+	MarkUsed(ctx context.Context, id uint) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// deviceAuthorizationRepository implements DeviceAuthorizationRepository for RFC 8628 device sign-ins.
+type deviceAuthorizationRepository struct {
+	db *gorm.DB
+}
+
+func NewDeviceAuthorizationRepository(db *gorm.DB) DeviceAuthorizationRepository {
+	return &deviceAuthorizationRepository{db: db}
+}
+
+// Create stores a newly started device authorization.
+// It returns ErrDuplicate if its user code is already taken.
+func (r *deviceAuthorizationRepository) Create(ctx context.Context, authorization *entities.DeviceAuthorization) error {
+	if err := r.db.WithContext(ctx).Create(authorization).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+// GetByDeviceCodeHash retrieves an authorization by the hash of its raw device code.
+// It returns ErrNotFound if no authorization matches.
+func (r *deviceAuthorizationRepository) GetByDeviceCodeHash(ctx context.Context, deviceCodeHash string) (*entities.DeviceAuthorization, error) {
+	return r.getWhere(ctx, "device_code_hash = ?", deviceCodeHash)
+}
+
+// GetByUserCode retrieves an authorization by its normalized user code.
+// It returns ErrNotFound if no authorization matches.
+func (r *deviceAuthorizationRepository) GetByUserCode(ctx context.Context, userCode string) (*entities.DeviceAuthorization, error) {
+	return r.getWhere(ctx, "user_code = ?", userCode)
+}
+
+func (r *deviceAuthorizationRepository) getWhere(ctx context.Context, query string, arg string) (*entities.DeviceAuthorization, error) {
+	var authorization entities.DeviceAuthorization
+	if err := r.db.WithContext(ctx).Where(query, arg).First(&authorization).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &authorization, nil
+}
+
+// Review records the user's decision on a pending, unexpired authorization.
+// It returns ErrConflict if the authorization was already reviewed or has expired.
+func (r *deviceAuthorizationRepository) Review(ctx context.Context, id uint, userID uint, status string) error {
+	res := r.db.WithContext(ctx).Model(&entities.DeviceAuthorization{}).
+		Where("id = ? AND status = ? AND expires_at > ?", id, entities.DeviceAuthorizationPending, time.Now()).
+		Updates(map[string]interface{}{"status": status, "user_id": userID})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// RecordPoll records that the device polled at polledAt and sets its polling interval in seconds.
+// lastPolledAt is the previous poll time the caller saw; it returns ErrConflict if another poll
+// was recorded in the meantime, which means the device is polling concurrently.
+func (r *deviceAuthorizationRepository) RecordPoll(ctx context.Context, id uint, lastPolledAt *time.Time, polledAt time.Time, pollInterval int) error {
+	query := r.db.WithContext(ctx).Model(&entities.DeviceAuthorization{}).Where("id = ?", id)
+	if lastPolledAt == nil {
+		query = query.Where("last_polled_at IS NULL")
+	} else {
+		query = query.Where("last_polled_at = ?", *lastPolledAt)
+	}
+	res := query.Updates(map[string]interface{}{"last_polled_at": polledAt, "poll_interval": pollInterval})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// MarkUsed marks an approved authorization as redeemed. It returns ErrConflict if it
+// was already redeemed, which means the device code was replayed.
+func (r *deviceAuthorizationRepository) MarkUsed(ctx context.Context, id uint) error {
+	res := r.db.WithContext(ctx).Model(&entities.DeviceAuthorization{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// DeleteExpired permanently removes authorizations that expired before the given time
+// and returns how many were removed.
+func (r *deviceAuthorizationRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Unscoped().Where("expires_at < ?", before).Delete(&entities.DeviceAuthorization{})
+	return res.RowsAffected, res.Error
+}
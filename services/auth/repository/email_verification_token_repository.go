@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"gorm.io/gorm"
+)
+
+type EmailVerificationTokenRepository interface {
+	Create(ctx context.Context, token *entities.EmailVerificationToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*entities.EmailVerificationToken, error)
+	GetLatestByUser(ctx context.Context, userID uint) (*entities.EmailVerificationToken, error)
+	MarkUsed(ctx context.Context, id uint) error
+	DeleteUnusedByUser(ctx context.Context, userID uint) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// emailVerificationTokenRepository implements EmailVerificationTokenRepository for email verification links.
+type emailVerificationTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailVerificationTokenRepository(db *gorm.DB) EmailVerificationTokenRepository {
+	return &emailVerificationTokenRepository{db: db}
+}
+
+// Create stores a newly issued verification token.
+func (r *emailVerificationTokenRepository) Create(ctx context.Context, token *entities.EmailVerificationToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// GetByHash retrieves a verification token by the hash of its raw token.
+// It returns ErrNotFound if no token matches.
+func (r *emailVerificationTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*entities.EmailVerificationToken, error) {
+	var token entities.EmailVerificationToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetLatestByUser retrieves the verification token most recently issued to the user.
+// It returns ErrNotFound if the user has none.
+func (r *emailVerificationTokenRepository) GetLatestByUser(ctx context.Context, userID uint) (*entities.EmailVerificationToken, error) {
+	var token entities.EmailVerificationToken
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed marks a verification token as used. It returns ErrConflict if the token
+// was already used, which means another request redeemed it first.
+func (r *emailVerificationTokenRepository) MarkUsed(ctx context.Context, id uint) error {
+	res := r.db.WithContext(ctx).Model(&entities.EmailVerificationToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// DeleteUnusedByUser permanently removes the user's verification tokens that were never used,
+// so links emailed earlier stop working.
+func (r *emailVerificationTokenRepository) DeleteUnusedByUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Delete(&entities.EmailVerificationToken{}).Error
+}
+
+// DeleteExpired permanently removes verification tokens that expired before the given time
+// and returns how many were removed.
+func (r *emailVerificationTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Unscoped().Where("expires_at < ?", before).Delete(&entities.EmailVerificationToken{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,14 @@
+package repository
+
+import "errors"
+
+// Errors returned by repositories. Database-specific errors are translated into these
+// so callers never depend on GORM or Postgres directly.
+var (
+	// ErrNotFound is returned when the requested record does not exist.
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicate is returned when a write violates a unique constraint.
+	ErrDuplicate = errors.New("record already exists")
+	// ErrConflict is returned when a conditional update loses a race with a concurrent writer.
+	ErrConflict = errors.New("record was modified concurrently")
+)
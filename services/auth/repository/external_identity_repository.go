@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"gorm.io/gorm"
+)
+
+type ExternalIdentityRepository interface {
+	Create(ctx context.Context, identity *entities.ExternalIdentity) error
+	GetByProviderSubject(ctx context.Context, provider string, subject string) (*entities.ExternalIdentity, error)
+	UpdateEmail(ctx context.Context, id uint, email string) error
+}
+
+// externalIdentityRepository implements ExternalIdentityRepository for accounts at external identity providers.
+type externalIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewExternalIdentityRepository(db *gorm.DB) ExternalIdentityRepository {
+	return &externalIdentityRepository{db: db}
+}
+
+// Create links an external identity to a user.
+// It returns ErrDuplicate if the provider's subject is already linked.
+func (r *externalIdentityRepository) Create(ctx context.Context, identity *entities.ExternalIdentity) error {
+	if err := r.db.WithContext(ctx).Create(identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+// GetByProviderSubject retrieves the identity with the given subject at a provider.
+// It returns ErrNotFound if the subject is not linked to any user.
+func (r *externalIdentityRepository) GetByProviderSubject(ctx context.Context, provider string, subject string) (*entities.ExternalIdentity, error) {
+	var identity entities.ExternalIdentity
+	if err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// UpdateEmail records the email the provider reported at the latest sign-in.
+func (r *externalIdentityRepository) UpdateEmail(ctx context.Context, id uint, email string) error {
+	return r.db.WithContext(ctx).Model(&entities.ExternalIdentity{}).Where("id = ?", id).Update("email", email).Error
+}
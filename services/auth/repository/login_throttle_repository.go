@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginThrottleRepository interface {
+	GetByKeys(ctx context.Context, keys []string) ([]entities.LoginThrottle, error)
+	RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*entities.LoginThrottle, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+	DeleteStale(ctx context.Context, before time.Time) (int64, error)
+}
+
+// loginThrottleRepository implements LoginThrottleRepository for failed login counters.
+type loginThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginThrottleRepository(db *gorm.DB) LoginThrottleRepository {
+	return &loginThrottleRepository{db: db}
+}
+
+// GetByKeys returns the counters that exist for the given keys. Keys without failures are omitted.
+func (r *loginThrottleRepository) GetByKeys(ctx context.Context, keys []string) ([]entities.LoginThrottle, error) {
+	var throttles []entities.LoginThrottle
+	if err := r.db.WithContext(ctx).Where("key IN ?", keys).Find(&throttles).Error; err != nil {
+		return nil, err
+	}
+	return throttles, nil
+}
+
+// RecordFailure atomically increments the failure counter for key and returns the updated row.
+// A counter whose last failure, and any lockout, ended more than window ago starts over at one,
+// so backoff keeps growing for attempts that resume as soon as a lockout expires.
+// The increment happens in a single upsert so concurrent replicas never lose a failure.
+func (r *loginThrottleRepository) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*entities.LoginThrottle, error) {
+	throttle := entities.LoginThrottle{Key: key, Failures: 1, LastFailureAt: now}
+	err := r.db.WithContext(ctx).
+		Clauses(
+			clause.OnConflict{
+				Columns: []clause.Column{{Name: "key"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"failures": gorm.Expr(
+						"CASE WHEN GREATEST(login_throttles.last_failure_at, COALESCE(login_throttles.locked_until, login_throttles.last_failure_at)) < ? THEN 1 ELSE login_throttles.failures + 1 END",
+						now.Add(-window),
+					),
+					"last_failure_at": now,
+					"updated_at":      now,
+				}),
+			},
+			clause.Returning{},
+		).
+		Create(&throttle).Error
+	if err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// Lock locks key until the given time.
+func (r *loginThrottleRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	return r.db.WithContext(ctx).Model(&entities.LoginThrottle{}).
+		Where("key = ?", key).
+		Update("locked_until", until).Error
+}
+
+// Reset clears the failure counter and any lockout for key.
+func (r *loginThrottleRepository) Reset(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Unscoped().Where("key = ?", key).Delete(&entities.LoginThrottle{}).Error
+}
+
+// DeleteStale permanently removes counters with no failure and no active lockout since the given time
+// and returns how many were removed.
+func (r *loginThrottleRepository) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Unscoped().
+		Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", before, before).
+		Delete(&entities.LoginThrottle{})
+	return res.RowsAffected, res.Error
+}
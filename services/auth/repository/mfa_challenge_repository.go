@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"gorm.io/gorm"
+)
+
+type MFAChallengeRepository interface {
+	Create(ctx context.Context, challenge *entities.MFAChallenge) error
+	GetByHash(ctx context.Context, tokenHash string) (*entities.MFAChallenge, error)
+	MarkUsed(ctx context.Context, id uint) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// mfaChallengeRepository implements MFAChallengeRepository for pending second-factor logins.
+type mfaChallengeRepository struct {
+	db *gorm.DB
+}
+
+func NewMFAChallengeRepository(db *gorm.DB) MFAChallengeRepository {
+	return &mfaChallengeRepository{db: db}
+}
+
+// Create stores a new MFA challenge.
+func (r *mfaChallengeRepository) Create(ctx context.Context, challenge *entities.MFAChallenge) error {
+	return r.db.WithContext(ctx).Create(challenge).Error
+}
+
+// GetByHash retrieves a challenge by the hash of its raw token.
+// It returns ErrNotFound if no challenge matches.
+func (r *mfaChallengeRepository) GetByHash(ctx context.Context, tokenHash string) (*entities.MFAChallenge, error) {
+	var challenge entities.MFAChallenge
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&challenge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// MarkUsed marks a challenge as completed. It returns ErrConflict if the challenge
+// was already used, which means another request completed it first.
+func (r *mfaChallengeRepository) MarkUsed(ctx context.Context, id uint) error {
+	res := r.db.WithContext(ctx).Model(&entities.MFAChallenge{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// DeleteExpired permanently removes challenges that expired before the given time
+// and returns how many were removed.
+func (r *mfaChallengeRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Unscoped().Where("expires_at < ?", before).Delete(&entities.MFAChallenge{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"gorm.io/gorm"
+)
+
+type OIDCLoginRepository interface {
+	Create(ctx context.Context, login *entities.OIDCLogin) error
+	GetByHash(ctx context.Context, stateHash string) (*entities.OIDCLogin, error)
+	MarkUsed(ctx context.Context, id uint) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// oidcLoginRepository implements OIDCLoginRepository for sign-ins waiting on an external identity provider.
+type oidcLoginRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCLoginRepository(db *gorm.DB) OIDCLoginRepository {
+	return &oidcLoginRepository{db: db}
+}
+
+// Create stores a newly started OIDC login.
+func (r *oidcLoginRepository) Create(ctx context.Context, login *entities.OIDCLogin) error {
+	return r.db.WithContext(ctx).Create(login).Error
+}
+
+// GetByHash retrieves a login by the hash of its raw state.
+// It returns ErrNotFound if no login matches.
+func (r *oidcLoginRepository) GetByHash(ctx context.Context, stateHash string) (*entities.OIDCLogin, error) {
+	var login entities.OIDCLogin
+	if err := r.db.WithContext(ctx).Where("state_hash = ?", stateHash).First(&login).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &login, nil
+}
+
+// MarkUsed marks a login as completed. It returns ErrConflict if the login
+// was already used, which means its state was replayed.
+func (r *oidcLoginRepository) MarkUsed(ctx context.Context, id uint) error {
+	res := r.db.WithContext(ctx).Model(&entities.OIDCLogin{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// DeleteExpired permanently removes logins that expired before the given time
+// and returns how many were removed.
+func (r *oidcLoginRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Unscoped().Where("expires_at < ?", before).Delete(&entities.OIDCLogin{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"gorm.io/gorm"
+)
+
+type PasswordResetTokenRepository interface {
+	Create(ctx context.Context, token *entities.PasswordResetToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*entities.PasswordResetToken, error)
+	GetLatestByUser(ctx context.Context, userID uint) (*entities.PasswordResetToken, error)
+	MarkUsed(ctx context.Context, id uint) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// passwordResetTokenRepository implements PasswordResetTokenRepository for password reset links.
+type passwordResetTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetTokenRepository(db *gorm.DB) PasswordResetTokenRepository {
+	return &passwordResetTokenRepository{db: db}
+}
+
+// Create stores a newly issued reset token.
+func (r *passwordResetTokenRepository) Create(ctx context.Context, token *entities.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// GetByHash retrieves a reset token by the hash of its raw token.
+// It returns ErrNotFound if no token matches.
+func (r *passwordResetTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*entities.PasswordResetToken, error) {
+	var token entities.PasswordResetToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetLatestByUser retrieves the reset token most recently issued to the user.
+// It returns ErrNotFound if the user has none.
+func (r *passwordResetTokenRepository) GetLatestByUser(ctx context.Context, userID uint) (*entities.PasswordResetToken, error) {
+	var token entities.PasswordResetToken
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed marks a reset token as used. It returns ErrConflict if the token
+// was already used, which means another request redeemed it first.
+func (r *passwordResetTokenRepository) MarkUsed(ctx context.Context, id uint) error {
+	res := r.db.WithContext(ctx).Model(&entities.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// DeleteExpired permanently removes reset tokens that expired before the given time
+// and returns how many were removed.
+func (r *passwordResetTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Unscoped().Where("expires_at < ?", before).Delete(&entities.PasswordResetToken{})
+	return res.RowsAffected, res.Error
+}
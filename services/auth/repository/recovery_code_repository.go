@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository interface {
+	Consume(ctx context.Context, userID uint, codeHash string) error
+}
+
+// recoveryCodeRepository implements RecoveryCodeRepository for MFA recovery codes.
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+// Consume marks the user's unused recovery code with the given hash as used.
+// It returns ErrNotFound if the user has no such unused code.
+func (r *recoveryCodeRepository) Consume(ctx context.Context, userID uint, codeHash string) error {
+	res := r.db.WithContext(ctx).Model(&entities.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/entities"
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *entities.Session) error
+	GetByID(ctx context.Context, id uint) (*entities.Session, error)
+	GetByFamilyID(ctx context.Context, familyID string) (*entities.Session, error)
+	ListActiveByUser(ctx context.Context, userID uint, usedSince time.Time) ([]entities.Session, error)
+	Touch(ctx context.Context, familyID string, at time.Time, ipAddress string, userAgent string) error
+	SetOrganization(ctx context.Context, familyID string, orgID string) error
+	Revoke(ctx context.Context, familyID string, at time.Time) error
+	RevokeAllForUser(ctx context.Context, userID uint, at time.Time) error
+	DeleteStale(ctx context.Context, before time.Time) (int64, error)
+}
+
+// sessionRepository implements SessionRepository for signed-in devices.
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// Create stores a new session.
+func (r *sessionRepository) Create(ctx context.Context, session *entities.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+// GetByID retrieves a session by primary key.
+// It returns ErrNotFound if the session does not exist.
+func (r *sessionRepository) GetByID(ctx context.Context, id uint) (*entities.Session, error) {
+	var session entities.Session
+	if err := r.db.WithContext(ctx).First(&session, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetByFamilyID retrieves the session that started the given refresh token family.
+// It returns ErrNotFound if no session matches.
+func (r *sessionRepository) GetByFamilyID(ctx context.Context, familyID string) (*entities.Session, error) {
+	var session entities.Session
+	if err := r.db.WithContext(ctx).Where("family_id = ?", familyID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListActiveByUser returns the user's sessions that are not revoked and were used since the given time,
+// most recently used first.
+func (r *sessionRepository) ListActiveByUser(ctx context.Context, userID uint, usedSince time.Time) ([]entities.Session, error) {
+	var sessions []entities.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND last_used_at >= ?", userID, usedSince).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Touch records a use of the session and the device it came from. Empty values keep the stored ones.
+// It returns ErrNotFound if no session matches the family.
+func (r *sessionRepository) Touch(ctx context.Context, familyID string, at time.Time, ipAddress string, userAgent string) error {
+	updates := map[string]interface{}{"last_used_at": at}
+	if ipAddress != "" {
+		updates["ip_address"] = ipAddress
+	}
+	if userAgent != "" {
+		updates["user_agent"] = userAgent
+	}
+	res := r.db.WithContext(ctx).Model(&entities.Session{}).Where("family_id = ?", familyID).Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetOrganization stores the organization the session's access tokens act within; an empty orgID clears it.
+// It returns ErrNotFound if no unrevoked session matches.
+func (r *sessionRepository) SetOrganization(ctx context.Context, familyID string, orgID string) error {
+	res := r.db.WithContext(ctx).Model(&entities.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("org_id", orgID)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Revoke marks the session started by the given refresh token family as revoked, if it is not already.
+func (r *sessionRepository) Revoke(ctx context.Context, familyID string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&entities.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
+
+// RevokeAllForUser marks every still-active session of the user as revoked.
+func (r *sessionRepository) RevokeAllForUser(ctx context.Context, userID uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&entities.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+// DeleteStale permanently removes sessions revoked or last used before the given time
+// and returns how many were removed.
+func (r *sessionRepository) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Unscoped().
+		Where("last_used_at < ? OR revoked_at < ?", before, before).
+		Delete(&entities.Session{})
+	return res.RowsAffected, res.Error
+}
@@ -2,15 +2,40 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/himakhaitan/noreboothq/services/auth/entities"
 	"gorm.io/gorm"
 )
 
 type UserRepository interface {
+	Create(ctx context.Context, user *entities.User) error
+	CreateWithIdentity(ctx context.Context, user *entities.User, identity *entities.ExternalIdentity) error
 	GetByEmail(ctx context.Context, email string) (*entities.User, error)
+	GetByID(ctx context.Context, id uint) (*entities.User, error)
+	SetTOTPSecret(ctx context.Context, id uint, sealedSecret string) error
+	EnableMFA(ctx context.Context, id uint, sealedSecret string, step int64, recoveryCodes []entities.RecoveryCode) error
+	DisableMFA(ctx context.Context, id uint) error
+	AdvanceTOTPStep(ctx context.Context, id uint, step int64) error
+	UpdatePassword(ctx context.Context, id uint, passwordHash string, sessionsRevokedAt time.Time) error
+	RevokeSessions(ctx context.Context, id uint, sessionsRevokedAt time.Time) error
+	RehashPassword(ctx context.Context, id uint, oldHash string, newHash string) error
+	MarkEmailVerified(ctx context.Context, id uint) error
+	CountByPasswordScheme(ctx context.Context) ([]PasswordSchemeCount, error)
 }
 
+// PasswordSchemeCount is the number of users whose password hash has the given scheme,
+// the algorithm-and-parameters prefix of the hash. Scheme is empty for unrecognised hashes.
+type PasswordSchemeCount struct {
+	Scheme string
+	Users  int64
+}
+
+// passwordSchemePattern extracts the scheme of bcrypt ("$2a$10$") and argon2id
+// ("$argon2id$v=19$m=19456,t=2,p=1$") hashes; it matches password.Scheme.
+const passwordSchemePattern = `^(\$2[aby]?\$[0-9]+\$|\$argon2id\$v=[0-9]+\$[^$]+\$)`
+
 // userRepository implements UserRepository interface for user-related database operations.
 type userRepository struct {
 	db *gorm.DB
@@ -20,12 +45,170 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
+// Create inserts a new user into the database.
+// It returns ErrDuplicate if a user with the same email already exists.
+func (r *userRepository) Create(ctx context.Context, user *entities.User) error {
+	if err := r.db.WithContext(ctx).Create(user).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+// CreateWithIdentity inserts a user provisioned through an external identity provider together
+// with the identity linking them, so neither exists without the other.
+// It returns ErrDuplicate if the email or the identity is already taken.
+func (r *userRepository) CreateWithIdentity(ctx context.Context, user *entities.User, identity *entities.ExternalIdentity) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicate
+	}
+	return err
+}
+
 // GetByEmail retrieves a user by their email address from the database.
-// It returns the user if found, or an error if not found or if there is a database error.
+// It returns ErrNotFound if no user has that email, or an error if there is a database error.
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
 	var user entities.User
 	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
 		return nil, err
 	}
 	return &user, nil
 }
+
+// GetByID retrieves a user by primary key.
+// It returns ErrNotFound if the user does not exist, or an error if there is a database error.
+func (r *userRepository) GetByID(ctx context.Context, id uint) (*entities.User, error) {
+	var user entities.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetTOTPSecret stores a new, not yet confirmed, TOTP secret for the user.
+// MFA stays disabled until EnableMFA confirms the secret.
+func (r *userRepository) SetTOTPSecret(ctx context.Context, id uint, sealedSecret string) error {
+	return r.db.WithContext(ctx).Model(&entities.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"totp_secret":    sealedSecret,
+		"mfa_enabled":    false,
+		"totp_last_step": 0,
+	}).Error
+}
+
+// EnableMFA turns on MFA for the user and replaces their recovery codes in a single transaction.
+// step is the time step of the code that confirmed enrollment, so it cannot be reused.
+// It returns ErrConflict if the stored secret is no longer sealedSecret or MFA is already enabled.
+func (r *userRepository) EnableMFA(ctx context.Context, id uint, sealedSecret string, step int64, recoveryCodes []entities.RecoveryCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&entities.User{}).
+			Where("id = ? AND totp_secret = ? AND NOT mfa_enabled", id, sealedSecret).
+			Updates(map[string]interface{}{"mfa_enabled": true, "totp_last_step": step})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrConflict
+		}
+
+		if err := tx.Unscoped().Where("user_id = ?", id).Delete(&entities.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&recoveryCodes).Error
+	})
+}
+
+// DisableMFA clears the user's TOTP secret and deletes their recovery codes.
+func (r *userRepository) DisableMFA(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entities.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"totp_secret":    "",
+			"mfa_enabled":    false,
+			"totp_last_step": 0,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", id).Delete(&entities.RecoveryCode{}).Error
+	})
+}
+
+// AdvanceTOTPStep records step as the last accepted TOTP time step.
+// It returns ErrConflict if a code from this or a later step was already accepted,
+// which means the code is being replayed.
+func (r *userRepository) AdvanceTOTPStep(ctx context.Context, id uint, step int64) error {
+	res := r.db.WithContext(ctx).Model(&entities.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// UpdatePassword replaces the user's password hash and rejects every access token issued
+// before sessionsRevokedAt.
+func (r *userRepository) UpdatePassword(ctx context.Context, id uint, passwordHash string, sessionsRevokedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&entities.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password_hash":       passwordHash,
+		"sessions_revoked_at": sessionsRevokedAt,
+	}).Error
+}
+
+// RevokeSessions rejects every access token of the user issued before sessionsRevokedAt.
+func (r *userRepository) RevokeSessions(ctx context.Context, id uint, sessionsRevokedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&entities.User{}).Where("id = ?", id).
+		Update("sessions_revoked_at", sessionsRevokedAt).Error
+}
+
+// MarkEmailVerified records that the user owns their email address.
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&entities.User{}).Where("id = ?", id).
+		Update("email_verified", true).Error
+}
+
+// RehashPassword replaces the user's password hash with an equivalent one made with newer settings.
+// Unlike UpdatePassword it leaves sessions alone. It returns ErrConflict if the stored hash is no
+// longer oldHash, because the password was changed in the meantime.
+func (r *userRepository) RehashPassword(ctx context.Context, id uint, oldHash string, newHash string) error {
+	res := r.db.WithContext(ctx).Model(&entities.User{}).
+		Where("id = ? AND password_hash = ?", id, oldHash).
+		Update("password_hash", newHash)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// CountByPasswordScheme counts the users with a password by the scheme of their password hash.
+// Users provisioned through an identity provider, who have no password, are not counted.
+func (r *userRepository) CountByPasswordScheme(ctx context.Context) ([]PasswordSchemeCount, error) {
+	var counts []PasswordSchemeCount
+	err := r.db.WithContext(ctx).Model(&entities.User{}).
+		Select("COALESCE(substring(password_hash from ?), '') AS scheme, COUNT(*) AS users", passwordSchemePattern).
+		Where("password_hash <> ''").
+		Group("scheme").
+		Order("users DESC").
+		Scan(&counts).Error
+	return counts, err
+}
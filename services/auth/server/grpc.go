@@ -9,7 +9,6 @@ import (
 	authpb "github.com/himakhaitan/noreboothq/proto/auth"
 	"github.com/himakhaitan/noreboothq/services/auth/controllers"
 	"github.com/himakhaitan/noreboothq/services/auth/handlers"
-	"github.com/himakhaitan/noreboothq/services/auth/repository"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 )
@@ -18,15 +17,17 @@ type GRPCServer struct {
 	grpcServer *grpc.Server
 	logger     *zap.Logger
 	port       int
-	userRepo   *repository.UserRepository
+	authCtrl   *controllers.AuthController
 }
 
-func NewGRPCServer(logger *zap.Logger, userRepo *repository.UserRepository, port int) *GRPCServer {
+// NewGRPCServer creates the gRPC server for the auth service.
+// Server options such as the authn interceptors are passed through to grpc.NewServer.
+func NewGRPCServer(logger *zap.Logger, authCtrl *controllers.AuthController, port int, opts ...grpc.ServerOption) *GRPCServer {
 	return &GRPCServer{
-		grpcServer: grpc.NewServer(),
+		grpcServer: grpc.NewServer(opts...),
 		logger:     logger,
 		port:       port,
-		userRepo:   userRepo,
+		authCtrl:   authCtrl,
 	}
 }
 
@@ -39,8 +40,7 @@ func (s *GRPCServer) Start(ctx context.Context) error {
 		return err
 	}
 
-	authCtrl := controllers.NewAuthController(s.userRepo)
-	authHandler := handlers.NewAuthHandler(authCtrl, s.logger)
+	authHandler := handlers.NewAuthHandler(s.authCtrl, s.logger)
 
 	authpb.RegisterAuthServiceServer(s.grpcServer, authHandler)
 
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/auth/controllers"
+	"github.com/himakhaitan/noreboothq/services/auth/handlers"
+	"go.uber.org/zap"
+)
+
+type HTTPServer struct {
+	logger   *zap.Logger
+	port     int
+	authCtrl *controllers.AuthController
+}
+
+func NewHTTPServer(logger *zap.Logger, authCtrl *controllers.AuthController, port int) *HTTPServer {
+	return &HTTPServer{
+		logger:   logger,
+		port:     port,
+		authCtrl: authCtrl,
+	}
+}
+
+// Start serves the HTTP endpoints (e.g. the JWKS document) until the context is canceled.
+// On cancellation it drains in-flight requests within a timeout period.
+func (s *HTTPServer) Start(ctx context.Context) error {
+	httpHandler := handlers.NewHTTPHandler(s.authCtrl, s.logger)
+
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", s.port),
+		Handler:           httpHandler.Routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	serveErrCh := make(chan error, 1)
+
+	go func() {
+		s.logger.Info("HTTP server started", zap.Int("port", s.port))
+		serveErrCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+		s.logger.Info("Context canceled, shutting down HTTP server", zap.String("reason", ctx.Err().Error()))
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			s.logger.Warn("Timeout reached, forcing HTTP server stop", zap.Error(err))
+			return srv.Close()
+		}
+
+		s.logger.Info("HTTP server stopped gracefully")
+		return nil
+
+	case err := <-serveErrCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+}
@@ -0,0 +1,50 @@
+package tokens
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/himakhaitan/noreboothq/shared/opaque"
+)
+
+const (
+	// apiKeyMarker starts every API key so keys are recognisable in logs and secret scanners
+	// and can be told apart from JWTs.
+	apiKeyMarker = "nrh_"
+	// apiKeyPrefixLen is the length of the public part of a key: the marker plus 12 hex characters.
+	apiKeyPrefixLen = len(apiKeyMarker) + 12
+)
+
+// NewAPIKey generates an API key of the form "nrh_<12 hex>_<secret>".
+// The prefix (everything before the second underscore) is stored in the clear to look the key up
+// and to show it in listings; only the hash of the full key is stored.
+func NewAPIKey() (raw string, prefix string, hash string, err error) {
+	id := make([]byte, (apiKeyPrefixLen-len(apiKeyMarker))/2)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	secret, _, err := opaque.New()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	prefix = apiKeyMarker + hex.EncodeToString(id)
+	raw = prefix + "_" + secret
+	return raw, prefix, opaque.Hash(raw), nil
+}
+
+// IsAPIKey reports whether raw looks like an API key rather than a JWT.
+func IsAPIKey(raw string) bool {
+	return strings.HasPrefix(raw, apiKeyMarker)
+}
+
+// APIKeyPrefix returns the lookup prefix of an API key, or false if raw is not a well-formed key.
+func APIKeyPrefix(raw string) (string, bool) {
+	if !IsAPIKey(raw) || len(raw) <= apiKeyPrefixLen+1 || raw[apiKeyPrefixLen] != '_' {
+		return "", false
+	}
+	return raw[:apiKeyPrefixLen], true
+}
@@ -0,0 +1,197 @@
+package tokens
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/himakhaitan/noreboothq/services/auth/config"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+)
+
+// ErrInvalidToken is returned when a token cannot be parsed, has a bad signature or has expired.
+// It is the shared authn error so verification failures are recognised across services.
+var ErrInvalidToken = authn.ErrInvalidToken
+
+// ScopeAuthAdmin grants access to administrative auth operations such as revoking other users' tokens.
+const ScopeAuthAdmin = "auth.admin"
+
+// ScopeAuthImpersonate allows issuing impersonation tokens that act as another user.
+const ScopeAuthImpersonate = "auth.impersonate"
+
+// ScopeAuthIntrospect allows introspecting other callers' tokens, as services verifying requests do.
+const ScopeAuthIntrospect = "auth.introspect"
+
+// ScopeAuthUsers allows services to look users up and to create accounts for people whose email
+// they already proved, such as the identity service for invitees.
+const ScopeAuthUsers = "auth.users"
+
+// Claims are the JWT claims carried by access tokens issued by the auth service.
+// The type lives in shared/authn so other services decode tokens identically.
+type Claims = authn.Claims
+
+// Manager issues and verifies signed access tokens.
+type Manager struct {
+	keys       *KeySet
+	issuer     string
+	ttl        time.Duration
+	refreshTTL time.Duration
+}
+
+// NewManager creates a Manager from the JWT configuration that signs with the given key set.
+// It returns an error if token lifetimes are not configured.
+func NewManager(cfg config.JWTConfig, keys *KeySet) (*Manager, error) {
+	if cfg.AccessTokenTTL <= 0 {
+		return nil, fmt.Errorf("jwt access_token_ttl must be positive")
+	}
+	if cfg.RefreshTokenTTL <= 0 {
+		return nil, fmt.Errorf("jwt refresh_token_ttl must be positive")
+	}
+
+	return &Manager{
+		keys:       keys,
+		issuer:     cfg.Issuer,
+		ttl:        cfg.AccessTokenTTL,
+		refreshTTL: cfg.RefreshTokenTTL,
+	}, nil
+}
+
+// TTL returns the lifetime of access tokens issued by this manager.
+func (m *Manager) TTL() time.Duration {
+	return m.ttl
+}
+
+// RefreshTTL returns the lifetime of refresh tokens handed out alongside access tokens.
+func (m *Manager) RefreshTTL() time.Duration {
+	return m.refreshTTL
+}
+
+// Issue signs a new access token for the given subject with the given scopes.
+func (m *Manager) Issue(subject string, scopes []string) (string, error) {
+	return m.IssueWithTTL(subject, scopes, m.ttl)
+}
+
+// IssueWithTTL signs a new access token like Issue but with a custom lifetime,
+// e.g. for short-lived service account tokens.
+func (m *Manager) IssueWithTTL(subject string, scopes []string, ttl time.Duration) (string, error) {
+	return m.issue(newClaims(subject, scopes), ttl)
+}
+
+// IssueForSession signs a new access token like Issue that also names the login session
+// it belongs to in the sid claim, so revoking the session rejects the token, and the organization
+// the session acts within, if any, in the org_id claim.
+func (m *Manager) IssueForSession(subject string, sessionID string, orgID string, scopes []string) (string, error) {
+	claims := newClaims(subject, scopes)
+	claims.SessionID = sessionID
+	claims.OrgID = orgID
+	return m.issue(claims, m.ttl)
+}
+
+// IssueForService signs a short-lived token without scopes for subject's session, restricted to
+// audience. The auth service presents it to another service to ask something on the user's behalf,
+// such as whether they belong to an organization.
+func (m *Manager) IssueForService(subject string, sessionID string, audience string, ttl time.Duration) (string, error) {
+	claims := newClaims(subject, nil)
+	claims.SessionID = sessionID
+	claims.Audience = jwt.ClaimStrings{audience}
+	return m.issue(claims, ttl)
+}
+
+// IssueImpersonation signs an access token for subject that names actor, the administrator
+// impersonating them, in the act claim (RFC 8693). It belongs to no session.
+func (m *Manager) IssueImpersonation(subject string, actor string, scopes []string, ttl time.Duration) (string, error) {
+	claims := newClaims(subject, scopes)
+	claims.Actor = &authn.Actor{Subject: actor}
+	return m.issue(claims, ttl)
+}
+
+// IssueExchanged signs a token obtained by exchanging the token with the given claims (RFC 8693).
+// It keeps the original subject, organization, session and actor, so revoking the original session
+// rejects it too, but carries only the given scopes and is restricted to audience.
+func (m *Manager) IssueExchanged(original *Claims, audience string, scopes []string, ttl time.Duration) (string, error) {
+	claims := newClaims(original.Subject, scopes)
+	claims.OrgID = original.OrgID
+	claims.SessionID = original.SessionID
+	claims.Actor = original.Actor
+	claims.Audience = jwt.ClaimStrings{audience}
+	return m.issue(claims, ttl)
+}
+
+// newClaims returns the claims of an access token for subject with the given scopes.
+func newClaims(subject string, scopes []string) Claims {
+	return Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: subject},
+		Scope:            strings.Join(scopes, " "),
+	}
+}
+
+// issue signs an access token with the given claims after filling in its ID, issuer and validity window.
+func (m *Manager) issue(claims Claims, ttl time.Duration) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims.ID = jti
+	claims.Issuer = m.issuer
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.NotBefore = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+
+	key := m.keys.signer()
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+
+	signed, err := token.SignedString(key.private)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signed, nil
+}
+
+// Parse verifies the token signature and standard claims and returns its claims.
+// Any verification failure is reported as ErrInvalidToken.
+func (m *Manager) Parse(token string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, m.keyFunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(m.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return &claims, nil
+}
+
+// Keys returns the key set tokens are signed and verified with.
+func (m *Manager) Keys() *KeySet {
+	return m.keys
+}
+
+// keyFunc selects the verification key named by the token's kid header.
+// A key's algorithm is fixed, so a token claiming a different alg is rejected.
+func (m *Manager) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, ok := m.keys.lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if t.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("key %q does not use %s", kid, t.Method.Alg())
+	}
+	return key.public, nil
+}
+
+// newTokenID returns a random 128-bit identifier used as the token's jti claim.
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,242 @@
+package tokens
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// errNoSigningKey is returned by Reload when the key directory holds no private key.
+var errNoSigningKey = errors.New("no private signing key found")
+
+// verificationKey is a public key tokens may be verified with, optionally paired
+// with the private key used to sign new tokens.
+type verificationKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	public  crypto.PublicKey
+	private crypto.Signer // nil for verification-only keys
+}
+
+// KeySet holds the asymmetric keys used to sign and verify access tokens.
+//
+// Keys are read from PEM files in a directory; each file's name (without extension)
+// is the key ID. Private keys ("PRIVATE KEY" or "RSA PRIVATE KEY") can sign and verify, public keys
+// ("PUBLIC KEY") can only verify. The private key with the greatest key ID signs
+// new tokens, so naming keys by creation date makes the newest key active.
+// RSA keys sign with RS256 and Ed25519 keys with EdDSA.
+type KeySet struct {
+	dir string
+
+	mu     sync.RWMutex
+	keys   map[string]*verificationKey
+	active *verificationKey
+}
+
+// LoadKeySet reads the keys in dir. If the directory holds no private key and
+// generateIfMissing is set, a new Ed25519 key is generated and written to it,
+// which is convenient for local development.
+func LoadKeySet(dir string, generateIfMissing bool) (*KeySet, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("jwt keys_dir cannot be empty")
+	}
+
+	ks := &KeySet{dir: dir}
+	err := ks.Reload()
+	if errors.Is(err, errNoSigningKey) && generateIfMissing {
+		if err := generateKey(dir); err != nil {
+			return nil, err
+		}
+		err = ks.Reload()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Reload re-reads the key directory and atomically swaps in the new keys.
+// On failure the previously loaded keys stay in use.
+func (ks *KeySet) Reload() error {
+	paths, err := filepath.Glob(filepath.Join(ks.dir, "*.pem"))
+	if err != nil {
+		return fmt.Errorf("failed to list keys in %s: %w", ks.dir, err)
+	}
+
+	keys := make(map[string]*verificationKey, len(paths))
+	var active *verificationKey
+	for _, path := range paths {
+		key, err := readKey(path)
+		if err != nil {
+			return err
+		}
+		keys[key.kid] = key
+		if key.private != nil && (active == nil || key.kid > active.kid) {
+			active = key
+		}
+	}
+	if active == nil {
+		return fmt.Errorf("%w in %s", errNoSigningKey, ks.dir)
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.active = active
+	ks.mu.Unlock()
+	return nil
+}
+
+// ActiveKeyID returns the ID of the key currently used to sign tokens.
+func (ks *KeySet) ActiveKeyID() string {
+	return ks.signer().kid
+}
+
+// signer returns the key currently used to sign tokens.
+func (ks *KeySet) signer() *verificationKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.active
+}
+
+// lookup returns the verification key with the given ID.
+func (ks *KeySet) lookup(kid string) (*verificationKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// JWK is a single public key in JSON Web Key (RFC 7517) form.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519) keys
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set document.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns every verification key as a JSON Web Key Set, ordered by key ID.
+func (ks *KeySet) JWKS() JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	set := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, key := range ks.keys {
+		jwk := JWK{KeyID: key.kid, Use: "sig", Algorithm: key.method.Alg()}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KeyID < set.Keys[j].KeyID })
+	return set
+}
+
+// readKey parses a PEM-encoded private or public key file.
+func readKey(path string) (*verificationKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s is not PEM encoded", path)
+	}
+
+	key := &verificationKey{kid: strings.TrimSuffix(filepath.Base(path), ".pem")}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key %s: %w", path, err)
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("key %s cannot sign", path)
+		}
+		key.private = signer
+		key.public = signer.Public()
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key %s: %w", path, err)
+		}
+		key.private = parsed
+		key.public = parsed.Public()
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+		}
+		key.public = parsed
+	default:
+		return nil, fmt.Errorf("key %s has unsupported PEM type %q", path, block.Type)
+	}
+
+	switch key.public.(type) {
+	case *rsa.PublicKey:
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("key %s must be RSA or Ed25519", path)
+	}
+	return key, nil
+}
+
+// generateKey writes a new Ed25519 private key to dir, named after the current UTC time.
+func generateKey(dir string) error {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return fmt.Errorf("failed to encode signing key: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create keys directory %s: %w", dir, err)
+	}
+
+	kid := time.Now().UTC().Format("20060102T150405Z")
+	path := filepath.Join(dir, kid+".pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return fmt.Errorf("failed to write signing key %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package tokens
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// userCodeAlphabet holds the characters of user codes: upper-case consonants only, so codes are
+// easy to read out and type and cannot spell words (RFC 8628 section 6.1).
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// userCodeLength is the number of characters in a user code, giving 20^8 (about 2^34) codes.
+const userCodeLength = 8
+
+// NewUserCode generates a random device flow user code in its display form, e.g. "WDJB-MJHT".
+func NewUserCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(userCodeAlphabet)))
+	for i := 0; i < userCodeLength; i++ {
+		if i == userCodeLength/2 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate user code: %w", err)
+		}
+		b.WriteByte(userCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// NormalizeUserCode returns the stored form of a user code as typed by a user:
+// upper case, with separators and whitespace removed.
+func NormalizeUserCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '\t' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(code))
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	authpb "github.com/himakhaitan/noreboothq/proto/auth"
+	"github.com/himakhaitan/noreboothq/services/identity/config"
+	"github.com/himakhaitan/noreboothq/services/identity/controllers"
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"github.com/himakhaitan/noreboothq/services/identity/handlers"
+	"github.com/himakhaitan/noreboothq/services/identity/repository"
+	"github.com/himakhaitan/noreboothq/services/identity/server"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+	sharedConfig "github.com/himakhaitan/noreboothq/shared/config"
+	sharedDB "github.com/himakhaitan/noreboothq/shared/db"
+	"github.com/himakhaitan/noreboothq/shared/env"
+	sharedLogger "github.com/himakhaitan/noreboothq/shared/logger"
+	"github.com/himakhaitan/noreboothq/shared/mailer"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func main() {
+	// Default values to use if none provided
+	defaults := struct {
+		configPath string
+		env        string
+	}{
+		configPath: "services/identity/config",
+		env:        "development",
+	}
+	resolved := env.ResolveEnvConfig(defaults.configPath, defaults.env)
+	cfg, err := sharedConfig.LoadConfig[config.IdentityServiceConfig](resolved.ConfigPath, resolved.Env)
+	if err != nil {
+		panic("failed to load config: " + err.Error())
+	}
+
+	// Initialize the logger with the loaded configuration
+	err = sharedLogger.Init(
+		sharedLogger.Config{
+			ServiceName: "identity-service",
+			Environment: resolved.Env,
+		}, cfg.Log.Level,
+	)
+	if err != nil {
+		panic("failed to init logger: " + err.Error())
+	}
+	defer sharedLogger.Sync() // flushes logs on exit
+
+	// Initialize database connection with the identity models for migration
+	db, err := sharedDB.NewConnection(sharedDB.Config{
+		Host:     cfg.DB.Host,
+		Port:     cfg.DB.Port,
+		User:     cfg.DB.User,
+		Password: cfg.DB.Password,
+		DBName:   cfg.DB.DBName,
+		SSLMode:  cfg.DB.SSLMode,
+	}, sharedLogger.Logger(),
+		&entities.Organization{},
+		&entities.OrganizationMember{},
+		&entities.Project{},
+		&entities.ProjectMember{},
+		&entities.Role{},
+		&entities.RoleAssignment{},
+		&entities.Policy{},
+		&entities.Team{},
+		&entities.TeamMember{},
+		&entities.Invite{},
+	)
+	if err != nil {
+		sharedLogger.Logger().Fatal("Failed to connect to database", zap.Error(err))
+	}
+
+	// Connect to the auth service, which introspects every bearer token and creates invitees' accounts.
+	// Traffic between services stays on the internal network, like the auth service's own gRPC port.
+	authConn, err := grpc.NewClient(cfg.Auth.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		sharedLogger.Logger().Fatal("Failed to create auth service client", zap.Error(err))
+	}
+	defer authConn.Close()
+	authClient := authpb.NewAuthServiceClient(authConn)
+
+	// The auth service only introspects tokens for callers that authenticate as a service account.
+	if cfg.Auth.ClientID == "" || cfg.Auth.ClientSecret == "" {
+		sharedLogger.Logger().Fatal("Missing auth service credentials: auth.client_id and auth.client_secret are required")
+	}
+	serviceToken := authn.NewClientCredentialsSource(authClient, cfg.Auth.ClientID, cfg.Auth.ClientSecret)
+
+	// Initialize the mailer used for invite emails
+	mail, err := mailer.New(mailer.Config{
+		Driver:       cfg.Mail.Driver,
+		From:         cfg.Mail.From,
+		SMTPHost:     cfg.Mail.SMTP.Host,
+		SMTPPort:     cfg.Mail.SMTP.Port,
+		SMTPUsername: cfg.Mail.SMTP.Username,
+		SMTPPassword: cfg.Mail.SMTP.Password,
+		FilePath:     cfg.Mail.FilePath,
+	})
+	if err != nil {
+		sharedLogger.Logger().Fatal("Failed to initialize mailer", zap.Error(err))
+	}
+
+	identityCtrl := controllers.NewIdentityController(controllers.Repositories{
+		Organizations:        repository.NewOrganizationRepository(db),
+		OrganizationMembers:  repository.NewOrganizationMemberRepository(db),
+		Projects:             repository.NewProjectRepository(db),
+		ProjectMembers:       repository.NewProjectMemberRepository(db),
+		Roles:                repository.NewRoleRepository(db),
+		RoleAssignments:      repository.NewRoleAssignmentRepository(db),
+		Policies:             repository.NewPolicyRepository(db),
+		Teams:                repository.NewTeamRepository(db),
+		TeamMembers:          repository.NewTeamMemberRepository(db),
+		Invites:              repository.NewInviteRepository(db),
+		AuthorizationChanges: repository.NewAuthorizationChangeRepository(db),
+	}, controllers.Dependencies{
+		Authorization: cfg.Authorization,
+		Invites:       cfg.Invites,
+		Mailer:        mail,
+		Accounts:      authClient,
+		ServiceToken:  serviceToken,
+		Logger:        sharedLogger.Logger(),
+	})
+
+	sharedLogger.Logger().Info("Identity Service Started")
+
+	// Graceful shutdown context
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Apply the authorization changes other replicas announce, and stream them to watching services
+	go identityCtrl.ListenForAuthorizationChanges(ctx)
+
+	// Start the gRPC server. Every RPC is authenticated through introspection, so revoked tokens and
+	// signed-out sessions stop working at once, and tokens restricted to other services are rejected.
+	// AcceptInvite also takes callers without a token, whose invite token is their credential.
+	authInterceptor := authn.NewInterceptor(authn.NewIntrospectionVerifier(authClient, serviceToken)).
+		WithOptionalAuthentication(handlers.OptionalAuthMethods...).
+		WithAudience(cfg.Auth.Audience).
+		WithLogger(sharedLogger.Logger())
+	grpcServer := server.NewGRPCServer(sharedLogger.Logger(), identityCtrl, cfg.Server.Port,
+		grpc.ChainUnaryInterceptor(authInterceptor.Unary()),
+		grpc.ChainStreamInterceptor(authInterceptor.Stream()),
+	)
+	if err := grpcServer.Start(ctx); err != nil {
+		sharedLogger.Logger().Fatal("Failed to start gRPC server", zap.Error(err))
+	}
+}
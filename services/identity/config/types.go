@@ -0,0 +1,71 @@
+package config
+
+import "time"
+
+// This file defines the configuration structure for the identity service.
+// Add new configuration fields as needed, ensuring they are properly tagged for koanf.
+type IdentityServiceConfig struct {
+	Server        ServerConfig        `koanf:"server"`
+	Auth          AuthConfig          `koanf:"auth"`
+	Authorization AuthorizationConfig `koanf:"authorization"`
+	Mail          MailConfig          `koanf:"mail"`
+	Invites       InvitesConfig       `koanf:"invites"`
+	Log           LogConfig           `koanf:"logging"`
+	DB            DatabaseConfig      `koanf:"database"`
+}
+
+type DatabaseConfig struct {
+	Host     string `koanf:"host"`
+	Port     int    `koanf:"port"`
+	User     string `koanf:"user"`
+	Password string `koanf:"password"`
+	DBName   string `koanf:"db_name"`
+	SSLMode  string `koanf:"ssl_mode"`
+}
+
+type ServerConfig struct {
+	Port int `koanf:"port"`
+}
+
+type AuthConfig struct {
+	// Address of the auth service's gRPC server, which introspects every bearer token and creates the accounts of invitees.
+	Address string `koanf:"address"` // e.g. "localhost:8080"
+	// Name of this service in the aud claim; tokens restricted to other services are rejected.
+	Audience string `koanf:"audience"`
+	// Credentials of this service's service account, which authenticates its calls to the auth
+	// service. It needs the "auth.introspect" scope.
+	ClientID     string `koanf:"client_id"`
+	ClientSecret string `koanf:"client_secret"`
+}
+
+type AuthorizationConfig struct {
+	// How long each user's roles, groups and policies are cached in-process for Authorize. Changes clear the cache of every
+	// replica at once, through Postgres notifications; the TTL bounds staleness while notifications are lost. Zero disables the cache.
+	CacheTTL  time.Duration `koanf:"cache_ttl"`
+	CacheSize int           `koanf:"cache_size"`
+}
+
+type MailConfig struct {
+	Driver   string     `koanf:"driver"` // "smtp" or "file"
+	From     string     `koanf:"from"`
+	SMTP     SMTPConfig `koanf:"smtp"`
+	FilePath string     `koanf:"file_path"` // used by the "file" driver; empty writes to stdout
+}
+
+type SMTPConfig struct {
+	Host     string `koanf:"host"`
+	Port     int    `koanf:"port"`
+	Username string `koanf:"username"`
+	Password string `koanf:"password"`
+}
+
+type InvitesConfig struct {
+	// How long an invite link stays valid.
+	TTL time.Duration `koanf:"ttl"` // e.g. "168h"
+	// Page the invite email links to; the token is appended as the "token" query parameter.
+	AcceptURL string `koanf:"accept_url"`
+}
+
+type LogConfig struct {
+	Level string `koanf:"level"`
+}
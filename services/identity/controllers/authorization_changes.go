@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ScopeIdentityWatch lets a service watch the authorization changes of every organization.
+const ScopeIdentityWatch = "identity.watch"
+
+const (
+	// watcherBuffer is how many changes a watcher may fall behind before it is dropped.
+	watcherBuffer = 64
+	// listenRetryDelay is how long to wait before listening again after the connection was lost.
+	listenRetryDelay = 5 * time.Second
+)
+
+// AllOrganizations is the organization ID of a change announced when changes to any organization
+// may have been missed, such as after this replica lost its connection to the database.
+const AllOrganizations uint = 0
+
+// changeFeed fans authorization changes out to the callers of WatchAuthorizationChanges.
+type changeFeed struct {
+	mu       sync.Mutex
+	watchers map[chan uint]struct{}
+}
+
+// subscribe returns a channel that receives every change from now on. It is closed if the watcher
+// falls more than watcherBuffer changes behind.
+func (f *changeFeed) subscribe() chan uint {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan uint, watcherBuffer)
+	if f.watchers == nil {
+		f.watchers = make(map[chan uint]struct{})
+	}
+	f.watchers[ch] = struct{}{}
+	return ch
+}
+
+// unsubscribe stops sending changes to ch.
+func (f *changeFeed) unsubscribe(ch chan uint) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.watchers[ch]; ok {
+		delete(f.watchers, ch)
+		close(ch)
+	}
+}
+
+// publish sends a change to every watcher, dropping those that are too far behind to take it.
+func (f *changeFeed) publish(orgID uint) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.watchers {
+		select {
+		case ch <- orgID:
+		default:
+			delete(f.watchers, ch)
+			close(ch)
+		}
+	}
+}
+
+// authorizationChanged is called once roles, role assignments, policies, memberships or teams of an
+// organization changed. It drops this replica's cached snapshots at once, so the caller's next check
+// sees the change, and announces it to every replica, which drop theirs and tell their watchers.
+// The change is already stored, so failing to announce it is logged rather than returned; other
+// replicas then catch up when their snapshots expire.
+func (c *IdentityController) authorizationChanged(ctx context.Context, orgID uint) {
+	c.evaluator.Invalidate()
+	if err := c.changeRepo.Publish(context.WithoutCancel(ctx), orgID); err != nil {
+		c.logger.Warn("Failed to announce authorization change", zap.Uint("organization_id", orgID), zap.Error(err))
+	}
+}
+
+// ListenForAuthorizationChanges applies the authorization changes every replica announces until ctx
+// is done: each drops the cached snapshots and is passed on to the callers of
+// WatchAuthorizationChanges. A lost connection is retried after listenRetryDelay. Changes may have
+// been missed meanwhile, so whenever listening starts the cache is dropped and watchers are sent
+// AllOrganizations.
+func (c *IdentityController) ListenForAuthorizationChanges(ctx context.Context) {
+	apply := func(orgID uint) {
+		c.evaluator.Invalidate()
+		c.changes.publish(orgID)
+	}
+	for {
+		err := c.changeRepo.Listen(ctx, func() { apply(AllOrganizations) }, apply)
+		if ctx.Err() != nil {
+			return
+		}
+		c.logger.Warn("Stopped listening for authorization changes", zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+// WatchAuthorizationChanges calls send with the organization ID of every authorization change,
+// AllOrganizations when changes may have been missed, until ctx is done or send fails. The first
+// change sent is always AllOrganizations, once the watcher is subscribed, so it can drop what it
+// cached before without missing anything made after. A watcher that falls too far behind gets
+// ErrWatcherLagging, after which it must assume anything changed. Callers must hold
+// ScopeIdentityWatch, since changes of every organization are reported.
+func (c *IdentityController) WatchAuthorizationChanges(ctx context.Context, send func(orgID uint) error) error {
+	changes := c.changes.subscribe()
+	defer c.changes.unsubscribe(changes)
+	if err := send(AllOrganizations); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case orgID, ok := <-changes:
+			if !ok {
+				return ErrWatcherLagging
+			}
+			if err := send(orgID); err != nil {
+				return err
+			}
+		}
+	}
+}
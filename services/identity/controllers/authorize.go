@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"github.com/himakhaitan/noreboothq/services/identity/repository"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+	"github.com/himakhaitan/noreboothq/shared/authz"
+)
+
+// Authorize decides a request for req.Subject, the caller if empty, at the current time.
+// Owners of the resource's organization may ask about anyone, other members only about themselves.
+func (c *IdentityController) Authorize(ctx context.Context, principal *authn.Principal, req authz.Request) (authz.Decision, error) {
+	if !actionPattern.MatchString(req.Action) {
+		return authz.Decision{}, fmt.Errorf("%w: %q", ErrInvalidPermission, req.Action)
+	}
+	if err := validateResource(req.Resource); err != nil {
+		return authz.Decision{}, err
+	}
+	a, err := c.accessTo(ctx, principal, req.Resource.OrganizationID)
+	if err != nil {
+		return authz.Decision{}, err
+	}
+	if req.Subject == "" {
+		req.Subject = principal.Subject
+	}
+	if req.Subject != principal.Subject && !a.owner() {
+		return authz.Decision{}, ErrNotOwner
+	}
+
+	decision, err := c.evaluator.Authorize(ctx, req)
+	if err != nil {
+		return authz.Decision{}, fmt.Errorf("failed to authorize: %w", err)
+	}
+	return decision, nil
+}
+
+// ListGrants returns what the caller's requests within an organization are decided from, for
+// evaluating them locally. Callers who are not members get an empty snapshot.
+func (c *IdentityController) ListGrants(ctx context.Context, principal *authn.Principal, orgID uint) (*authz.Snapshot, error) {
+	snapshot, err := c.snapshot(ctx, principal.Subject, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list grants: %w", err)
+	}
+	return snapshot, nil
+}
+
+// snapshot loads everything subject's requests within an organization are decided from, including
+// the roles granted to their teams. Owners hold every permission on the whole organization, and users
+// who are not members get an empty snapshot, so neither roles nor policies allow them anything.
+func (c *IdentityController) snapshot(ctx context.Context, subject string, orgID uint) (*authz.Snapshot, error) {
+	member, err := c.orgMemberRepo.Get(ctx, orgID, subject)
+	if errors.Is(err, repository.ErrNotFound) {
+		return &authz.Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &authz.Snapshot{OrgRole: member.Role, Groups: strings.Fields(member.Groups)}
+	if member.Role == entities.OrganizationRoleOwner {
+		snapshot.Grants = append(snapshot.Grants, authz.Grant{
+			Role:        entities.OrganizationRoleOwner,
+			Scope:       authz.Resource{OrganizationID: orgID},
+			Permissions: []string{"*"},
+		})
+	}
+
+	assignments, err := c.assignmentRepo.List(ctx, orgID, subject)
+	if err != nil {
+		return nil, err
+	}
+	teams, err := c.teamRepo.ListByMember(ctx, orgID, subject)
+	if err != nil {
+		return nil, err
+	}
+	teamSlugs := make(map[uint]string, len(teams))
+	for _, team := range teams {
+		teamSlugs[team.ID] = team.Slug
+		snapshot.Teams = append(snapshot.Teams, team.Slug)
+	}
+	teamAssignments, err := c.assignmentRepo.ListByTeams(ctx, teamIDs(teams))
+	if err != nil {
+		return nil, err
+	}
+	assignments = append(assignments, teamAssignments...)
+
+	if len(assignments) > 0 {
+		custom, err := c.roleRepo.ListByOrganization(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		permissions := make(map[string][]string, len(builtInRoles)+len(custom))
+		for _, role := range builtInRoles {
+			permissions[role.Name] = role.Permissions
+		}
+		for _, role := range custom {
+			permissions[role.Name] = toRole(role).Permissions
+		}
+
+		for _, assignment := range assignments {
+			snapshot.Grants = append(snapshot.Grants, authz.Grant{
+				Role: assignment.RoleName,
+				Scope: authz.Resource{
+					OrganizationID: assignment.OrganizationID,
+					ProjectID:      assignment.ProjectID,
+					Environment:    assignment.Environment,
+				},
+				Permissions: permissions[assignment.RoleName],
+				Team:        teamSlugs[assignment.TeamID],
+			})
+		}
+	}
+
+	policies, err := c.policyRepo.ListByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for _, policy := range policies {
+		converted, err := toPolicy(policy)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Policies = append(snapshot.Policies, *converted)
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	authpb "github.com/himakhaitan/noreboothq/proto/auth"
+	"github.com/himakhaitan/noreboothq/services/identity/config"
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"github.com/himakhaitan/noreboothq/services/identity/repository"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+	"github.com/himakhaitan/noreboothq/shared/authz"
+	"github.com/himakhaitan/noreboothq/shared/mailer"
+	"go.uber.org/zap"
+)
+
+// ScopeIdentityAdmin lets the caller manage every organization and project as if they owned it.
+const ScopeIdentityAdmin = "identity.admin"
+
+// maxNameLength bounds organization and project names, in characters.
+const maxNameLength = 200
+
+// slugPattern matches slugs: lower-case letters and digits, with single dashes between them.
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Repositories groups the data access dependencies of the IdentityController.
+type Repositories struct {
+	Organizations       repository.OrganizationRepository
+	OrganizationMembers repository.OrganizationMemberRepository
+	Projects            repository.ProjectRepository
+	ProjectMembers      repository.ProjectMemberRepository
+	Roles               repository.RoleRepository
+	RoleAssignments     repository.RoleAssignmentRepository
+	Policies            repository.PolicyRepository
+	Teams               repository.TeamRepository
+	TeamMembers         repository.TeamMemberRepository
+	Invites             repository.InviteRepository
+	// AuthorizationChanges announces authorization changes to every replica.
+	AuthorizationChanges repository.AuthorizationChangeRepository
+}
+
+// Dependencies groups the configuration and collaborators of the IdentityController.
+type Dependencies struct {
+	Authorization config.AuthorizationConfig
+	Invites       config.InvitesConfig
+	// Mailer delivers invite emails.
+	Mailer mailer.Mailer
+	// Accounts is the auth service, which creates the accounts of invitees who have none.
+	Accounts authpb.AuthServiceClient
+	// ServiceToken authenticates the calls to Accounts as this service's own service account.
+	ServiceToken authn.TokenSource
+	// Logger reports failures that must not fail the request, such as announcing an authorization change.
+	Logger *zap.Logger
+}
+
+// IdentityController manages organizations, projects, teams, their memberships, invites and who may do what in them.
+// Every method acts on behalf of the given principal and enforces what they may see and change.
+type IdentityController struct {
+	orgRepo           repository.OrganizationRepository
+	orgMemberRepo     repository.OrganizationMemberRepository
+	projectRepo       repository.ProjectRepository
+	projectMemberRepo repository.ProjectMemberRepository
+	roleRepo          repository.RoleRepository
+	assignmentRepo    repository.RoleAssignmentRepository
+	policyRepo        repository.PolicyRepository
+	teamRepo          repository.TeamRepository
+	teamMemberRepo    repository.TeamMemberRepository
+	inviteRepo        repository.InviteRepository
+	changeRepo        repository.AuthorizationChangeRepository
+	invites           config.InvitesConfig
+	mailer            mailer.Mailer
+	accounts          authpb.AuthServiceClient
+	serviceToken      authn.TokenSource
+	logger            *zap.Logger
+	// evaluator answers Authorize from the roles, memberships, teams and policies stored here, caching them per user.
+	evaluator *authz.Evaluator
+	// changes passes the authorization changes of every replica on to WatchAuthorizationChanges.
+	changes changeFeed
+}
+
+// NewIdentityController creates a new instance of IdentityController with the provided repositories and dependencies.
+func NewIdentityController(repos Repositories, deps Dependencies) *IdentityController {
+	c := &IdentityController{
+		orgRepo:           repos.Organizations,
+		orgMemberRepo:     repos.OrganizationMembers,
+		projectRepo:       repos.Projects,
+		projectMemberRepo: repos.ProjectMembers,
+		roleRepo:          repos.Roles,
+		assignmentRepo:    repos.RoleAssignments,
+		policyRepo:        repos.Policies,
+		teamRepo:          repos.Teams,
+		teamMemberRepo:    repos.TeamMembers,
+		inviteRepo:        repos.Invites,
+		changeRepo:        repos.AuthorizationChanges,
+		invites:           deps.Invites,
+		mailer:            deps.Mailer,
+		accounts:          deps.Accounts,
+		serviceToken:      deps.ServiceToken,
+		logger:            deps.Logger,
+	}
+	c.evaluator = authz.NewEvaluator(authz.SourceFunc(c.snapshot), deps.Authorization.CacheTTL, deps.Authorization.CacheSize)
+	return c
+}
+
+// access is what a principal may do in one organization.
+type access struct {
+	// role is the principal's role in the organization; empty if they are not a member.
+	role  string
+	admin bool
+}
+
+// owner reports whether the principal may manage the organization.
+func (a access) owner() bool {
+	return a.admin || a.role == entities.OrganizationRoleOwner
+}
+
+// accessTo returns the principal's access to an organization. Unless the principal is an
+// identity admin, organizations they do not belong to are reported as ErrOrganizationNotFound.
+func (c *IdentityController) accessTo(ctx context.Context, principal *authn.Principal, orgID uint) (access, error) {
+	a := access{admin: principal.HasScope(ScopeIdentityAdmin)}
+
+	member, err := c.orgMemberRepo.Get(ctx, orgID, principal.Subject)
+	switch {
+	case err == nil:
+		a.role = member.Role
+	case !errors.Is(err, repository.ErrNotFound):
+		return access{}, fmt.Errorf("failed to look up membership: %w", err)
+	case !a.admin:
+		return access{}, ErrOrganizationNotFound
+	}
+	return a, nil
+}
+
+// ownerOf returns the principal's access to an organization they must own.
+func (c *IdentityController) ownerOf(ctx context.Context, principal *authn.Principal, orgID uint) (access, error) {
+	a, err := c.accessTo(ctx, principal, orgID)
+	if err != nil {
+		return access{}, err
+	}
+	if !a.owner() {
+		return access{}, ErrNotOwner
+	}
+	return a, nil
+}
+
+// validateName trims and checks an organization or project name.
+func validateName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+		return "", ErrInvalidName
+	}
+	return name, nil
+}
+
+// validateSlug checks an organization or project slug.
+func validateSlug(slug string) error {
+	if len(slug) < 3 || len(slug) > 63 || !slugPattern.MatchString(slug) {
+		return ErrInvalidSlug
+	}
+	return nil
+}
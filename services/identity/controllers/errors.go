@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/himakhaitan/noreboothq/shared/authz"
+)
+
+// Errors returned by the IdentityController. Handlers translate these into gRPC status codes.
+var (
+	// ErrOrganizationNotFound is returned for organizations that do not exist and for those the
+	// caller does not belong to, so callers cannot probe for other tenants.
+	ErrOrganizationNotFound = errors.New("organization not found")
+	// ErrProjectNotFound is returned for projects that do not exist and for those the caller cannot see.
+	ErrProjectNotFound = errors.New("project not found")
+	// ErrMemberNotFound is returned when removing a user who is not a member.
+	ErrMemberNotFound = errors.New("member not found")
+	// ErrNotOwner is returned when a member who is not an owner tries to manage an organization.
+	ErrNotOwner = errors.New("only organization owners can do this")
+	// ErrLastOwner is returned when removing or demoting an organization's only owner.
+	ErrLastOwner = errors.New("organization must keep at least one owner")
+	// ErrNotOrganizationMember is returned when adding a user to a project of an organization they do not belong to.
+	ErrNotOrganizationMember = errors.New("user is not a member of the project's organization")
+	// ErrSlugTaken is returned when an organization, or a project within its organization, already uses the slug.
+	ErrSlugTaken = errors.New("slug is already taken")
+	// ErrInvalidSlug is returned for slugs that are not 3 to 63 lower-case letters, digits and single dashes.
+	ErrInvalidSlug = errors.New("slug must be 3 to 63 lower-case letters, digits and dashes, starting and ending with a letter or digit")
+	// ErrInvalidName is returned for empty or overlong names.
+	ErrInvalidName = errors.New("name must be 1 to 200 characters")
+	// ErrInvalidRole is returned for organization roles other than owner and member.
+	ErrInvalidRole = errors.New(`role must be "owner" or "member"`)
+	// ErrAlreadyMember is returned when adding a user to a project they already belong to.
+	ErrAlreadyMember = errors.New("user is already a member")
+	// ErrRoleNotFound is returned for roles that are neither built in nor defined by the organization.
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrRoleExists is returned when creating a role whose name is taken, including by a built-in role.
+	ErrRoleExists = errors.New("role already exists")
+	// ErrBuiltInRole is returned when changing or deleting a built-in role.
+	ErrBuiltInRole = errors.New("built-in roles cannot be changed")
+	// ErrInvalidRoleName is returned for role names that are not 2 to 63 lower-case letters, digits and single dashes.
+	ErrInvalidRoleName = errors.New("role name must be 2 to 63 lower-case letters, digits and dashes")
+	// ErrInvalidPermission is returned for malformed permissions and actions, and for roles without permissions.
+	ErrInvalidPermission = errors.New(`permissions must be dotted lower-case names such as "config.write", optionally ending in ".*", or "*"`)
+	// ErrInvalidResource is returned for resources without an organization, environments outside a
+	// project, and malformed environment names.
+	ErrInvalidResource = errors.New("resource must name an organization, and an environment only within a project")
+	// ErrAssignmentNotFound is returned for role assignments that do not exist or the caller cannot see.
+	ErrAssignmentNotFound = errors.New("role assignment not found")
+	// ErrAssignmentExists is returned when the user or team already holds the role on the same scope.
+	ErrAssignmentExists = errors.New("role is already assigned here")
+	// ErrInvalidAssignee is returned when a role assignment names both or neither of a user and a team.
+	ErrInvalidAssignee = errors.New("assign a role to either a user or a team")
+	// ErrTeamNotFound is returned for teams that do not exist and for those of organizations the caller does not belong to.
+	ErrTeamNotFound = errors.New("team not found")
+	// ErrInviteNotFound is returned for invites that do not exist and for those the caller cannot see.
+	ErrInviteNotFound = errors.New("invite not found")
+	// ErrInviteNotPending is returned when revoking an invite that was already accepted or revoked.
+	ErrInviteNotPending = errors.New("invite was already accepted or revoked")
+	// ErrInvalidInvite is returned when accepting with a token that is unknown, expired, revoked or already used.
+	ErrInvalidInvite = errors.New("invite link is invalid or has expired")
+	// ErrInvalidEmail is returned when inviting an address that is not a bare email address.
+	ErrInvalidEmail = errors.New("invalid email address")
+	// ErrPasswordRequired is returned when accepting an invite without signing in or choosing a password.
+	ErrPasswordRequired = errors.New("sign in, or choose a password for the new account, to accept the invite")
+	// ErrAccountExists is returned when accepting an invite without signing in for an email that already has an account.
+	ErrAccountExists = errors.New("an account with this email exists; sign in and accept the invite again")
+	// ErrAccountRejected is returned when the auth service refuses to create the invitee's account,
+	// such as for a weak password, with its reason appended.
+	ErrAccountRejected = errors.New("account could not be created")
+	// ErrInvalidGroup is returned for group names that are not 2 to 63 lower-case letters, digits and single dashes.
+	ErrInvalidGroup = errors.New("group names must be 2 to 63 lower-case letters, digits and dashes")
+	// ErrPolicyNotFound is returned for policies the organization does not have.
+	ErrPolicyNotFound = errors.New("policy not found")
+	// ErrPolicyExists is returned when creating a policy whose name is taken.
+	ErrPolicyExists = errors.New("policy already exists")
+	// ErrWatcherLagging is returned to a watcher of authorization changes that fell too far behind to
+	// be sent every change; it must assume anything changed and watch again.
+	ErrWatcherLagging = errors.New("too far behind on authorization changes; watch again")
+	// ErrInvalidPolicy is returned for policies that cannot be evaluated, with the reason appended.
+	// It is the shared/authz error, so that package's validation errors match it too.
+	ErrInvalidPolicy = authz.ErrInvalidPolicy
+)
@@ -0,0 +1,289 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	authpb "github.com/himakhaitan/noreboothq/proto/auth"
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"github.com/himakhaitan/noreboothq/services/identity/repository"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+	"github.com/himakhaitan/noreboothq/shared/mailer"
+	"github.com/himakhaitan/noreboothq/shared/opaque"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Invite statuses, derived from when an invite was accepted, revoked and expires.
+const (
+	InviteStatusPending  = "pending"
+	InviteStatusAccepted = "accepted"
+	InviteStatusRevoked  = "revoked"
+	InviteStatusExpired  = "expired"
+)
+
+// AcceptedInvite is the outcome of accepting an invite.
+type AcceptedInvite struct {
+	OrganizationID uint
+	// UserID is the subject that joined the organization.
+	UserID string
+	// AccountCreated is set when an account was created for the invited email.
+	AccountCreated bool
+}
+
+// CreateInvite invites someone to an organization the caller owns, with an organization role and
+// optionally teams of the organization to join, and emails them a single-use link to accept it.
+func (c *IdentityController) CreateInvite(ctx context.Context, principal *authn.Principal, orgID uint, email string, role string, teamIDs []uint) (*entities.Invite, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return nil, ErrInvalidEmail
+	}
+	if role == "" {
+		role = entities.OrganizationRoleMember
+	}
+	if role != entities.OrganizationRoleOwner && role != entities.OrganizationRoleMember {
+		return nil, ErrInvalidRole
+	}
+	if _, err := c.ownerOf(ctx, principal, orgID); err != nil {
+		return nil, err
+	}
+	org, err := c.getOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	teamIDs = slices.Clone(teamIDs)
+	slices.Sort(teamIDs)
+	teamIDs = slices.Compact(teamIDs)
+	for _, teamID := range teamIDs {
+		team, err := c.teamRepo.GetByID(ctx, teamID)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && team.OrganizationID != orgID) {
+			return nil, ErrTeamNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up team: %w", err)
+		}
+	}
+
+	raw, hash, err := opaque.New()
+	if err != nil {
+		return nil, err
+	}
+	link, err := opaque.Link(c.invites.AcceptURL, raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid invites accept_url: %w", err)
+	}
+
+	invite := &entities.Invite{
+		OrganizationID: orgID,
+		Email:          email,
+		Role:           role,
+		TeamIDs:        formatIDs(teamIDs),
+		TokenHash:      hash,
+		InvitedBy:      principal.Subject,
+		ExpiresAt:      time.Now().Add(c.invites.TTL),
+	}
+	if err := c.inviteRepo.Create(ctx, invite); err != nil {
+		return nil, fmt.Errorf("failed to store invite: %w", err)
+	}
+
+	err = c.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: fmt.Sprintf("You're invited to %s on NoRebootHQ", org.Name),
+		Body: fmt.Sprintf("You have been invited to join the %s organization on NoRebootHQ.\n\n"+
+			"Use this link within %s to accept:\n\n%s\n\n"+
+			"If you weren't expecting this invitation, ignore this email.\n",
+			org.Name, c.invites.TTL, link),
+	})
+	if err != nil {
+		// Nobody can accept an invite whose link was never delivered; withdraw it so it is not listed as pending.
+		if revokeErr := c.inviteRepo.Revoke(ctx, invite.ID); revokeErr != nil {
+			err = errors.Join(err, revokeErr)
+		}
+		return nil, fmt.Errorf("failed to send invite: %w", err)
+	}
+	return invite, nil
+}
+
+// ListInvites returns the invites of an organization the caller owns, newest first.
+func (c *IdentityController) ListInvites(ctx context.Context, principal *authn.Principal, orgID uint) ([]entities.Invite, error) {
+	if _, err := c.ownerOf(ctx, principal, orgID); err != nil {
+		return nil, err
+	}
+	invites, err := c.inviteRepo.ListByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invites: %w", err)
+	}
+	return invites, nil
+}
+
+// RevokeInvite withdraws a pending invite of an organization the caller owns, so its link stops working.
+func (c *IdentityController) RevokeInvite(ctx context.Context, principal *authn.Principal, id uint) error {
+	invite, err := c.inviteRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInviteNotFound
+		}
+		return fmt.Errorf("failed to look up invite: %w", err)
+	}
+	if _, err := c.ownerOf(ctx, principal, invite.OrganizationID); err != nil {
+		if errors.Is(err, ErrOrganizationNotFound) {
+			return ErrInviteNotFound
+		}
+		return err
+	}
+
+	if err := c.inviteRepo.Revoke(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return ErrInviteNotPending
+		}
+		return fmt.Errorf("failed to revoke invite: %w", err)
+	}
+	return nil
+}
+
+// AcceptInvite redeems the token of an invite link. A signed-in caller, principal, joins as
+// themselves if checkInvitee finds they are the invitee. Without a principal an account is created
+// for the invited email through the auth service, unless one exists already, in which case
+// ErrAccountExists asks the invitee to sign in and accept again. An account created here has its
+// email verified, since the invite link proved the invitee owns it. The invitee joins the
+// organization with the invite's role and the invite's teams that still exist.
+func (c *IdentityController) AcceptInvite(ctx context.Context, principal *authn.Principal, rawToken string, password string) (*AcceptedInvite, error) {
+	invite, err := c.inviteRepo.GetByHash(ctx, opaque.Hash(rawToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidInvite
+		}
+		return nil, fmt.Errorf("failed to look up invite: %w", err)
+	}
+	if InviteStatus(invite, time.Now()) != InviteStatusPending {
+		return nil, ErrInvalidInvite
+	}
+
+	accepted := &AcceptedInvite{OrganizationID: invite.OrganizationID}
+	if principal != nil {
+		if err := c.checkInvitee(ctx, principal, invite); err != nil {
+			return nil, err
+		}
+		accepted.UserID = principal.Subject
+	} else {
+		if password == "" {
+			return nil, ErrPasswordRequired
+		}
+		userID, err := c.register(ctx, invite.Email, password)
+		if err != nil {
+			return nil, err
+		}
+		accepted.UserID, accepted.AccountCreated = userID, true
+	}
+
+	teamIDs, err := parseIDs(invite.TeamIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read teams of invite %d: %w", invite.ID, err)
+	}
+	if err := c.inviteRepo.Accept(ctx, invite, accepted.UserID, teamIDs); err != nil {
+		// The organization was deleted or the invite redeemed or revoked in the meantime. An account
+		// created above is kept; its owner can sign in and be invited again.
+		if errors.Is(err, repository.ErrConflict) || errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidInvite
+		}
+		return nil, fmt.Errorf("failed to accept invite: %w", err)
+	}
+	c.authorizationChanged(ctx, invite.OrganizationID)
+	return accepted, nil
+}
+
+// InviteStatus returns whether an invite is pending, accepted, revoked or expired at the given time.
+func InviteStatus(invite *entities.Invite, now time.Time) string {
+	switch {
+	case invite.AcceptedAt != nil:
+		return InviteStatusAccepted
+	case invite.RevokedAt != nil:
+		return InviteStatusRevoked
+	case !now.Before(invite.ExpiresAt):
+		return InviteStatusExpired
+	default:
+		return InviteStatusPending
+	}
+}
+
+// checkInvitee returns ErrInvalidInvite unless principal is the invitee in person: a user, not a
+// service account, acting for themselves with an unrestricted token, whose account email is
+// verified and the one the invite was sent to. Otherwise anyone signed in who got hold of a leaked
+// link could redeem it.
+func (c *IdentityController) checkInvitee(ctx context.Context, principal *authn.Principal, invite *entities.Invite) error {
+	if principal.Impersonated() || len(principal.Audience) > 0 {
+		return ErrInvalidInvite
+	}
+	// Users' subjects are their numeric IDs; service accounts' are "sa:<id>".
+	userID, err := strconv.ParseUint(principal.Subject, 10, 64)
+	if err != nil {
+		return ErrInvalidInvite
+	}
+
+	ctx, err = authn.WithServiceToken(ctx, c.serviceToken)
+	if err != nil {
+		return err
+	}
+	user, err := c.accounts.GetUser(ctx, &authpb.GetUserRequest{UserId: userID})
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.NotFound:
+		return ErrInvalidInvite
+	default:
+		return fmt.Errorf("failed to look up invitee: %w", err)
+	}
+	if !user.GetEmailVerified() || !strings.EqualFold(user.GetEmail(), invite.Email) {
+		return ErrInvalidInvite
+	}
+	return nil
+}
+
+// register creates a user with the auth service and returns their subject. The invite link proved
+// they own email, so the account is created with it verified.
+func (c *IdentityController) register(ctx context.Context, email string, password string) (string, error) {
+	ctx, err := authn.WithServiceToken(ctx, c.serviceToken)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.accounts.ProvisionUser(ctx, &authpb.ProvisionUserRequest{Email: email, Password: password})
+	switch status.Code(err) {
+	case codes.OK:
+		return strconv.FormatUint(resp.GetUserId(), 10), nil
+	case codes.AlreadyExists:
+		return "", ErrAccountExists
+	case codes.InvalidArgument:
+		return "", fmt.Errorf("%w: %s", ErrAccountRejected, status.Convert(err).Message())
+	default:
+		return "", fmt.Errorf("failed to create account: %w", err)
+	}
+}
+
+// formatIDs joins IDs with spaces for storage.
+func formatIDs(ids []uint) string {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatUint(uint64(id), 10))
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseIDs reads IDs stored by formatIDs.
+func parseIDs(s string) ([]uint, error) {
+	fields := strings.Fields(s)
+	ids := make([]uint, 0, len(fields))
+	for _, field := range fields {
+		id, err := strconv.ParseUint(field, 10, 0)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
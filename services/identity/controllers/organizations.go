@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"github.com/himakhaitan/noreboothq/services/identity/repository"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+)
+
+// Organization is an organization together with the caller's role in it.
+type Organization struct {
+	entities.Organization
+	// Role is the caller's role, or empty for identity admins who are not members.
+	Role string
+}
+
+// CreateOrganization creates an organization with the caller as its first owner.
+func (c *IdentityController) CreateOrganization(ctx context.Context, principal *authn.Principal, name string, slug string) (*Organization, error) {
+	name, err := validateName(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSlug(slug); err != nil {
+		return nil, err
+	}
+
+	org := &entities.Organization{Name: name, Slug: slug}
+	if err := c.orgRepo.Create(ctx, org, principal.Subject); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrSlugTaken
+		}
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+	return &Organization{Organization: *org, Role: entities.OrganizationRoleOwner}, nil
+}
+
+// GetOrganization returns an organization the caller belongs to.
+func (c *IdentityController) GetOrganization(ctx context.Context, principal *authn.Principal, id uint) (*Organization, error) {
+	a, err := c.accessTo(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+	org, err := c.getOrganization(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &Organization{Organization: *org, Role: a.role}, nil
+}
+
+// ListOrganizations returns the organizations the caller belongs to, ordered by name.
+func (c *IdentityController) ListOrganizations(ctx context.Context, principal *authn.Principal) ([]Organization, error) {
+	orgs, err := c.orgRepo.ListByMember(ctx, principal.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	memberships, err := c.orgMemberRepo.ListByUser(ctx, principal.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memberships: %w", err)
+	}
+	roles := make(map[uint]string, len(memberships))
+	for _, member := range memberships {
+		roles[member.OrganizationID] = member.Role
+	}
+
+	result := make([]Organization, 0, len(orgs))
+	for _, org := range orgs {
+		result = append(result, Organization{Organization: org, Role: roles[org.ID]})
+	}
+	return result, nil
+}
+
+// UpdateOrganization renames an organization the caller owns.
+func (c *IdentityController) UpdateOrganization(ctx context.Context, principal *authn.Principal, id uint, name string) (*Organization, error) {
+	name, err := validateName(name)
+	if err != nil {
+		return nil, err
+	}
+	a, err := c.ownerOf(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.orgRepo.UpdateName(ctx, id, name); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, fmt.Errorf("failed to update organization: %w", err)
+	}
+	org, err := c.getOrganization(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &Organization{Organization: *org, Role: a.role}, nil
+}
+
+// DeleteOrganization deletes an organization the caller owns, with its projects, memberships and roles.
+func (c *IdentityController) DeleteOrganization(ctx context.Context, principal *authn.Principal, id uint) error {
+	if _, err := c.ownerOf(ctx, principal, id); err != nil {
+		return err
+	}
+	if err := c.orgRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrOrganizationNotFound
+		}
+		return fmt.Errorf("failed to delete organization: %w", err)
+	}
+	c.authorizationChanged(ctx, id)
+	return nil
+}
+
+// AddOrganizationMember adds a user to an organization the caller owns, or changes the role of an
+// existing member. User IDs are auth service subjects and are not checked against the auth service.
+func (c *IdentityController) AddOrganizationMember(ctx context.Context, principal *authn.Principal, orgID uint, userID string, role string) (*entities.OrganizationMember, error) {
+	if role == "" {
+		role = entities.OrganizationRoleMember
+	}
+	if role != entities.OrganizationRoleOwner && role != entities.OrganizationRoleMember {
+		return nil, ErrInvalidRole
+	}
+	if _, err := c.ownerOf(ctx, principal, orgID); err != nil {
+		return nil, err
+	}
+
+	member := &entities.OrganizationMember{OrganizationID: orgID, UserID: userID, Role: role}
+	if err := c.orgMemberRepo.Save(ctx, member); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrOrganizationNotFound
+		case errors.Is(err, repository.ErrLastOwner):
+			return nil, ErrLastOwner
+		}
+		return nil, fmt.Errorf("failed to save organization member: %w", err)
+	}
+	// Owners hold every permission, so a role change can change authorization decisions.
+	c.authorizationChanged(ctx, orgID)
+	return member, nil
+}
+
+// RemoveOrganizationMember removes a user from an organization and its projects, withdrawing their roles. Owners can remove
+// anyone and members themselves, but the organization's last owner always stays.
+func (c *IdentityController) RemoveOrganizationMember(ctx context.Context, principal *authn.Principal, orgID uint, userID string) error {
+	a, err := c.accessTo(ctx, principal, orgID)
+	if err != nil {
+		return err
+	}
+	if !a.owner() && userID != principal.Subject {
+		return ErrNotOwner
+	}
+
+	if err := c.orgMemberRepo.Remove(ctx, orgID, userID); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrMemberNotFound
+		case errors.Is(err, repository.ErrLastOwner):
+			return ErrLastOwner
+		}
+		return fmt.Errorf("failed to remove organization member: %w", err)
+	}
+	c.authorizationChanged(ctx, orgID)
+	return nil
+}
+
+// ListOrganizationMembers returns the members of an organization the caller belongs to.
+func (c *IdentityController) ListOrganizationMembers(ctx context.Context, principal *authn.Principal, orgID uint) ([]entities.OrganizationMember, error) {
+	if _, err := c.accessTo(ctx, principal, orgID); err != nil {
+		return nil, err
+	}
+	if _, err := c.getOrganization(ctx, orgID); err != nil {
+		return nil, err
+	}
+	members, err := c.orgMemberRepo.ListByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organization members: %w", err)
+	}
+	return members, nil
+}
+
+// SetOrganizationMemberGroups replaces the groups of a member of an organization the caller owns.
+// Groups are labels such as "on-call" that authorization policies can refer to.
+func (c *IdentityController) SetOrganizationMemberGroups(ctx context.Context, principal *authn.Principal, orgID uint, userID string, groups []string) (*entities.OrganizationMember, error) {
+	for _, group := range groups {
+		if !validName(group) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidGroup, group)
+		}
+	}
+	groups = slices.Compact(slices.Sorted(slices.Values(groups)))
+	if _, err := c.ownerOf(ctx, principal, orgID); err != nil {
+		return nil, err
+	}
+
+	if err := c.orgMemberRepo.SetGroups(ctx, orgID, userID, strings.Join(groups, " ")); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("failed to set member groups: %w", err)
+	}
+	c.authorizationChanged(ctx, orgID)
+
+	member, err := c.orgMemberRepo.Get(ctx, orgID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up membership: %w", err)
+	}
+	return member, nil
+}
+
+// getOrganization looks an organization up, reporting a missing one as ErrOrganizationNotFound.
+func (c *IdentityController) getOrganization(ctx context.Context, id uint) (*entities.Organization, error) {
+	org, err := c.orgRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, fmt.Errorf("failed to look up organization: %w", err)
+	}
+	return org, nil
+}
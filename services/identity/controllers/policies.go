@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"github.com/himakhaitan/noreboothq/services/identity/repository"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+	"github.com/himakhaitan/noreboothq/shared/authz"
+)
+
+// ListPolicies returns the policies of an organization the caller belongs to, ordered by name.
+func (c *IdentityController) ListPolicies(ctx context.Context, principal *authn.Principal, orgID uint) ([]authz.Policy, error) {
+	if _, err := c.accessTo(ctx, principal, orgID); err != nil {
+		return nil, err
+	}
+	stored, err := c.policyRepo.ListByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policies: %w", err)
+	}
+
+	policies := make([]authz.Policy, 0, len(stored))
+	for _, policy := range stored {
+		converted, err := toPolicy(policy)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *converted)
+	}
+	return policies, nil
+}
+
+// CreatePolicy adds a policy to an organization the caller owns. The policy's scope is always
+// within that organization.
+func (c *IdentityController) CreatePolicy(ctx context.Context, principal *authn.Principal, orgID uint, policy authz.Policy) (*authz.Policy, error) {
+	stored, err := c.preparePolicy(ctx, principal, orgID, policy)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.policyRepo.Create(ctx, stored); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrPolicyExists
+		}
+		return nil, fmt.Errorf("failed to create policy: %w", err)
+	}
+	c.authorizationChanged(ctx, orgID)
+	return toPolicy(*stored)
+}
+
+// UpdatePolicy replaces the policy with the same name in an organization the caller owns.
+func (c *IdentityController) UpdatePolicy(ctx context.Context, principal *authn.Principal, orgID uint, policy authz.Policy) (*authz.Policy, error) {
+	stored, err := c.preparePolicy(ctx, principal, orgID, policy)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.policyRepo.Update(ctx, stored); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrPolicyNotFound
+		}
+		return nil, fmt.Errorf("failed to update policy: %w", err)
+	}
+	c.authorizationChanged(ctx, orgID)
+	return toPolicy(*stored)
+}
+
+// DeletePolicy deletes a policy of an organization the caller owns.
+func (c *IdentityController) DeletePolicy(ctx context.Context, principal *authn.Principal, orgID uint, name string) error {
+	if _, err := c.ownerOf(ctx, principal, orgID); err != nil {
+		return err
+	}
+	if err := c.policyRepo.Delete(ctx, orgID, name); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrPolicyNotFound
+		}
+		return fmt.Errorf("failed to delete policy: %w", err)
+	}
+	c.authorizationChanged(ctx, orgID)
+	return nil
+}
+
+// preparePolicy checks a policy the caller wants to store in an organization they own and converts it.
+func (c *IdentityController) preparePolicy(ctx context.Context, principal *authn.Principal, orgID uint, policy authz.Policy) (*entities.Policy, error) {
+	if !validName(policy.Name) {
+		return nil, fmt.Errorf("%w: name must be 2 to 63 lower-case letters, digits and dashes", ErrInvalidPolicy)
+	}
+	actions, err := normalizePermissions(policy.Actions)
+	if err != nil {
+		return nil, err
+	}
+	policy.Actions = actions
+	policy.Scope.OrganizationID = orgID
+	if err := validateResource(policy.Scope); err != nil {
+		return nil, err
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := c.ownerOf(ctx, principal, orgID); err != nil {
+		return nil, err
+	}
+	if err := c.checkScopeProject(ctx, policy.Scope); err != nil {
+		return nil, err
+	}
+
+	conditions, err := json.Marshal(policy.Conditions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode policy conditions: %w", err)
+	}
+	return &entities.Policy{
+		OrganizationID: orgID,
+		Name:           policy.Name,
+		Description:    strings.TrimSpace(policy.Description),
+		Effect:         policy.Effect,
+		Actions:        strings.Join(policy.Actions, " "),
+		ProjectID:      policy.Scope.ProjectID,
+		Environment:    policy.Scope.Environment,
+		Conditions:     string(conditions),
+		Timezone:       policy.Timezone,
+	}, nil
+}
+
+// toPolicy converts a stored policy.
+func toPolicy(policy entities.Policy) (*authz.Policy, error) {
+	var conditions []authz.Condition
+	if err := json.Unmarshal([]byte(policy.Conditions), &conditions); err != nil {
+		return nil, fmt.Errorf("failed to decode conditions of policy %s: %w", policy.Name, err)
+	}
+	return &authz.Policy{
+		Name:        policy.Name,
+		Description: policy.Description,
+		Effect:      policy.Effect,
+		Actions:     strings.Fields(policy.Actions),
+		Scope: authz.Resource{
+			OrganizationID: policy.OrganizationID,
+			ProjectID:      policy.ProjectID,
+			Environment:    policy.Environment,
+		},
+		Conditions: conditions,
+		Timezone:   policy.Timezone,
+	}, nil
+}
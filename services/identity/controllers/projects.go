@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"github.com/himakhaitan/noreboothq/services/identity/repository"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+)
+
+// CreateProject creates a project in an organization the caller owns.
+func (c *IdentityController) CreateProject(ctx context.Context, principal *authn.Principal, orgID uint, name string, slug string) (*entities.Project, error) {
+	name, err := validateName(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSlug(slug); err != nil {
+		return nil, err
+	}
+	if _, err := c.ownerOf(ctx, principal, orgID); err != nil {
+		return nil, err
+	}
+	if _, err := c.getOrganization(ctx, orgID); err != nil {
+		return nil, err
+	}
+
+	project := &entities.Project{OrganizationID: orgID, Name: name, Slug: slug}
+	if err := c.projectRepo.Create(ctx, project); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrSlugTaken
+		}
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+	return project, nil
+}
+
+// GetProject returns a project the caller can see: any project of an organization they own,
+// otherwise only projects they belong to.
+func (c *IdentityController) GetProject(ctx context.Context, principal *authn.Principal, id uint) (*entities.Project, error) {
+	project, _, err := c.visibleProject(ctx, principal, id)
+	return project, err
+}
+
+// ListProjects returns an organization's projects the caller can see, ordered by name.
+func (c *IdentityController) ListProjects(ctx context.Context, principal *authn.Principal, orgID uint) ([]entities.Project, error) {
+	a, err := c.accessTo(ctx, principal, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	var projects []entities.Project
+	if a.owner() {
+		projects, err = c.projectRepo.ListByOrganization(ctx, orgID)
+	} else {
+		projects, err = c.projectRepo.ListByMember(ctx, orgID, principal.Subject)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	return projects, nil
+}
+
+// UpdateProject renames a project of an organization the caller owns.
+func (c *IdentityController) UpdateProject(ctx context.Context, principal *authn.Principal, id uint, name string) (*entities.Project, error) {
+	name, err := validateName(name)
+	if err != nil {
+		return nil, err
+	}
+	project, err := c.ownedProject(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.projectRepo.UpdateName(ctx, id, name); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to update project: %w", err)
+	}
+	project.Name = name
+	return project, nil
+}
+
+// DeleteProject deletes a project of an organization the caller owns, with its memberships and role assignments.
+func (c *IdentityController) DeleteProject(ctx context.Context, principal *authn.Principal, id uint) error {
+	project, err := c.ownedProject(ctx, principal, id)
+	if err != nil {
+		return err
+	}
+	if err := c.projectRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrProjectNotFound
+		}
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+	c.authorizationChanged(ctx, project.OrganizationID)
+	return nil
+}
+
+// AddProjectMember adds a member of the project's organization to a project of an organization the caller owns.
+func (c *IdentityController) AddProjectMember(ctx context.Context, principal *authn.Principal, projectID uint, userID string) (*entities.ProjectMember, error) {
+	project, err := c.ownedProject(ctx, principal, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.orgMemberRepo.Get(ctx, project.OrganizationID, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotOrganizationMember
+		}
+		return nil, fmt.Errorf("failed to look up membership: %w", err)
+	}
+
+	member := &entities.ProjectMember{ProjectID: projectID, UserID: userID}
+	if err := c.projectMemberRepo.Create(ctx, member); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrAlreadyMember
+		}
+		return nil, fmt.Errorf("failed to add project member: %w", err)
+	}
+	return member, nil
+}
+
+// RemoveProjectMember removes a user from a project. Owners of the project's organization can
+// remove anyone and project members themselves.
+func (c *IdentityController) RemoveProjectMember(ctx context.Context, principal *authn.Principal, projectID uint, userID string) error {
+	_, a, err := c.visibleProject(ctx, principal, projectID)
+	if err != nil {
+		return err
+	}
+	if !a.owner() && userID != principal.Subject {
+		return ErrNotOwner
+	}
+
+	if err := c.projectMemberRepo.Remove(ctx, projectID, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrMemberNotFound
+		}
+		return fmt.Errorf("failed to remove project member: %w", err)
+	}
+	return nil
+}
+
+// ListProjectMembers returns the members of a project the caller can see.
+func (c *IdentityController) ListProjectMembers(ctx context.Context, principal *authn.Principal, projectID uint) ([]entities.ProjectMember, error) {
+	if _, _, err := c.visibleProject(ctx, principal, projectID); err != nil {
+		return nil, err
+	}
+	members, err := c.projectMemberRepo.ListByProject(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project members: %w", err)
+	}
+	return members, nil
+}
+
+// visibleProject returns a project the caller can see along with their access to its organization.
+// Projects they cannot see are reported as ErrProjectNotFound.
+func (c *IdentityController) visibleProject(ctx context.Context, principal *authn.Principal, id uint) (*entities.Project, access, error) {
+	project, err := c.projectRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, access{}, ErrProjectNotFound
+		}
+		return nil, access{}, fmt.Errorf("failed to look up project: %w", err)
+	}
+
+	a, err := c.accessTo(ctx, principal, project.OrganizationID)
+	if errors.Is(err, ErrOrganizationNotFound) {
+		return nil, access{}, ErrProjectNotFound
+	}
+	if err != nil {
+		return nil, access{}, err
+	}
+	if a.owner() {
+		return project, a, nil
+	}
+
+	if _, err := c.projectMemberRepo.Get(ctx, id, principal.Subject); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, access{}, ErrProjectNotFound
+		}
+		return nil, access{}, fmt.Errorf("failed to look up project membership: %w", err)
+	}
+	return project, a, nil
+}
+
+// ownedProject returns a project of an organization the caller owns.
+func (c *IdentityController) ownedProject(ctx context.Context, principal *authn.Principal, id uint) (*entities.Project, error) {
+	project, a, err := c.visibleProject(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+	if !a.owner() {
+		return nil, ErrNotOwner
+	}
+	return project, nil
+}
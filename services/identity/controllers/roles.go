@@ -0,0 +1,357 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"github.com/himakhaitan/noreboothq/services/identity/repository"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+	"github.com/himakhaitan/noreboothq/shared/authz"
+)
+
+// Role is a named set of permissions that can be assigned to organization members.
+type Role struct {
+	Name        string
+	Description string
+	Permissions []string
+	// BuiltIn is set for the roles every organization has, which cannot be changed.
+	BuiltIn bool
+}
+
+// builtInRoles exist in every organization.
+var builtInRoles = []Role{
+	{Name: "viewer", Description: "Reads configuration", Permissions: []string{"config.read"}, BuiltIn: true},
+	{Name: "editor", Description: "Reads and edits configuration", Permissions: []string{"config.read", "config.write"}, BuiltIn: true},
+	{Name: "operator", Description: "Reads and activates configuration", Permissions: []string{"config.activate", "config.read"}, BuiltIn: true},
+	{Name: "admin", Description: "Does anything", Permissions: []string{"*"}, BuiltIn: true},
+}
+
+// reservedRoleNames cannot be used for custom roles because they name organization roles.
+var reservedRoleNames = []string{entities.OrganizationRoleOwner, entities.OrganizationRoleMember}
+
+var (
+	// namePattern matches role, group and policy names: lower-case letters and digits, with single dashes between them.
+	namePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	// actionPattern matches actions: dotted lower-case names such as "config.write".
+	actionPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*(\.[a-z][a-z0-9_]*)*$`)
+	// permissionPattern matches permissions: an action, an action prefix ending in ".*", or "*".
+	permissionPattern = regexp.MustCompile(`^(\*|[a-z][a-z0-9_]*(\.[a-z][a-z0-9_]*)*(\.\*)?)$`)
+	// environmentPattern matches environment names such as "staging" or "eu-prod".
+	environmentPattern = regexp.MustCompile(`^[a-z0-9]+([-_][a-z0-9]+)*$`)
+)
+
+// ListRoles returns the roles of an organization the caller belongs to: the built-in roles
+// followed by the organization's own, ordered by name.
+func (c *IdentityController) ListRoles(ctx context.Context, principal *authn.Principal, orgID uint) ([]Role, error) {
+	if _, err := c.accessTo(ctx, principal, orgID); err != nil {
+		return nil, err
+	}
+	custom, err := c.roleRepo.ListByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	roles := slices.Clone(builtInRoles)
+	for _, role := range custom {
+		roles = append(roles, toRole(role))
+	}
+	return roles, nil
+}
+
+// CreateRole defines a custom role in an organization the caller owns.
+func (c *IdentityController) CreateRole(ctx context.Context, principal *authn.Principal, orgID uint, name string, description string, permissions []string) (*Role, error) {
+	if err := validateRoleName(name); err != nil {
+		return nil, err
+	}
+	if builtInRole(name) != nil || slices.Contains(reservedRoleNames, name) {
+		return nil, ErrRoleExists
+	}
+	permissions, err := normalizePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.ownerOf(ctx, principal, orgID); err != nil {
+		return nil, err
+	}
+
+	role := &entities.Role{
+		OrganizationID: orgID,
+		Name:           name,
+		Description:    strings.TrimSpace(description),
+		Permissions:    strings.Join(permissions, " "),
+	}
+	if err := c.roleRepo.Create(ctx, role); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrRoleExists
+		}
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+	result := toRole(*role)
+	return &result, nil
+}
+
+// UpdateRole replaces the description and permissions of a custom role in an organization the caller owns.
+func (c *IdentityController) UpdateRole(ctx context.Context, principal *authn.Principal, orgID uint, name string, description string, permissions []string) (*Role, error) {
+	if builtInRole(name) != nil {
+		return nil, ErrBuiltInRole
+	}
+	permissions, err := normalizePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.ownerOf(ctx, principal, orgID); err != nil {
+		return nil, err
+	}
+
+	role := &entities.Role{
+		OrganizationID: orgID,
+		Name:           name,
+		Description:    strings.TrimSpace(description),
+		Permissions:    strings.Join(permissions, " "),
+	}
+	if err := c.roleRepo.Update(ctx, role); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+	c.authorizationChanged(ctx, orgID)
+
+	result := toRole(*role)
+	return &result, nil
+}
+
+// DeleteRole deletes a custom role of an organization the caller owns, withdrawing it from everyone who holds it.
+func (c *IdentityController) DeleteRole(ctx context.Context, principal *authn.Principal, orgID uint, name string) error {
+	if builtInRole(name) != nil {
+		return ErrBuiltInRole
+	}
+	if _, err := c.ownerOf(ctx, principal, orgID); err != nil {
+		return err
+	}
+	if err := c.roleRepo.Delete(ctx, orgID, name); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrRoleNotFound
+		}
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	c.authorizationChanged(ctx, orgID)
+	return nil
+}
+
+// AssignRole grants a role to a member or, when teamID is set instead of userID, a team of an
+// organization the caller owns, on the organization itself, one of its projects or one environment of a project.
+func (c *IdentityController) AssignRole(ctx context.Context, principal *authn.Principal, scope authz.Resource, userID string, teamID uint, roleName string) (*entities.RoleAssignment, error) {
+	if (userID == "") == (teamID == 0) {
+		return nil, ErrInvalidAssignee
+	}
+	if err := validateResource(scope); err != nil {
+		return nil, err
+	}
+	if _, err := c.ownerOf(ctx, principal, scope.OrganizationID); err != nil {
+		return nil, err
+	}
+	if err := c.checkScopeProject(ctx, scope); err != nil {
+		return nil, err
+	}
+	if err := c.checkAssignee(ctx, scope.OrganizationID, userID, teamID); err != nil {
+		return nil, err
+	}
+	if _, err := c.role(ctx, scope.OrganizationID, roleName); err != nil {
+		return nil, err
+	}
+
+	assignment := &entities.RoleAssignment{
+		OrganizationID: scope.OrganizationID,
+		ProjectID:      scope.ProjectID,
+		Environment:    scope.Environment,
+		UserID:         userID,
+		TeamID:         teamID,
+		RoleName:       roleName,
+	}
+	if err := c.assignmentRepo.Create(ctx, assignment); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrAssignmentExists
+		}
+		return nil, fmt.Errorf("failed to assign role: %w", err)
+	}
+	c.authorizationChanged(ctx, scope.OrganizationID)
+	return assignment, nil
+}
+
+// UnassignRole withdraws a role assignment in an organization the caller owns.
+func (c *IdentityController) UnassignRole(ctx context.Context, principal *authn.Principal, id uint) error {
+	assignment, err := c.assignmentRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrAssignmentNotFound
+		}
+		return fmt.Errorf("failed to look up role assignment: %w", err)
+	}
+	if _, err := c.ownerOf(ctx, principal, assignment.OrganizationID); err != nil {
+		if errors.Is(err, ErrOrganizationNotFound) {
+			return ErrAssignmentNotFound
+		}
+		return err
+	}
+
+	if err := c.assignmentRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrAssignmentNotFound
+		}
+		return fmt.Errorf("failed to unassign role: %w", err)
+	}
+	c.authorizationChanged(ctx, assignment.OrganizationID)
+	return nil
+}
+
+// ListRoleAssignments returns the role assignments of an organization, only those of userID and
+// their teams if it is not empty. Members who are not owners only see their own.
+func (c *IdentityController) ListRoleAssignments(ctx context.Context, principal *authn.Principal, orgID uint, userID string) ([]entities.RoleAssignment, error) {
+	a, err := c.accessTo(ctx, principal, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !a.owner() {
+		if userID != "" && userID != principal.Subject {
+			return nil, ErrNotOwner
+		}
+		userID = principal.Subject
+	}
+
+	assignments, err := c.assignmentRepo.List(ctx, orgID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list role assignments: %w", err)
+	}
+	if userID == "" {
+		return assignments, nil
+	}
+
+	teams, err := c.teamRepo.ListByMember(ctx, orgID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+	teamAssignments, err := c.assignmentRepo.ListByTeams(ctx, teamIDs(teams))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list role assignments: %w", err)
+	}
+	return append(assignments, teamAssignments...), nil
+}
+
+// checkAssignee checks that a role is assigned to a member or a team of the organization.
+func (c *IdentityController) checkAssignee(ctx context.Context, orgID uint, userID string, teamID uint) error {
+	if teamID != 0 {
+		team, err := c.teamRepo.GetByID(ctx, teamID)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && team.OrganizationID != orgID) {
+			return ErrTeamNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to look up team: %w", err)
+		}
+		return nil
+	}
+	if _, err := c.orgMemberRepo.Get(ctx, orgID, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotOrganizationMember
+		}
+		return fmt.Errorf("failed to look up membership: %w", err)
+	}
+	return nil
+}
+
+// role returns a built-in role or a custom role of the organization.
+func (c *IdentityController) role(ctx context.Context, orgID uint, name string) (*Role, error) {
+	if role := builtInRole(name); role != nil {
+		return role, nil
+	}
+	custom, err := c.roleRepo.Get(ctx, orgID, name)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to look up role: %w", err)
+	}
+	role := toRole(*custom)
+	return &role, nil
+}
+
+// checkScopeProject returns ErrProjectNotFound unless the scope's project, if any, belongs to its organization.
+func (c *IdentityController) checkScopeProject(ctx context.Context, scope authz.Resource) error {
+	if scope.ProjectID == 0 {
+		return nil
+	}
+	project, err := c.projectRepo.GetByID(ctx, scope.ProjectID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && project.OrganizationID != scope.OrganizationID) {
+		return ErrProjectNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up project: %w", err)
+	}
+	return nil
+}
+
+// builtInRole returns the built-in role with the given name, or nil if there is none.
+func builtInRole(name string) *Role {
+	for i := range builtInRoles {
+		if builtInRoles[i].Name == name {
+			role := builtInRoles[i]
+			return &role
+		}
+	}
+	return nil
+}
+
+// toRole converts a stored custom role.
+func toRole(role entities.Role) Role {
+	return Role{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: strings.Fields(role.Permissions),
+	}
+}
+
+// validateRoleName checks the name of a custom role.
+func validateRoleName(name string) error {
+	if !validName(name) {
+		return ErrInvalidRoleName
+	}
+	return nil
+}
+
+// validName reports whether name is a valid role, group or policy name.
+func validName(name string) bool {
+	return len(name) >= 2 && len(name) <= 63 && namePattern.MatchString(name)
+}
+
+// normalizePermissions trims, checks, sorts and de-duplicates a role's permissions, of which there must be at least one.
+func normalizePermissions(permissions []string) ([]string, error) {
+	normalized := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		permission = strings.TrimSpace(permission)
+		if !permissionPattern.MatchString(permission) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPermission, permission)
+		}
+		normalized = append(normalized, permission)
+	}
+	if len(normalized) == 0 {
+		return nil, ErrInvalidPermission
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
+
+// validateResource checks that a resource names an organization, and an environment only within a project.
+func validateResource(resource authz.Resource) error {
+	if !resource.Valid() {
+		return ErrInvalidResource
+	}
+	if resource.Environment != "" && (len(resource.Environment) > 63 || !environmentPattern.MatchString(resource.Environment)) {
+		return ErrInvalidResource
+	}
+	return nil
+}
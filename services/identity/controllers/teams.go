@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"github.com/himakhaitan/noreboothq/services/identity/repository"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+)
+
+// CreateTeam creates a team in an organization the caller owns.
+func (c *IdentityController) CreateTeam(ctx context.Context, principal *authn.Principal, orgID uint, name string, slug string) (*entities.Team, error) {
+	name, err := validateName(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSlug(slug); err != nil {
+		return nil, err
+	}
+	if _, err := c.ownerOf(ctx, principal, orgID); err != nil {
+		return nil, err
+	}
+
+	team := &entities.Team{OrganizationID: orgID, Name: name, Slug: slug}
+	if err := c.teamRepo.Create(ctx, team); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrSlugTaken
+		}
+		return nil, fmt.Errorf("failed to create team: %w", err)
+	}
+	return team, nil
+}
+
+// ListTeams returns the teams of an organization the caller belongs to, ordered by slug.
+func (c *IdentityController) ListTeams(ctx context.Context, principal *authn.Principal, orgID uint) ([]entities.Team, error) {
+	if _, err := c.accessTo(ctx, principal, orgID); err != nil {
+		return nil, err
+	}
+	teams, err := c.teamRepo.ListByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+	return teams, nil
+}
+
+// DeleteTeam deletes a team of an organization the caller owns, withdrawing the roles granted to it.
+func (c *IdentityController) DeleteTeam(ctx context.Context, principal *authn.Principal, id uint) error {
+	team, err := c.ownedTeam(ctx, principal, id)
+	if err != nil {
+		return err
+	}
+	if err := c.teamRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrTeamNotFound
+		}
+		return fmt.Errorf("failed to delete team: %w", err)
+	}
+	c.authorizationChanged(ctx, team.OrganizationID)
+	return nil
+}
+
+// AddTeamMember adds a member of the team's organization to the team. The caller must own the organization.
+func (c *IdentityController) AddTeamMember(ctx context.Context, principal *authn.Principal, teamID uint, userID string) (*entities.TeamMember, error) {
+	team, err := c.ownedTeam(ctx, principal, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.orgMemberRepo.Get(ctx, team.OrganizationID, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotOrganizationMember
+		}
+		return nil, fmt.Errorf("failed to look up membership: %w", err)
+	}
+
+	member := &entities.TeamMember{TeamID: teamID, UserID: userID}
+	if err := c.teamMemberRepo.Create(ctx, member); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrAlreadyMember
+		}
+		return nil, fmt.Errorf("failed to add team member: %w", err)
+	}
+	c.authorizationChanged(ctx, team.OrganizationID)
+	return member, nil
+}
+
+// RemoveTeamMember removes a user from a team. Owners of the team's organization can remove
+// anyone and team members themselves.
+func (c *IdentityController) RemoveTeamMember(ctx context.Context, principal *authn.Principal, teamID uint, userID string) error {
+	team, a, err := c.visibleTeam(ctx, principal, teamID)
+	if err != nil {
+		return err
+	}
+	if !a.owner() && userID != principal.Subject {
+		return ErrNotOwner
+	}
+
+	if err := c.teamMemberRepo.Remove(ctx, teamID, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrMemberNotFound
+		}
+		return fmt.Errorf("failed to remove team member: %w", err)
+	}
+	c.authorizationChanged(ctx, team.OrganizationID)
+	return nil
+}
+
+// ListTeamMembers returns the members of a team of an organization the caller belongs to.
+func (c *IdentityController) ListTeamMembers(ctx context.Context, principal *authn.Principal, teamID uint) ([]entities.TeamMember, error) {
+	if _, _, err := c.visibleTeam(ctx, principal, teamID); err != nil {
+		return nil, err
+	}
+	members, err := c.teamMemberRepo.ListByTeam(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list team members: %w", err)
+	}
+	return members, nil
+}
+
+// teamIDs returns the IDs of teams.
+func teamIDs(teams []entities.Team) []uint {
+	ids := make([]uint, 0, len(teams))
+	for _, team := range teams {
+		ids = append(ids, team.ID)
+	}
+	return ids
+}
+
+// visibleTeam returns a team of an organization the caller belongs to along with their access to it.
+// Teams of other organizations are reported as ErrTeamNotFound.
+func (c *IdentityController) visibleTeam(ctx context.Context, principal *authn.Principal, id uint) (*entities.Team, access, error) {
+	team, err := c.teamRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, access{}, ErrTeamNotFound
+		}
+		return nil, access{}, fmt.Errorf("failed to look up team: %w", err)
+	}
+
+	a, err := c.accessTo(ctx, principal, team.OrganizationID)
+	if errors.Is(err, ErrOrganizationNotFound) {
+		return nil, access{}, ErrTeamNotFound
+	}
+	if err != nil {
+		return nil, access{}, err
+	}
+	return team, a, nil
+}
+
+// ownedTeam returns a team of an organization the caller owns.
+func (c *IdentityController) ownedTeam(ctx context.Context, principal *authn.Principal, id uint) (*entities.Team, error) {
+	team, a, err := c.visibleTeam(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+	if !a.owner() {
+		return nil, ErrNotOwner
+	}
+	return team, nil
+}
@@ -0,0 +1,24 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Invite asks someone to join an organization. It is emailed as a link carrying a single-use token,
+// of which only the SHA-256 hash is stored. Role is the organization role the invitee gets and
+// TeamIDs the space-separated IDs of the teams they join. InvitedBy and AcceptedBy are auth service subjects.
+type Invite struct {
+	gorm.Model
+	OrganizationID uint      `gorm:"index;not null"`
+	Email          string    `gorm:"not null"`
+	Role           string    `gorm:"not null"`
+	TeamIDs        string    `gorm:"not null;default:''"`
+	TokenHash      string    `gorm:"uniqueIndex;not null"`
+	InvitedBy      string    `gorm:"not null"`
+	ExpiresAt      time.Time `gorm:"index;not null"`
+	AcceptedAt     *time.Time
+	AcceptedBy     string `gorm:"not null;default:''"`
+	RevokedAt      *time.Time
+}
@@ -0,0 +1,12 @@
+package entities
+
+import "gorm.io/gorm"
+
+// Organization is a tenant: every project, configuration and role belongs to exactly one.
+// Its ID, in decimal, is the org_id claim of access tokens acting within it.
+type Organization struct {
+	gorm.Model
+	Name string `gorm:"not null"`
+	// Slug is the organization's unique, URL-safe handle. It never changes.
+	Slug string `gorm:"uniqueIndex;not null"`
+}
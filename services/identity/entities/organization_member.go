@@ -0,0 +1,22 @@
+package entities
+
+import "gorm.io/gorm"
+
+// Roles a user can hold in an organization.
+const (
+	// OrganizationRoleOwner manages the organization, its members and its projects.
+	OrganizationRoleOwner = "owner"
+	// OrganizationRoleMember sees the organization and the projects they were added to.
+	OrganizationRoleMember = "member"
+)
+
+// OrganizationMember makes a user part of an organization.
+// UserID is the user's auth service subject, the sub claim of their access tokens.
+// Groups are space-separated labels, such as "on-call", that authorization policies can refer to.
+type OrganizationMember struct {
+	gorm.Model
+	OrganizationID uint   `gorm:"uniqueIndex:idx_organization_members_org_user;not null"`
+	UserID         string `gorm:"uniqueIndex:idx_organization_members_org_user;index;not null"`
+	Role           string `gorm:"not null"`
+	Groups         string `gorm:"not null;default:''"`
+}
@@ -0,0 +1,19 @@
+package entities
+
+import "gorm.io/gorm"
+
+// Policy conditionally allows or denies actions within an organization: on the organization itself
+// when ProjectID is 0, a project, or one environment of a project. Actions are space-separated
+// permissions and Conditions the JSON-encoded authz.Condition list that must all hold.
+type Policy struct {
+	gorm.Model
+	OrganizationID uint   `gorm:"uniqueIndex:idx_policies_org_name;not null"`
+	Name           string `gorm:"uniqueIndex:idx_policies_org_name;not null"`
+	Description    string
+	Effect         string `gorm:"not null"`
+	Actions        string `gorm:"not null"`
+	ProjectID      uint   `gorm:"index;not null;default:0"`
+	Environment    string `gorm:"not null;default:''"`
+	Conditions     string `gorm:"not null;default:'[]'"`
+	Timezone       string `gorm:"not null;default:''"`
+}
@@ -0,0 +1,12 @@
+package entities
+
+import "gorm.io/gorm"
+
+// Project groups the resources of one application inside an organization.
+type Project struct {
+	gorm.Model
+	OrganizationID uint   `gorm:"uniqueIndex:idx_projects_org_slug;not null"`
+	Name           string `gorm:"not null"`
+	// Slug is the project's URL-safe handle, unique within its organization. It never changes.
+	Slug string `gorm:"uniqueIndex:idx_projects_org_slug;not null"`
+}
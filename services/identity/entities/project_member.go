@@ -0,0 +1,11 @@
+package entities
+
+import "gorm.io/gorm"
+
+// ProjectMember gives a member of the project's organization access to the project.
+// UserID is the user's auth service subject.
+type ProjectMember struct {
+	gorm.Model
+	ProjectID uint   `gorm:"uniqueIndex:idx_project_members_project_user;not null"`
+	UserID    string `gorm:"uniqueIndex:idx_project_members_project_user;index;not null"`
+}
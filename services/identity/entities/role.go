@@ -0,0 +1,13 @@
+package entities
+
+import "gorm.io/gorm"
+
+// Role is a named set of permissions an organization defines in addition to the built-in roles.
+// Permissions are space-separated, e.g. "config.read config.write".
+type Role struct {
+	gorm.Model
+	OrganizationID uint   `gorm:"uniqueIndex:idx_roles_org_name;not null"`
+	Name           string `gorm:"uniqueIndex:idx_roles_org_name;not null"`
+	Description    string
+	Permissions    string `gorm:"not null"`
+}
@@ -0,0 +1,17 @@
+package entities
+
+import "gorm.io/gorm"
+
+// RoleAssignment grants a role to a member of an organization, or to a team when TeamID is set and
+// UserID empty, on a scope: the organization itself when ProjectID is 0, a project, or one environment
+// of a project. The role applies to everything nested in its scope. RoleName refers to a built-in role
+// or to a Role of the organization.
+type RoleAssignment struct {
+	gorm.Model
+	OrganizationID uint   `gorm:"uniqueIndex:idx_role_assignments_grant;index:idx_role_assignments_org_user;not null"`
+	ProjectID      uint   `gorm:"uniqueIndex:idx_role_assignments_grant;index;not null;default:0"`
+	Environment    string `gorm:"uniqueIndex:idx_role_assignments_grant;not null;default:''"`
+	UserID         string `gorm:"uniqueIndex:idx_role_assignments_grant;index:idx_role_assignments_org_user;not null;default:''"`
+	TeamID         uint   `gorm:"uniqueIndex:idx_role_assignments_grant;index;not null;default:0"`
+	RoleName       string `gorm:"uniqueIndex:idx_role_assignments_grant;not null"`
+}
@@ -0,0 +1,12 @@
+package entities
+
+import "gorm.io/gorm"
+
+// Team is a named set of organization members that roles can be granted to together.
+type Team struct {
+	gorm.Model
+	OrganizationID uint   `gorm:"uniqueIndex:idx_teams_org_slug;not null"`
+	Name           string `gorm:"not null"`
+	// Slug is the team's URL-safe handle, unique within its organization. It never changes.
+	Slug string `gorm:"uniqueIndex:idx_teams_org_slug;not null"`
+}
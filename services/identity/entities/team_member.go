@@ -0,0 +1,11 @@
+package entities
+
+import "gorm.io/gorm"
+
+// TeamMember puts a member of the team's organization in the team.
+// UserID is the user's auth service subject.
+type TeamMember struct {
+	gorm.Model
+	TeamID uint   `gorm:"uniqueIndex:idx_team_members_team_user;not null"`
+	UserID string `gorm:"uniqueIndex:idx_team_members_team_user;index;not null"`
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/himakhaitan/noreboothq/services/identity/controllers"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatusError maps controller errors to gRPC status errors.
+// Unexpected errors are logged and returned as Internal without leaking details to the client.
+func (h *IdentityHandler) toStatusError(err error) error {
+	switch {
+	case errors.Is(err, controllers.ErrOrganizationNotFound), errors.Is(err, controllers.ErrProjectNotFound),
+		errors.Is(err, controllers.ErrMemberNotFound), errors.Is(err, controllers.ErrRoleNotFound),
+		errors.Is(err, controllers.ErrAssignmentNotFound), errors.Is(err, controllers.ErrPolicyNotFound),
+		errors.Is(err, controllers.ErrTeamNotFound), errors.Is(err, controllers.ErrInviteNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, controllers.ErrNotOwner), errors.Is(err, controllers.ErrBuiltInRole):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, controllers.ErrLastOwner), errors.Is(err, controllers.ErrNotOrganizationMember),
+		errors.Is(err, controllers.ErrInviteNotPending), errors.Is(err, controllers.ErrAccountExists):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, controllers.ErrSlugTaken), errors.Is(err, controllers.ErrAlreadyMember),
+		errors.Is(err, controllers.ErrRoleExists), errors.Is(err, controllers.ErrAssignmentExists),
+		errors.Is(err, controllers.ErrPolicyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, controllers.ErrInvalidSlug), errors.Is(err, controllers.ErrInvalidName),
+		errors.Is(err, controllers.ErrInvalidRole), errors.Is(err, controllers.ErrInvalidRoleName),
+		errors.Is(err, controllers.ErrInvalidPermission), errors.Is(err, controllers.ErrInvalidResource),
+		errors.Is(err, controllers.ErrInvalidGroup), errors.Is(err, controllers.ErrInvalidPolicy),
+		errors.Is(err, controllers.ErrInvalidAssignee), errors.Is(err, controllers.ErrInvalidEmail),
+		errors.Is(err, controllers.ErrInvalidInvite), errors.Is(err, controllers.ErrPasswordRequired),
+		errors.Is(err, controllers.ErrAccountRejected):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, controllers.ErrWatcherLagging):
+		return status.Error(codes.Aborted, err.Error())
+	default:
+		h.logger.Error("Request failed", zap.Error(err))
+		return status.Error(codes.Internal, "internal error")
+	}
+}
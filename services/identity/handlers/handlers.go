@@ -0,0 +1,1246 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	identitypb "github.com/himakhaitan/noreboothq/proto/identity"
+	"github.com/himakhaitan/noreboothq/services/identity/controllers"
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+	"github.com/himakhaitan/noreboothq/shared/authz"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// IdentityHandler implements the IdentityService gRPC API on top of the IdentityController.
+type IdentityHandler struct {
+	identitypb.UnimplementedIdentityServiceServer
+	ctrl   *controllers.IdentityController
+	logger *zap.Logger
+}
+
+// OptionalAuthMethods are the IdentityService RPCs callable without a bearer token. A token sent
+// with them is still verified, so signed-in callers are recognised. Every other method requires one.
+var OptionalAuthMethods = []string{
+	identitypb.IdentityService_AcceptInvite_FullMethodName,
+}
+
+// NewIdentityHandler creates a new instance of IdentityHandler with the provided IdentityController and logger.
+func NewIdentityHandler(ctrl *controllers.IdentityController, logger *zap.Logger) *IdentityHandler {
+	return &IdentityHandler{
+		ctrl:   ctrl,
+		logger: logger,
+	}
+}
+
+// CreateOrganization creates an organization owned by the caller.
+func (h *IdentityHandler) CreateOrganization(ctx context.Context, req *identitypb.CreateOrganizationRequest) (*identitypb.CreateOrganizationResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.Info("CreateOrganization request received",
+		zap.String("user_id", principal.Subject),
+		zap.String("slug", req.Slug),
+	)
+
+	org, err := h.ctrl.CreateOrganization(ctx, principal, req.Name, req.Slug)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.CreateOrganizationResponse{Organization: toOrganizationProto(org)}, nil
+}
+
+// GetOrganization returns an organization the caller belongs to.
+func (h *IdentityHandler) GetOrganization(ctx context.Context, req *identitypb.GetOrganizationRequest) (*identitypb.GetOrganizationResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Id == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	org, err := h.ctrl.GetOrganization(ctx, principal, uint(req.Id))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.GetOrganizationResponse{Organization: toOrganizationProto(org)}, nil
+}
+
+// ListOrganizations returns the organizations the caller belongs to.
+func (h *IdentityHandler) ListOrganizations(ctx context.Context, req *identitypb.ListOrganizationsRequest) (*identitypb.ListOrganizationsResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orgs, err := h.ctrl.ListOrganizations(ctx, principal)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	resp := &identitypb.ListOrganizationsResponse{Organizations: make([]*identitypb.Organization, 0, len(orgs))}
+	for i := range orgs {
+		resp.Organizations = append(resp.Organizations, toOrganizationProto(&orgs[i]))
+	}
+	return resp, nil
+}
+
+// UpdateOrganization renames an organization.
+func (h *IdentityHandler) UpdateOrganization(ctx context.Context, req *identitypb.UpdateOrganizationRequest) (*identitypb.UpdateOrganizationResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Id == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	org, err := h.ctrl.UpdateOrganization(ctx, principal, uint(req.Id), req.Name)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.UpdateOrganizationResponse{Organization: toOrganizationProto(org)}, nil
+}
+
+// DeleteOrganization deletes an organization with its projects and memberships.
+func (h *IdentityHandler) DeleteOrganization(ctx context.Context, req *identitypb.DeleteOrganizationRequest) (*identitypb.DeleteOrganizationResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Id == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	h.logger.Info("DeleteOrganization request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint64("organization_id", req.Id),
+	)
+
+	if err := h.ctrl.DeleteOrganization(ctx, principal, uint(req.Id)); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.DeleteOrganizationResponse{}, nil
+}
+
+// AddOrganizationMember adds a user to an organization or changes their role.
+func (h *IdentityHandler) AddOrganizationMember(ctx context.Context, req *identitypb.AddOrganizationMemberRequest) (*identitypb.AddOrganizationMemberResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.OrganizationId == 0 || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "organization_id and user_id are required")
+	}
+
+	h.logger.Info("AddOrganizationMember request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint64("organization_id", req.OrganizationId),
+		zap.String("member_id", req.UserId),
+		zap.String("role", req.Role),
+	)
+
+	member, err := h.ctrl.AddOrganizationMember(ctx, principal, uint(req.OrganizationId), req.UserId, req.Role)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.AddOrganizationMemberResponse{Member: toOrganizationMemberProto(member)}, nil
+}
+
+// RemoveOrganizationMember removes a user from an organization and its projects.
+func (h *IdentityHandler) RemoveOrganizationMember(ctx context.Context, req *identitypb.RemoveOrganizationMemberRequest) (*identitypb.RemoveOrganizationMemberResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.OrganizationId == 0 || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "organization_id and user_id are required")
+	}
+
+	h.logger.Info("RemoveOrganizationMember request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint64("organization_id", req.OrganizationId),
+		zap.String("member_id", req.UserId),
+	)
+
+	if err := h.ctrl.RemoveOrganizationMember(ctx, principal, uint(req.OrganizationId), req.UserId); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.RemoveOrganizationMemberResponse{}, nil
+}
+
+// ListOrganizationMembers returns the members of an organization.
+func (h *IdentityHandler) ListOrganizationMembers(ctx context.Context, req *identitypb.ListOrganizationMembersRequest) (*identitypb.ListOrganizationMembersResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.OrganizationId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "organization_id is required")
+	}
+
+	members, err := h.ctrl.ListOrganizationMembers(ctx, principal, uint(req.OrganizationId))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	resp := &identitypb.ListOrganizationMembersResponse{Members: make([]*identitypb.OrganizationMember, 0, len(members))}
+	for i := range members {
+		resp.Members = append(resp.Members, toOrganizationMemberProto(&members[i]))
+	}
+	return resp, nil
+}
+
+// SetOrganizationMemberGroups replaces the groups of an organization member.
+func (h *IdentityHandler) SetOrganizationMemberGroups(ctx context.Context, req *identitypb.SetOrganizationMemberGroupsRequest) (*identitypb.SetOrganizationMemberGroupsResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.OrganizationId == 0 || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "organization_id and user_id are required")
+	}
+
+	h.logger.Info("SetOrganizationMemberGroups request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint64("organization_id", req.OrganizationId),
+		zap.String("member_id", req.UserId),
+		zap.Strings("groups", req.Groups),
+	)
+
+	member, err := h.ctrl.SetOrganizationMemberGroups(ctx, principal, uint(req.OrganizationId), req.UserId, req.Groups)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.SetOrganizationMemberGroupsResponse{Member: toOrganizationMemberProto(member)}, nil
+}
+
+// CreateProject creates a project in an organization, by default the caller's active one.
+func (h *IdentityHandler) CreateProject(ctx context.Context, req *identitypb.CreateProjectRequest) (*identitypb.CreateProjectResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orgID, err := organizationID(principal, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.Info("CreateProject request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint("organization_id", orgID),
+		zap.String("slug", req.Slug),
+	)
+
+	project, err := h.ctrl.CreateProject(ctx, principal, orgID, req.Name, req.Slug)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.CreateProjectResponse{Project: toProjectProto(project)}, nil
+}
+
+// GetProject returns a project the caller can see.
+func (h *IdentityHandler) GetProject(ctx context.Context, req *identitypb.GetProjectRequest) (*identitypb.GetProjectResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Id == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	project, err := h.ctrl.GetProject(ctx, principal, uint(req.Id))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.GetProjectResponse{Project: toProjectProto(project)}, nil
+}
+
+// ListProjects returns the projects of an organization, by default the caller's active one.
+func (h *IdentityHandler) ListProjects(ctx context.Context, req *identitypb.ListProjectsRequest) (*identitypb.ListProjectsResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orgID, err := organizationID(principal, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := h.ctrl.ListProjects(ctx, principal, orgID)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	resp := &identitypb.ListProjectsResponse{Projects: make([]*identitypb.Project, 0, len(projects))}
+	for i := range projects {
+		resp.Projects = append(resp.Projects, toProjectProto(&projects[i]))
+	}
+	return resp, nil
+}
+
+// UpdateProject renames a project.
+func (h *IdentityHandler) UpdateProject(ctx context.Context, req *identitypb.UpdateProjectRequest) (*identitypb.UpdateProjectResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Id == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	project, err := h.ctrl.UpdateProject(ctx, principal, uint(req.Id), req.Name)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.UpdateProjectResponse{Project: toProjectProto(project)}, nil
+}
+
+// DeleteProject deletes a project with its memberships.
+func (h *IdentityHandler) DeleteProject(ctx context.Context, req *identitypb.DeleteProjectRequest) (*identitypb.DeleteProjectResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Id == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	h.logger.Info("DeleteProject request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint64("project_id", req.Id),
+	)
+
+	if err := h.ctrl.DeleteProject(ctx, principal, uint(req.Id)); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.DeleteProjectResponse{}, nil
+}
+
+// AddProjectMember adds a member of the project's organization to a project.
+func (h *IdentityHandler) AddProjectMember(ctx context.Context, req *identitypb.AddProjectMemberRequest) (*identitypb.AddProjectMemberResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ProjectId == 0 || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "project_id and user_id are required")
+	}
+
+	h.logger.Info("AddProjectMember request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint64("project_id", req.ProjectId),
+		zap.String("member_id", req.UserId),
+	)
+
+	member, err := h.ctrl.AddProjectMember(ctx, principal, uint(req.ProjectId), req.UserId)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.AddProjectMemberResponse{Member: toProjectMemberProto(member)}, nil
+}
+
+// RemoveProjectMember removes a user from a project.
+func (h *IdentityHandler) RemoveProjectMember(ctx context.Context, req *identitypb.RemoveProjectMemberRequest) (*identitypb.RemoveProjectMemberResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ProjectId == 0 || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "project_id and user_id are required")
+	}
+
+	h.logger.Info("RemoveProjectMember request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint64("project_id", req.ProjectId),
+		zap.String("member_id", req.UserId),
+	)
+
+	if err := h.ctrl.RemoveProjectMember(ctx, principal, uint(req.ProjectId), req.UserId); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.RemoveProjectMemberResponse{}, nil
+}
+
+// ListProjectMembers returns the members of a project.
+func (h *IdentityHandler) ListProjectMembers(ctx context.Context, req *identitypb.ListProjectMembersRequest) (*identitypb.ListProjectMembersResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ProjectId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "project_id is required")
+	}
+
+	members, err := h.ctrl.ListProjectMembers(ctx, principal, uint(req.ProjectId))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	resp := &identitypb.ListProjectMembersResponse{Members: make([]*identitypb.ProjectMember, 0, len(members))}
+	for i := range members {
+		resp.Members = append(resp.Members, toProjectMemberProto(&members[i]))
+	}
+	return resp, nil
+}
+
+// CreateTeam creates a team in an organization, by default the caller's active one.
+func (h *IdentityHandler) CreateTeam(ctx context.Context, req *identitypb.CreateTeamRequest) (*identitypb.CreateTeamResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orgID, err := organizationID(principal, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.Info("CreateTeam request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint("organization_id", orgID),
+		zap.String("slug", req.Slug),
+	)
+
+	team, err := h.ctrl.CreateTeam(ctx, principal, orgID, req.Name, req.Slug)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.CreateTeamResponse{Team: toTeamProto(team)}, nil
+}
+
+// ListTeams returns the teams of an organization, by default the caller's active one.
+func (h *IdentityHandler) ListTeams(ctx context.Context, req *identitypb.ListTeamsRequest) (*identitypb.ListTeamsResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orgID, err := organizationID(principal, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	teams, err := h.ctrl.ListTeams(ctx, principal, orgID)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	resp := &identitypb.ListTeamsResponse{Teams: make([]*identitypb.Team, 0, len(teams))}
+	for i := range teams {
+		resp.Teams = append(resp.Teams, toTeamProto(&teams[i]))
+	}
+	return resp, nil
+}
+
+// DeleteTeam deletes a team with its memberships and role assignments.
+func (h *IdentityHandler) DeleteTeam(ctx context.Context, req *identitypb.DeleteTeamRequest) (*identitypb.DeleteTeamResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.TeamId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "team_id is required")
+	}
+
+	h.logger.Info("DeleteTeam request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint64("team_id", req.TeamId),
+	)
+
+	if err := h.ctrl.DeleteTeam(ctx, principal, uint(req.TeamId)); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.DeleteTeamResponse{}, nil
+}
+
+// AddTeamMember adds a member of the team's organization to the team.
+func (h *IdentityHandler) AddTeamMember(ctx context.Context, req *identitypb.AddTeamMemberRequest) (*identitypb.AddTeamMemberResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.TeamId == 0 || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "team_id and user_id are required")
+	}
+
+	h.logger.Info("AddTeamMember request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint64("team_id", req.TeamId),
+		zap.String("member_id", req.UserId),
+	)
+
+	member, err := h.ctrl.AddTeamMember(ctx, principal, uint(req.TeamId), req.UserId)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.AddTeamMemberResponse{Member: toTeamMemberProto(member)}, nil
+}
+
+// RemoveTeamMember removes a user from a team.
+func (h *IdentityHandler) RemoveTeamMember(ctx context.Context, req *identitypb.RemoveTeamMemberRequest) (*identitypb.RemoveTeamMemberResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.TeamId == 0 || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "team_id and user_id are required")
+	}
+
+	h.logger.Info("RemoveTeamMember request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint64("team_id", req.TeamId),
+		zap.String("member_id", req.UserId),
+	)
+
+	if err := h.ctrl.RemoveTeamMember(ctx, principal, uint(req.TeamId), req.UserId); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.RemoveTeamMemberResponse{}, nil
+}
+
+// ListTeamMembers returns the members of a team.
+func (h *IdentityHandler) ListTeamMembers(ctx context.Context, req *identitypb.ListTeamMembersRequest) (*identitypb.ListTeamMembersResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.TeamId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "team_id is required")
+	}
+
+	members, err := h.ctrl.ListTeamMembers(ctx, principal, uint(req.TeamId))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	resp := &identitypb.ListTeamMembersResponse{Members: make([]*identitypb.TeamMember, 0, len(members))}
+	for i := range members {
+		resp.Members = append(resp.Members, toTeamMemberProto(&members[i]))
+	}
+	return resp, nil
+}
+
+// CreateInvite invites someone by email to an organization, by default the caller's active one.
+func (h *IdentityHandler) CreateInvite(ctx context.Context, req *identitypb.CreateInviteRequest) (*identitypb.CreateInviteResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Email == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+	orgID, err := organizationID(principal, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+	teamIDs := make([]uint, 0, len(req.TeamIds))
+	for _, id := range req.TeamIds {
+		teamIDs = append(teamIDs, uint(id))
+	}
+
+	h.logger.Info("CreateInvite request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint("organization_id", orgID),
+		zap.String("email", req.Email),
+		zap.String("role", req.Role),
+	)
+
+	invite, err := h.ctrl.CreateInvite(ctx, principal, orgID, req.Email, req.Role, teamIDs)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.CreateInviteResponse{Invite: toInviteProto(invite, time.Now())}, nil
+}
+
+// ListInvites returns the invites of an organization, by default the caller's active one.
+func (h *IdentityHandler) ListInvites(ctx context.Context, req *identitypb.ListInvitesRequest) (*identitypb.ListInvitesResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orgID, err := organizationID(principal, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	invites, err := h.ctrl.ListInvites(ctx, principal, orgID)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	now := time.Now()
+	resp := &identitypb.ListInvitesResponse{Invites: make([]*identitypb.Invite, 0, len(invites))}
+	for i := range invites {
+		resp.Invites = append(resp.Invites, toInviteProto(&invites[i], now))
+	}
+	return resp, nil
+}
+
+// RevokeInvite withdraws a pending invite.
+func (h *IdentityHandler) RevokeInvite(ctx context.Context, req *identitypb.RevokeInviteRequest) (*identitypb.RevokeInviteResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Id == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	h.logger.Info("RevokeInvite request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint64("invite_id", req.Id),
+	)
+
+	if err := h.ctrl.RevokeInvite(ctx, principal, uint(req.Id)); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.RevokeInviteResponse{}, nil
+}
+
+// AcceptInvite redeems an invite link, joining as the signed-in caller or creating an account for the invited email.
+func (h *IdentityHandler) AcceptInvite(ctx context.Context, req *identitypb.AcceptInviteRequest) (*identitypb.AcceptInviteResponse, error) {
+	if req.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	// Signed-in callers are optional here: the interceptor only attaches a principal if a token was sent.
+	principal, _ := authn.PrincipalFromContext(ctx)
+	if principal != nil {
+		h.logger.Info("AcceptInvite request received", zap.String("user_id", principal.Subject))
+	} else {
+		h.logger.Info("AcceptInvite request received")
+	}
+
+	accepted, err := h.ctrl.AcceptInvite(ctx, principal, req.Token, req.Password)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	h.logger.Info("Invite accepted",
+		zap.Uint("organization_id", accepted.OrganizationID),
+		zap.String("user_id", accepted.UserID),
+		zap.Bool("account_created", accepted.AccountCreated),
+	)
+	return &identitypb.AcceptInviteResponse{
+		OrganizationId: uint64(accepted.OrganizationID),
+		UserId:         accepted.UserID,
+		AccountCreated: accepted.AccountCreated,
+	}, nil
+}
+
+// ListRoles returns the built-in and custom roles of an organization, by default the caller's active one.
+func (h *IdentityHandler) ListRoles(ctx context.Context, req *identitypb.ListRolesRequest) (*identitypb.ListRolesResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orgID, err := organizationID(principal, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := h.ctrl.ListRoles(ctx, principal, orgID)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	resp := &identitypb.ListRolesResponse{Roles: make([]*identitypb.Role, 0, len(roles))}
+	for i := range roles {
+		resp.Roles = append(resp.Roles, toRoleProto(&roles[i]))
+	}
+	return resp, nil
+}
+
+// CreateRole defines a custom role in an organization.
+func (h *IdentityHandler) CreateRole(ctx context.Context, req *identitypb.CreateRoleRequest) (*identitypb.CreateRoleResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orgID, err := organizationID(principal, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.Info("CreateRole request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint("organization_id", orgID),
+		zap.String("role", req.Name),
+		zap.Strings("permissions", req.Permissions),
+	)
+
+	role, err := h.ctrl.CreateRole(ctx, principal, orgID, req.Name, req.Description, req.Permissions)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.CreateRoleResponse{Role: toRoleProto(role)}, nil
+}
+
+// UpdateRole changes a custom role's description and permissions.
+func (h *IdentityHandler) UpdateRole(ctx context.Context, req *identitypb.UpdateRoleRequest) (*identitypb.UpdateRoleResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	orgID, err := organizationID(principal, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.Info("UpdateRole request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint("organization_id", orgID),
+		zap.String("role", req.Name),
+		zap.Strings("permissions", req.Permissions),
+	)
+
+	role, err := h.ctrl.UpdateRole(ctx, principal, orgID, req.Name, req.Description, req.Permissions)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.UpdateRoleResponse{Role: toRoleProto(role)}, nil
+}
+
+// DeleteRole deletes a custom role and its assignments.
+func (h *IdentityHandler) DeleteRole(ctx context.Context, req *identitypb.DeleteRoleRequest) (*identitypb.DeleteRoleResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	orgID, err := organizationID(principal, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.Info("DeleteRole request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint("organization_id", orgID),
+		zap.String("role", req.Name),
+	)
+
+	if err := h.ctrl.DeleteRole(ctx, principal, orgID, req.Name); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.DeleteRoleResponse{}, nil
+}
+
+// AssignRole grants a role to an organization member on an organization, project or environment.
+func (h *IdentityHandler) AssignRole(ctx context.Context, req *identitypb.AssignRoleRequest) (*identitypb.AssignRoleResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Role == "" {
+		return nil, status.Error(codes.InvalidArgument, "role is required")
+	}
+	if (req.UserId == "") == (req.TeamId == 0) {
+		return nil, status.Error(codes.InvalidArgument, "exactly one of user_id and team_id is required")
+	}
+	scope, err := resource(principal, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.Info("AssignRole request received",
+		zap.String("user_id", principal.Subject),
+		zap.Stringer("scope", scope),
+		zap.String("member_id", req.UserId),
+		zap.Uint64("team_id", req.TeamId),
+		zap.String("role", req.Role),
+	)
+
+	assignment, err := h.ctrl.AssignRole(ctx, principal, scope, req.UserId, uint(req.TeamId), req.Role)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.AssignRoleResponse{Assignment: toRoleAssignmentProto(assignment)}, nil
+}
+
+// UnassignRole withdraws a role assignment.
+func (h *IdentityHandler) UnassignRole(ctx context.Context, req *identitypb.UnassignRoleRequest) (*identitypb.UnassignRoleResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Id == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	h.logger.Info("UnassignRole request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint64("assignment_id", req.Id),
+	)
+
+	if err := h.ctrl.UnassignRole(ctx, principal, uint(req.Id)); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.UnassignRoleResponse{}, nil
+}
+
+// ListRoleAssignments returns the role assignments of an organization the caller may see.
+func (h *IdentityHandler) ListRoleAssignments(ctx context.Context, req *identitypb.ListRoleAssignmentsRequest) (*identitypb.ListRoleAssignmentsResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orgID, err := organizationID(principal, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments, err := h.ctrl.ListRoleAssignments(ctx, principal, orgID, req.UserId)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	resp := &identitypb.ListRoleAssignmentsResponse{Assignments: make([]*identitypb.RoleAssignment, 0, len(assignments))}
+	for i := range assignments {
+		resp.Assignments = append(resp.Assignments, toRoleAssignmentProto(&assignments[i]))
+	}
+	return resp, nil
+}
+
+// Authorize decides whether a user may perform an action on a resource.
+func (h *IdentityHandler) Authorize(ctx context.Context, req *identitypb.AuthorizeRequest) (*identitypb.AuthorizeResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Action == "" {
+		return nil, status.Error(codes.InvalidArgument, "action is required")
+	}
+	res, err := resource(principal, req.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	decision, err := h.ctrl.Authorize(ctx, principal, authz.Request{
+		Subject:            req.UserId,
+		Action:             req.Action,
+		Resource:           res,
+		ResourceAttributes: req.ResourceAttributes,
+		Context:            req.Context,
+	})
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	if !decision.Allowed {
+		h.logger.Info("Authorization denied",
+			zap.String("user_id", principal.Subject),
+			zap.String("subject", req.UserId),
+			zap.String("action", req.Action),
+			zap.Stringer("resource", res),
+			zap.String("policy", decision.Policy),
+		)
+	}
+
+	resp := &identitypb.AuthorizeResponse{Allowed: decision.Allowed, Policy: decision.Policy}
+	if decision.Role != "" {
+		resp.Role = decision.Role
+		resp.Scope = toResourceProto(decision.Scope)
+		resp.Team = decision.Team
+	}
+	return resp, nil
+}
+
+// ListGrants returns the roles the caller holds in an organization, their attributes and the organization's policies.
+func (h *IdentityHandler) ListGrants(ctx context.Context, req *identitypb.ListGrantsRequest) (*identitypb.ListGrantsResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orgID, err := organizationID(principal, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := h.ctrl.ListGrants(ctx, principal, orgID)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	resp := &identitypb.ListGrantsResponse{
+		Grants:   make([]*identitypb.Grant, 0, len(snapshot.Grants)),
+		OrgRole:  snapshot.OrgRole,
+		Groups:   snapshot.Groups,
+		Policies: make([]*identitypb.Policy, 0, len(snapshot.Policies)),
+		Teams:    snapshot.Teams,
+	}
+	for _, grant := range snapshot.Grants {
+		resp.Grants = append(resp.Grants, &identitypb.Grant{
+			Role:        grant.Role,
+			Scope:       toResourceProto(grant.Scope),
+			Permissions: grant.Permissions,
+			Team:        grant.Team,
+		})
+	}
+	for i := range snapshot.Policies {
+		resp.Policies = append(resp.Policies, toPolicyProto(&snapshot.Policies[i]))
+	}
+	return resp, nil
+}
+
+// WatchAuthorizationChanges streams the organization ID of every authorization change to services
+// that cache ListGrants, until the caller hangs up.
+func (h *IdentityHandler) WatchAuthorizationChanges(req *identitypb.WatchAuthorizationChangesRequest, stream grpc.ServerStreamingServer[identitypb.AuthorizationChange]) error {
+	principal, err := authn.RequireScope(stream.Context(), controllers.ScopeIdentityWatch)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("WatchAuthorizationChanges stream opened", zap.String("subject", principal.Subject))
+	err = h.ctrl.WatchAuthorizationChanges(stream.Context(), func(orgID uint) error {
+		return stream.Send(&identitypb.AuthorizationChange{OrganizationId: uint64(orgID)})
+	})
+	if errors.Is(err, controllers.ErrWatcherLagging) {
+		return h.toStatusError(err)
+	}
+	// Anything else is the stream failing to send, already a status error.
+	return err
+}
+
+// ListPolicies returns the policies of an organization, by default the caller's active one.
+func (h *IdentityHandler) ListPolicies(ctx context.Context, req *identitypb.ListPoliciesRequest) (*identitypb.ListPoliciesResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orgID, err := organizationID(principal, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := h.ctrl.ListPolicies(ctx, principal, orgID)
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+
+	resp := &identitypb.ListPoliciesResponse{Policies: make([]*identitypb.Policy, 0, len(policies))}
+	for i := range policies {
+		resp.Policies = append(resp.Policies, toPolicyProto(&policies[i]))
+	}
+	return resp, nil
+}
+
+// CreatePolicy adds a policy to an organization.
+func (h *IdentityHandler) CreatePolicy(ctx context.Context, req *identitypb.CreatePolicyRequest) (*identitypb.CreatePolicyResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Policy == nil {
+		return nil, status.Error(codes.InvalidArgument, "policy is required")
+	}
+	orgID, err := organizationID(principal, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.Info("CreatePolicy request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint("organization_id", orgID),
+		zap.String("policy", req.Policy.Name),
+		zap.String("effect", req.Policy.Effect),
+	)
+
+	policy, err := h.ctrl.CreatePolicy(ctx, principal, orgID, fromPolicyProto(req.Policy))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.CreatePolicyResponse{Policy: toPolicyProto(policy)}, nil
+}
+
+// UpdatePolicy replaces a policy of an organization.
+func (h *IdentityHandler) UpdatePolicy(ctx context.Context, req *identitypb.UpdatePolicyRequest) (*identitypb.UpdatePolicyResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Policy == nil {
+		return nil, status.Error(codes.InvalidArgument, "policy is required")
+	}
+	orgID, err := organizationID(principal, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.Info("UpdatePolicy request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint("organization_id", orgID),
+		zap.String("policy", req.Policy.Name),
+		zap.String("effect", req.Policy.Effect),
+	)
+
+	policy, err := h.ctrl.UpdatePolicy(ctx, principal, orgID, fromPolicyProto(req.Policy))
+	if err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.UpdatePolicyResponse{Policy: toPolicyProto(policy)}, nil
+}
+
+// DeletePolicy deletes a policy of an organization.
+func (h *IdentityHandler) DeletePolicy(ctx context.Context, req *identitypb.DeletePolicyRequest) (*identitypb.DeletePolicyResponse, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	orgID, err := organizationID(principal, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.Info("DeletePolicy request received",
+		zap.String("user_id", principal.Subject),
+		zap.Uint("organization_id", orgID),
+		zap.String("policy", req.Name),
+	)
+
+	if err := h.ctrl.DeletePolicy(ctx, principal, orgID, req.Name); err != nil {
+		return nil, h.toStatusError(err)
+	}
+	return &identitypb.DeletePolicyResponse{}, nil
+}
+
+// organizationID returns the requested organization, defaulting to the one the caller's token acts within.
+func organizationID(principal *authn.Principal, requested uint64) (uint, error) {
+	if requested != 0 {
+		return uint(requested), nil
+	}
+	if principal.OrgID == "" {
+		return 0, status.Error(codes.InvalidArgument, "organization_id is required without an active organization")
+	}
+	id, err := strconv.ParseUint(principal.OrgID, 10, 64)
+	if err != nil {
+		return 0, status.Error(codes.InvalidArgument, "the token's active organization is invalid")
+	}
+	return uint(id), nil
+}
+
+// resource converts a requested resource, whose organization defaults to the caller's active one.
+func resource(principal *authn.Principal, requested *identitypb.Resource) (authz.Resource, error) {
+	orgID, err := organizationID(principal, requested.GetOrganizationId())
+	if err != nil {
+		return authz.Resource{}, err
+	}
+	return authz.Resource{
+		OrganizationID: orgID,
+		ProjectID:      uint(requested.GetProjectId()),
+		Environment:    requested.GetEnvironment(),
+	}, nil
+}
+
+// toOrganizationProto converts an organization and the caller's role in it to its wire form.
+func toOrganizationProto(org *controllers.Organization) *identitypb.Organization {
+	return &identitypb.Organization{
+		Id:        uint64(org.ID),
+		Name:      org.Name,
+		Slug:      org.Slug,
+		CreatedAt: org.CreatedAt.Unix(),
+		Role:      org.Role,
+	}
+}
+
+// toOrganizationMemberProto converts an organization membership to its wire form.
+func toOrganizationMemberProto(member *entities.OrganizationMember) *identitypb.OrganizationMember {
+	return &identitypb.OrganizationMember{
+		UserId:    member.UserID,
+		Role:      member.Role,
+		CreatedAt: member.CreatedAt.Unix(),
+		Groups:    strings.Fields(member.Groups),
+	}
+}
+
+// toProjectProto converts a project to its wire form.
+func toProjectProto(project *entities.Project) *identitypb.Project {
+	return &identitypb.Project{
+		Id:             uint64(project.ID),
+		OrganizationId: uint64(project.OrganizationID),
+		Name:           project.Name,
+		Slug:           project.Slug,
+		CreatedAt:      project.CreatedAt.Unix(),
+	}
+}
+
+// toProjectMemberProto converts a project membership to its wire form.
+func toProjectMemberProto(member *entities.ProjectMember) *identitypb.ProjectMember {
+	return &identitypb.ProjectMember{
+		UserId:    member.UserID,
+		CreatedAt: member.CreatedAt.Unix(),
+	}
+}
+
+// toTeamProto converts a team to its wire form.
+func toTeamProto(team *entities.Team) *identitypb.Team {
+	return &identitypb.Team{
+		Id:             uint64(team.ID),
+		OrganizationId: uint64(team.OrganizationID),
+		Name:           team.Name,
+		Slug:           team.Slug,
+		CreatedAt:      team.CreatedAt.Unix(),
+	}
+}
+
+// toTeamMemberProto converts a team membership to its wire form.
+func toTeamMemberProto(member *entities.TeamMember) *identitypb.TeamMember {
+	return &identitypb.TeamMember{
+		UserId:    member.UserID,
+		CreatedAt: member.CreatedAt.Unix(),
+	}
+}
+
+// toInviteProto converts an invite to its wire form, with its status at the given time.
+func toInviteProto(invite *entities.Invite, now time.Time) *identitypb.Invite {
+	var teamIDs []uint64
+	for _, field := range strings.Fields(invite.TeamIDs) {
+		if id, err := strconv.ParseUint(field, 10, 64); err == nil {
+			teamIDs = append(teamIDs, id)
+		}
+	}
+	return &identitypb.Invite{
+		Id:             uint64(invite.ID),
+		OrganizationId: uint64(invite.OrganizationID),
+		Email:          invite.Email,
+		Role:           invite.Role,
+		TeamIds:        teamIDs,
+		InvitedBy:      invite.InvitedBy,
+		Status:         controllers.InviteStatus(invite, now),
+		CreatedAt:      invite.CreatedAt.Unix(),
+		ExpiresAt:      invite.ExpiresAt.Unix(),
+		AcceptedBy:     invite.AcceptedBy,
+	}
+}
+
+// toRoleProto converts a role to its wire form.
+func toRoleProto(role *controllers.Role) *identitypb.Role {
+	return &identitypb.Role{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: role.Permissions,
+		BuiltIn:     role.BuiltIn,
+	}
+}
+
+// toRoleAssignmentProto converts a role assignment to its wire form.
+func toRoleAssignmentProto(assignment *entities.RoleAssignment) *identitypb.RoleAssignment {
+	return &identitypb.RoleAssignment{
+		Id:     uint64(assignment.ID),
+		UserId: assignment.UserID,
+		Role:   assignment.RoleName,
+		Scope: toResourceProto(authz.Resource{
+			OrganizationID: assignment.OrganizationID,
+			ProjectID:      assignment.ProjectID,
+			Environment:    assignment.Environment,
+		}),
+		CreatedAt: assignment.CreatedAt.Unix(),
+		TeamId:    uint64(assignment.TeamID),
+	}
+}
+
+// toResourceProto converts a resource to its wire form.
+func toResourceProto(resource authz.Resource) *identitypb.Resource {
+	return &identitypb.Resource{
+		OrganizationId: uint64(resource.OrganizationID),
+		ProjectId:      uint64(resource.ProjectID),
+		Environment:    resource.Environment,
+	}
+}
+
+// toPolicyProto converts a policy to its wire form.
+func toPolicyProto(policy *authz.Policy) *identitypb.Policy {
+	conditions := make([]*identitypb.Condition, 0, len(policy.Conditions))
+	for _, condition := range policy.Conditions {
+		conditions = append(conditions, &identitypb.Condition{
+			Attribute: condition.Attribute,
+			Operator:  condition.Operator,
+			Values:    condition.Values,
+		})
+	}
+	return &identitypb.Policy{
+		Name:        policy.Name,
+		Description: policy.Description,
+		Effect:      policy.Effect,
+		Actions:     policy.Actions,
+		Scope:       toResourceProto(policy.Scope),
+		Conditions:  conditions,
+		Timezone:    policy.Timezone,
+	}
+}
+
+// fromPolicyProto converts a requested policy. The scope's organization is set by the controller.
+func fromPolicyProto(policy *identitypb.Policy) authz.Policy {
+	conditions := make([]authz.Condition, 0, len(policy.Conditions))
+	for _, condition := range policy.Conditions {
+		conditions = append(conditions, authz.Condition{
+			Attribute: condition.GetAttribute(),
+			Operator:  condition.GetOperator(),
+			Values:    condition.GetValues(),
+		})
+	}
+	return authz.Policy{
+		Name:        policy.Name,
+		Description: policy.Description,
+		Effect:      policy.Effect,
+		Actions:     policy.Actions,
+		Scope: authz.Resource{
+			ProjectID:   uint(policy.GetScope().GetProjectId()),
+			Environment: policy.GetScope().GetEnvironment(),
+		},
+		Conditions: conditions,
+		Timezone:   policy.Timezone,
+	}
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+)
+
+// authorizationChannel is the Postgres notification channel authorization changes are announced on.
+const authorizationChannel = "identity_authorization_changes"
+
+// AuthorizationChangeRepository announces changes to what authorization decisions are made from
+// (roles, assignments, policies, memberships and teams) to every identity replica, through Postgres
+// LISTEN/NOTIFY. Notifications carry the ID of the organization that changed.
+type AuthorizationChangeRepository interface {
+	Publish(ctx context.Context, orgID uint) error
+	Listen(ctx context.Context, ready func(), handle func(orgID uint)) error
+}
+
+// authorizationChangeRepository implements AuthorizationChangeRepository on the service's database.
+type authorizationChangeRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthorizationChangeRepository(db *gorm.DB) AuthorizationChangeRepository {
+	return &authorizationChangeRepository{db: db}
+}
+
+// Publish notifies every listener, this replica's included, that the organization changed.
+// Postgres delivers notifications sent inside a transaction only once it commits.
+func (r *authorizationChangeRepository) Publish(ctx context.Context, orgID uint) error {
+	return r.db.WithContext(ctx).
+		Exec("SELECT pg_notify(?, ?)", authorizationChannel, strconv.FormatUint(uint64(orgID), 10)).Error
+}
+
+// Listen holds a connection of its own that listens for changes, calls ready once it does and then
+// handle for every change, until ctx is done or the connection fails. Changes published while
+// nobody listens are lost, so callers must assume anything changed before ready is called.
+func (r *authorizationChangeRepository) Listen(ctx context.Context, ready func(), handle func(orgID uint)) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	// The connection is left in LISTEN mode, so it is discarded rather than returned to the pool.
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn any) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("listening needs a pgx connection, not %T", driverConn)
+		}
+		pgConn := stdConn.Conn()
+		defer pgConn.Close(context.WithoutCancel(ctx))
+
+		if _, err := pgConn.Exec(ctx, "LISTEN "+authorizationChannel); err != nil {
+			return err
+		}
+		ready()
+		for {
+			notification, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+			orgID, err := strconv.ParseUint(notification.Payload, 10, 0)
+			if err != nil {
+				return fmt.Errorf("malformed authorization change %q: %w", notification.Payload, err)
+			}
+			handle(uint(orgID))
+		}
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
@@ -0,0 +1,17 @@
+package repository
+
+import "errors"
+
+// Errors returned by repositories. Database-specific errors are translated into these
+// so callers never depend on GORM or Postgres directly.
+var (
+	// ErrNotFound is returned when the requested record does not exist.
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicate is returned when a write violates a unique constraint.
+	ErrDuplicate = errors.New("record already exists")
+	// ErrLastOwner is returned when a membership change would leave an organization without an owner.
+	ErrLastOwner = errors.New("organization must keep at least one owner")
+	// ErrConflict is returned when a conditional update finds the record already changed, such as
+	// an invite that was accepted or revoked in the meantime.
+	ErrConflict = errors.New("record was changed concurrently")
+)
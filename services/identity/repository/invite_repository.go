@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InviteRepository interface {
+	Create(ctx context.Context, invite *entities.Invite) error
+	GetByID(ctx context.Context, id uint) (*entities.Invite, error)
+	GetByHash(ctx context.Context, tokenHash string) (*entities.Invite, error)
+	ListByOrganization(ctx context.Context, orgID uint) ([]entities.Invite, error)
+	Revoke(ctx context.Context, id uint) error
+	Accept(ctx context.Context, invite *entities.Invite, userID string, teamIDs []uint) error
+}
+
+// inviteRepository implements InviteRepository for invitations to organizations.
+type inviteRepository struct {
+	db *gorm.DB
+}
+
+func NewInviteRepository(db *gorm.DB) InviteRepository {
+	return &inviteRepository{db: db}
+}
+
+// Create stores a newly issued invite.
+func (r *inviteRepository) Create(ctx context.Context, invite *entities.Invite) error {
+	return r.db.WithContext(ctx).Create(invite).Error
+}
+
+// GetByID retrieves an invite by primary key.
+// It returns ErrNotFound if the invite does not exist.
+func (r *inviteRepository) GetByID(ctx context.Context, id uint) (*entities.Invite, error) {
+	var invite entities.Invite
+	if err := r.db.WithContext(ctx).First(&invite, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// GetByHash retrieves an invite by the hash of its raw token.
+// It returns ErrNotFound if no invite matches.
+func (r *inviteRepository) GetByHash(ctx context.Context, tokenHash string) (*entities.Invite, error) {
+	var invite entities.Invite
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&invite).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// ListByOrganization returns the invites of an organization, newest first.
+func (r *inviteRepository) ListByOrganization(ctx context.Context, orgID uint) ([]entities.Invite, error) {
+	var invites []entities.Invite
+	if err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).Order("id DESC").Find(&invites).Error; err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+// Revoke marks an invite as revoked. It returns ErrConflict if the invite was already accepted or revoked.
+func (r *inviteRepository) Revoke(ctx context.Context, id uint) error {
+	res := r.db.WithContext(ctx).Model(&entities.Invite{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// Accept redeems an invite for the user in one transaction: it marks the invite accepted, adds the
+// user to its organization with the invite's role, promoting but never demoting an existing member,
+// and to those of teamIDs that still belong to the organization. It returns ErrConflict if the invite
+// is no longer pending, which means another request redeemed it first, and ErrNotFound if the
+// organization no longer exists.
+func (r *inviteRepository) Accept(ctx context.Context, invite *entities.Invite, userID string, teamIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockOrganization(tx, invite.OrganizationID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		res := tx.Model(&entities.Invite{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", invite.ID, now).
+			Updates(map[string]any{"accepted_at": now, "accepted_by": userID})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrConflict
+		}
+
+		var existing entities.OrganizationMember
+		err := tx.Where("organization_id = ? AND user_id = ?", invite.OrganizationID, userID).First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			member := &entities.OrganizationMember{OrganizationID: invite.OrganizationID, UserID: userID, Role: invite.Role}
+			if err := tx.Create(member).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case invite.Role == entities.OrganizationRoleOwner && existing.Role != entities.OrganizationRoleOwner:
+			if err := tx.Model(&existing).Update("role", invite.Role).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(teamIDs) == 0 {
+			return nil
+		}
+		var teams []uint
+		err = tx.Model(&entities.Team{}).Where("id IN ? AND organization_id = ?", teamIDs, invite.OrganizationID).Pluck("id", &teams).Error
+		if err != nil {
+			return err
+		}
+		for _, teamID := range teams {
+			err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entities.TeamMember{TeamID: teamID, UserID: userID}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
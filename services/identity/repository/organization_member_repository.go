@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OrganizationMemberRepository interface {
+	Get(ctx context.Context, orgID uint, userID string) (*entities.OrganizationMember, error)
+	ListByOrganization(ctx context.Context, orgID uint) ([]entities.OrganizationMember, error)
+	ListByUser(ctx context.Context, userID string) ([]entities.OrganizationMember, error)
+	Save(ctx context.Context, member *entities.OrganizationMember) error
+	SetGroups(ctx context.Context, orgID uint, userID string, groups string) error
+	Remove(ctx context.Context, orgID uint, userID string) error
+}
+
+// organizationMemberRepository implements OrganizationMemberRepository for organization memberships.
+type organizationMemberRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationMemberRepository(db *gorm.DB) OrganizationMemberRepository {
+	return &organizationMemberRepository{db: db}
+}
+
+// Get retrieves the user's membership of an organization.
+// It returns ErrNotFound if the user is not a member.
+func (r *organizationMemberRepository) Get(ctx context.Context, orgID uint, userID string) (*entities.OrganizationMember, error) {
+	var member entities.OrganizationMember
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND user_id = ?", orgID, userID).First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// ListByOrganization returns the members of an organization in the order they joined.
+func (r *organizationMemberRepository) ListByOrganization(ctx context.Context, orgID uint) ([]entities.OrganizationMember, error) {
+	var members []entities.OrganizationMember
+	if err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).Order("id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// ListByUser returns the user's memberships of every organization they belong to.
+func (r *organizationMemberRepository) ListByUser(ctx context.Context, userID string) ([]entities.OrganizationMember, error) {
+	var members []entities.OrganizationMember
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// Save adds the user to the organization, or changes their role if they already belong to it.
+// member is updated with the stored record. It returns ErrNotFound if the organization does not exist
+// and ErrLastOwner when demoting its only owner.
+func (r *organizationMemberRepository) Save(ctx context.Context, member *entities.OrganizationMember) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockOrganization(tx, member.OrganizationID); err != nil {
+			return err
+		}
+
+		var existing entities.OrganizationMember
+		err := tx.Where("organization_id = ? AND user_id = ?", member.OrganizationID, member.UserID).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(member).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		if existing.Role == entities.OrganizationRoleOwner && member.Role != entities.OrganizationRoleOwner {
+			if err := ensureAnotherOwner(tx, member.OrganizationID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&existing).Update("role", member.Role).Error; err != nil {
+			return err
+		}
+		*member = existing
+		return nil
+	})
+}
+
+// SetGroups replaces the space-separated groups of a member.
+// It returns ErrNotFound if the user is not a member.
+func (r *organizationMemberRepository) SetGroups(ctx context.Context, orgID uint, userID string, groups string) error {
+	res := r.db.WithContext(ctx).Model(&entities.OrganizationMember{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Update("groups", groups)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Remove takes the user out of the organization and every project and team in it, withdrawing their roles.
+// It returns ErrNotFound if the user is not a member and ErrLastOwner when removing its only owner.
+func (r *organizationMemberRepository) Remove(ctx context.Context, orgID uint, userID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockOrganization(tx, orgID); err != nil {
+			return err
+		}
+
+		var existing entities.OrganizationMember
+		err := tx.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&existing).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if existing.Role == entities.OrganizationRoleOwner {
+			if err := ensureAnotherOwner(tx, orgID); err != nil {
+				return err
+			}
+		}
+
+		projects := tx.Model(&entities.Project{}).Select("id").Where("organization_id = ?", orgID)
+		err = tx.Unscoped().Where("project_id IN (?) AND user_id = ?", projects, userID).Delete(&entities.ProjectMember{}).Error
+		if err != nil {
+			return err
+		}
+		teams := tx.Model(&entities.Team{}).Select("id").Where("organization_id = ?", orgID)
+		err = tx.Unscoped().Where("team_id IN (?) AND user_id = ?", teams, userID).Delete(&entities.TeamMember{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Where("organization_id = ? AND user_id = ?", orgID, userID).Delete(&entities.RoleAssignment{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&existing).Error
+	})
+}
+
+// lockOrganization locks the organization's row for the rest of the transaction, so concurrent
+// membership changes cannot both remove the last owner. It returns ErrNotFound if the organization does not exist.
+func lockOrganization(tx *gorm.DB, orgID uint) error {
+	var org entities.Organization
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&org, orgID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ensureAnotherOwner returns ErrLastOwner unless the organization has more than one owner.
+func ensureAnotherOwner(tx *gorm.DB, orgID uint) error {
+	var owners int64
+	err := tx.Model(&entities.OrganizationMember{}).
+		Where("organization_id = ? AND role = ?", orgID, entities.OrganizationRoleOwner).
+		Count(&owners).Error
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
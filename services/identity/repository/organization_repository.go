@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"gorm.io/gorm"
+)
+
+type OrganizationRepository interface {
+	Create(ctx context.Context, org *entities.Organization, ownerID string) error
+	GetByID(ctx context.Context, id uint) (*entities.Organization, error)
+	ListByMember(ctx context.Context, userID string) ([]entities.Organization, error)
+	UpdateName(ctx context.Context, id uint, name string) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// organizationRepository implements OrganizationRepository for tenants.
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+// Create stores a new organization together with its first owner.
+// It returns ErrDuplicate if the slug is taken.
+func (r *organizationRepository) Create(ctx context.Context, org *entities.Organization, ownerID string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		return tx.Create(&entities.OrganizationMember{
+			OrganizationID: org.ID,
+			UserID:         ownerID,
+			Role:           entities.OrganizationRoleOwner,
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// GetByID retrieves an organization by primary key.
+// It returns ErrNotFound if the organization does not exist.
+func (r *organizationRepository) GetByID(ctx context.Context, id uint) (*entities.Organization, error) {
+	var org entities.Organization
+	if err := r.db.WithContext(ctx).First(&org, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &org, nil
+}
+
+// ListByMember returns the organizations the user belongs to, ordered by name.
+func (r *organizationRepository) ListByMember(ctx context.Context, userID string) ([]entities.Organization, error) {
+	var orgs []entities.Organization
+	err := r.db.WithContext(ctx).
+		Joins("JOIN organization_members ON organization_members.organization_id = organizations.id AND organization_members.deleted_at IS NULL").
+		Where("organization_members.user_id = ?", userID).
+		Order("organizations.name, organizations.id").
+		Find(&orgs).Error
+	if err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// UpdateName renames an organization.
+// It returns ErrNotFound if the organization does not exist.
+func (r *organizationRepository) UpdateName(ctx context.Context, id uint, name string) error {
+	res := r.db.WithContext(ctx).Model(&entities.Organization{}).Where("id = ?", id).Update("name", name)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes an organization for good, together with its projects, teams, every membership, its roles,
+// their assignments, its policies and its invites, so its slug can be used again. It returns ErrNotFound if the organization does not exist.
+func (r *organizationRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		projects := tx.Model(&entities.Project{}).Select("id").Where("organization_id = ?", id)
+		if err := tx.Unscoped().Where("project_id IN (?)", projects).Delete(&entities.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("organization_id = ?", id).Delete(&entities.Project{}).Error; err != nil {
+			return err
+		}
+		teams := tx.Model(&entities.Team{}).Select("id").Where("organization_id = ?", id)
+		if err := tx.Unscoped().Where("team_id IN (?)", teams).Delete(&entities.TeamMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("organization_id = ?", id).Delete(&entities.Team{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("organization_id = ?", id).Delete(&entities.OrganizationMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("organization_id = ?", id).Delete(&entities.RoleAssignment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("organization_id = ?", id).Delete(&entities.Role{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("organization_id = ?", id).Delete(&entities.Policy{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("organization_id = ?", id).Delete(&entities.Invite{}).Error; err != nil {
+			return err
+		}
+		res := tx.Unscoped().Delete(&entities.Organization{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"gorm.io/gorm"
+)
+
+type PolicyRepository interface {
+	Create(ctx context.Context, policy *entities.Policy) error
+	ListByOrganization(ctx context.Context, orgID uint) ([]entities.Policy, error)
+	Update(ctx context.Context, policy *entities.Policy) error
+	Delete(ctx context.Context, orgID uint, name string) error
+}
+
+// policyRepository implements PolicyRepository for the authorization policies of organizations.
+type policyRepository struct {
+	db *gorm.DB
+}
+
+func NewPolicyRepository(db *gorm.DB) PolicyRepository {
+	return &policyRepository{db: db}
+}
+
+// Create stores a new policy.
+// It returns ErrDuplicate if the organization already has a policy with the same name.
+func (r *policyRepository) Create(ctx context.Context, policy *entities.Policy) error {
+	if err := r.db.WithContext(ctx).Create(policy).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+// ListByOrganization returns the policies of an organization, ordered by name.
+func (r *policyRepository) ListByOrganization(ctx context.Context, orgID uint) ([]entities.Policy, error) {
+	var policies []entities.Policy
+	if err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).Order("name").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// Update replaces every field of a policy, identified by its organization and name.
+// It returns ErrNotFound if the organization has no such policy.
+func (r *policyRepository) Update(ctx context.Context, policy *entities.Policy) error {
+	res := r.db.WithContext(ctx).Model(&entities.Policy{}).
+		Where("organization_id = ? AND name = ?", policy.OrganizationID, policy.Name).
+		Updates(map[string]any{
+			"description": policy.Description,
+			"effect":      policy.Effect,
+			"actions":     policy.Actions,
+			"project_id":  policy.ProjectID,
+			"environment": policy.Environment,
+			"conditions":  policy.Conditions,
+			"timezone":    policy.Timezone,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a policy for good, so the name can be used again.
+// It returns ErrNotFound if the organization has no such policy.
+func (r *policyRepository) Delete(ctx context.Context, orgID uint, name string) error {
+	res := r.db.WithContext(ctx).Unscoped().Where("organization_id = ? AND name = ?", orgID, name).Delete(&entities.Policy{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
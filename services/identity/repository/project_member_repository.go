@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"gorm.io/gorm"
+)
+
+type ProjectMemberRepository interface {
+	Create(ctx context.Context, member *entities.ProjectMember) error
+	Get(ctx context.Context, projectID uint, userID string) (*entities.ProjectMember, error)
+	ListByProject(ctx context.Context, projectID uint) ([]entities.ProjectMember, error)
+	Remove(ctx context.Context, projectID uint, userID string) error
+}
+
+// projectMemberRepository implements ProjectMemberRepository for project memberships.
+type projectMemberRepository struct {
+	db *gorm.DB
+}
+
+func NewProjectMemberRepository(db *gorm.DB) ProjectMemberRepository {
+	return &projectMemberRepository{db: db}
+}
+
+// Create adds a user to a project.
+// It returns ErrDuplicate if the user already belongs to it.
+func (r *projectMemberRepository) Create(ctx context.Context, member *entities.ProjectMember) error {
+	if err := r.db.WithContext(ctx).Create(member).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+// Get retrieves the user's membership of a project.
+// It returns ErrNotFound if the user is not a member.
+func (r *projectMemberRepository) Get(ctx context.Context, projectID uint, userID string) (*entities.ProjectMember, error) {
+	var member entities.ProjectMember
+	err := r.db.WithContext(ctx).Where("project_id = ? AND user_id = ?", projectID, userID).First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// ListByProject returns the members of a project in the order they joined.
+func (r *projectMemberRepository) ListByProject(ctx context.Context, projectID uint) ([]entities.ProjectMember, error) {
+	var members []entities.ProjectMember
+	if err := r.db.WithContext(ctx).Where("project_id = ?", projectID).Order("id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// Remove takes the user out of a project.
+// It returns ErrNotFound if the user is not a member.
+func (r *projectMemberRepository) Remove(ctx context.Context, projectID uint, userID string) error {
+	res := r.db.WithContext(ctx).Unscoped().
+		Where("project_id = ? AND user_id = ?", projectID, userID).
+		Delete(&entities.ProjectMember{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"gorm.io/gorm"
+)
+
+type ProjectRepository interface {
+	Create(ctx context.Context, project *entities.Project) error
+	GetByID(ctx context.Context, id uint) (*entities.Project, error)
+	ListByOrganization(ctx context.Context, orgID uint) ([]entities.Project, error)
+	ListByMember(ctx context.Context, orgID uint, userID string) ([]entities.Project, error)
+	UpdateName(ctx context.Context, id uint, name string) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// projectRepository implements ProjectRepository for the projects of organizations.
+type projectRepository struct {
+	db *gorm.DB
+}
+
+func NewProjectRepository(db *gorm.DB) ProjectRepository {
+	return &projectRepository{db: db}
+}
+
+// Create stores a new project.
+// It returns ErrDuplicate if the organization already has a project with the same slug.
+func (r *projectRepository) Create(ctx context.Context, project *entities.Project) error {
+	if err := r.db.WithContext(ctx).Create(project).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+// GetByID retrieves a project by primary key.
+// It returns ErrNotFound if the project does not exist.
+func (r *projectRepository) GetByID(ctx context.Context, id uint) (*entities.Project, error) {
+	var project entities.Project
+	if err := r.db.WithContext(ctx).First(&project, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &project, nil
+}
+
+// ListByOrganization returns every project of an organization, ordered by name.
+func (r *projectRepository) ListByOrganization(ctx context.Context, orgID uint) ([]entities.Project, error) {
+	var projects []entities.Project
+	if err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).Order("name, id").Find(&projects).Error; err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// ListByMember returns the projects of an organization the user belongs to, ordered by name.
+func (r *projectRepository) ListByMember(ctx context.Context, orgID uint, userID string) ([]entities.Project, error) {
+	var projects []entities.Project
+	err := r.db.WithContext(ctx).
+		Joins("JOIN project_members ON project_members.project_id = projects.id AND project_members.deleted_at IS NULL").
+		Where("projects.organization_id = ? AND project_members.user_id = ?", orgID, userID).
+		Order("projects.name, projects.id").
+		Find(&projects).Error
+	if err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// UpdateName renames a project.
+// It returns ErrNotFound if the project does not exist.
+func (r *projectRepository) UpdateName(ctx context.Context, id uint, name string) error {
+	res := r.db.WithContext(ctx).Model(&entities.Project{}).Where("id = ?", id).Update("name", name)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a project with its memberships, role assignments and policies for good, so its slug can be used again.
+// It returns ErrNotFound if the project does not exist.
+func (r *projectRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("project_id = ?", id).Delete(&entities.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("project_id = ?", id).Delete(&entities.RoleAssignment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("project_id = ?", id).Delete(&entities.Policy{}).Error; err != nil {
+			return err
+		}
+		res := tx.Unscoped().Delete(&entities.Project{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"gorm.io/gorm"
+)
+
+type RoleAssignmentRepository interface {
+	Create(ctx context.Context, assignment *entities.RoleAssignment) error
+	GetByID(ctx context.Context, id uint) (*entities.RoleAssignment, error)
+	List(ctx context.Context, orgID uint, userID string) ([]entities.RoleAssignment, error)
+	ListByTeams(ctx context.Context, teamIDs []uint) ([]entities.RoleAssignment, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+// roleAssignmentRepository implements RoleAssignmentRepository for the roles granted to organization members and teams.
+type roleAssignmentRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleAssignmentRepository(db *gorm.DB) RoleAssignmentRepository {
+	return &roleAssignmentRepository{db: db}
+}
+
+// Create stores a new role assignment.
+// It returns ErrDuplicate if the user or team already holds the role on the same scope.
+func (r *roleAssignmentRepository) Create(ctx context.Context, assignment *entities.RoleAssignment) error {
+	if err := r.db.WithContext(ctx).Create(assignment).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+// GetByID retrieves a role assignment by primary key.
+// It returns ErrNotFound if the assignment does not exist.
+func (r *roleAssignmentRepository) GetByID(ctx context.Context, id uint) (*entities.RoleAssignment, error) {
+	var assignment entities.RoleAssignment
+	if err := r.db.WithContext(ctx).First(&assignment, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// List returns the role assignments of an organization in the order they were made,
+// only those of userID if it is not empty.
+func (r *roleAssignmentRepository) List(ctx context.Context, orgID uint, userID string) ([]entities.RoleAssignment, error) {
+	query := r.db.WithContext(ctx).Where("organization_id = ?", orgID)
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	var assignments []entities.RoleAssignment
+	if err := query.Order("id").Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// ListByTeams returns the role assignments of the given teams in the order they were made.
+func (r *roleAssignmentRepository) ListByTeams(ctx context.Context, teamIDs []uint) ([]entities.RoleAssignment, error) {
+	if len(teamIDs) == 0 {
+		return nil, nil
+	}
+	var assignments []entities.RoleAssignment
+	if err := r.db.WithContext(ctx).Where("team_id IN ?", teamIDs).Order("id").Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// Delete removes a role assignment for good.
+// It returns ErrNotFound if the assignment does not exist.
+func (r *roleAssignmentRepository) Delete(ctx context.Context, id uint) error {
+	res := r.db.WithContext(ctx).Unscoped().Delete(&entities.RoleAssignment{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
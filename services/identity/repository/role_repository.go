@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	Create(ctx context.Context, role *entities.Role) error
+	Get(ctx context.Context, orgID uint, name string) (*entities.Role, error)
+	ListByOrganization(ctx context.Context, orgID uint) ([]entities.Role, error)
+	Update(ctx context.Context, role *entities.Role) error
+	Delete(ctx context.Context, orgID uint, name string) error
+}
+
+// roleRepository implements RoleRepository for the custom roles of organizations.
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// Create stores a new custom role.
+// It returns ErrDuplicate if the organization already has a role with the same name.
+func (r *roleRepository) Create(ctx context.Context, role *entities.Role) error {
+	if err := r.db.WithContext(ctx).Create(role).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+// Get retrieves a custom role of an organization by name.
+// It returns ErrNotFound if the organization has no such role.
+func (r *roleRepository) Get(ctx context.Context, orgID uint, name string) (*entities.Role, error) {
+	var role entities.Role
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND name = ?", orgID, name).First(&role).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// ListByOrganization returns the custom roles of an organization, ordered by name.
+func (r *roleRepository) ListByOrganization(ctx context.Context, orgID uint) ([]entities.Role, error) {
+	var roles []entities.Role
+	if err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// Update changes the description and permissions of a custom role, identified by its organization and name.
+// It returns ErrNotFound if the organization has no such role.
+func (r *roleRepository) Update(ctx context.Context, role *entities.Role) error {
+	res := r.db.WithContext(ctx).Model(&entities.Role{}).
+		Where("organization_id = ? AND name = ?", role.OrganizationID, role.Name).
+		Updates(map[string]any{"description": role.Description, "permissions": role.Permissions})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a custom role and its assignments for good, so the name can be used again.
+// It returns ErrNotFound if the organization has no such role.
+func (r *roleRepository) Delete(ctx context.Context, orgID uint, name string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Unscoped().Where("organization_id = ? AND name = ?", orgID, name).Delete(&entities.Role{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return tx.Unscoped().Where("organization_id = ? AND role_name = ?", orgID, name).Delete(&entities.RoleAssignment{}).Error
+	})
+}
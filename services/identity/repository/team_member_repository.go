@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"gorm.io/gorm"
+)
+
+type TeamMemberRepository interface {
+	Create(ctx context.Context, member *entities.TeamMember) error
+	ListByTeam(ctx context.Context, teamID uint) ([]entities.TeamMember, error)
+	Remove(ctx context.Context, teamID uint, userID string) error
+}
+
+// teamMemberRepository implements TeamMemberRepository for team memberships.
+type teamMemberRepository struct {
+	db *gorm.DB
+}
+
+func NewTeamMemberRepository(db *gorm.DB) TeamMemberRepository {
+	return &teamMemberRepository{db: db}
+}
+
+// Create adds a user to a team.
+// It returns ErrDuplicate if the user already belongs to it.
+func (r *teamMemberRepository) Create(ctx context.Context, member *entities.TeamMember) error {
+	if err := r.db.WithContext(ctx).Create(member).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+// ListByTeam returns the members of a team in the order they joined.
+func (r *teamMemberRepository) ListByTeam(ctx context.Context, teamID uint) ([]entities.TeamMember, error) {
+	var members []entities.TeamMember
+	if err := r.db.WithContext(ctx).Where("team_id = ?", teamID).Order("id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// Remove takes the user out of a team.
+// It returns ErrNotFound if the user is not a member.
+func (r *teamMemberRepository) Remove(ctx context.Context, teamID uint, userID string) error {
+	res := r.db.WithContext(ctx).Unscoped().
+		Where("team_id = ? AND user_id = ?", teamID, userID).
+		Delete(&entities.TeamMember{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
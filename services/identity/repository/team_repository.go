@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/himakhaitan/noreboothq/services/identity/entities"
+	"gorm.io/gorm"
+)
+
+type TeamRepository interface {
+	Create(ctx context.Context, team *entities.Team) error
+	GetByID(ctx context.Context, id uint) (*entities.Team, error)
+	ListByOrganization(ctx context.Context, orgID uint) ([]entities.Team, error)
+	ListByMember(ctx context.Context, orgID uint, userID string) ([]entities.Team, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+// teamRepository implements TeamRepository for the teams of organizations.
+type teamRepository struct {
+	db *gorm.DB
+}
+
+func NewTeamRepository(db *gorm.DB) TeamRepository {
+	return &teamRepository{db: db}
+}
+
+// Create stores a new team.
+// It returns ErrDuplicate if its organization already has a team with the slug.
+func (r *teamRepository) Create(ctx context.Context, team *entities.Team) error {
+	if err := r.db.WithContext(ctx).Create(team).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+// GetByID retrieves a team by primary key.
+// It returns ErrNotFound if the team does not exist.
+func (r *teamRepository) GetByID(ctx context.Context, id uint) (*entities.Team, error) {
+	var team entities.Team
+	if err := r.db.WithContext(ctx).First(&team, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &team, nil
+}
+
+// ListByOrganization returns the teams of an organization ordered by slug.
+func (r *teamRepository) ListByOrganization(ctx context.Context, orgID uint) ([]entities.Team, error) {
+	var teams []entities.Team
+	if err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).Order("slug").Find(&teams).Error; err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+// ListByMember returns the teams of an organization the user belongs to, ordered by slug.
+func (r *teamRepository) ListByMember(ctx context.Context, orgID uint, userID string) ([]entities.Team, error) {
+	var teams []entities.Team
+	err := r.db.WithContext(ctx).
+		Joins("JOIN team_members ON team_members.team_id = teams.id").
+		Where("teams.organization_id = ? AND team_members.user_id = ?", orgID, userID).
+		Order("teams.slug").
+		Find(&teams).Error
+	if err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+// Delete removes a team with its memberships and role assignments for good, so its slug can be used again.
+// It returns ErrNotFound if the team does not exist.
+func (r *teamRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("team_id = ?", id).Delete(&entities.TeamMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("team_id = ?", id).Delete(&entities.RoleAssignment{}).Error; err != nil {
+			return err
+		}
+		res := tx.Unscoped().Delete(&entities.Team{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	identitypb "github.com/himakhaitan/noreboothq/proto/identity"
+	"github.com/himakhaitan/noreboothq/services/identity/controllers"
+	"github.com/himakhaitan/noreboothq/services/identity/handlers"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+type GRPCServer struct {
+	grpcServer *grpc.Server
+	logger     *zap.Logger
+	port       int
+	ctrl       *controllers.IdentityController
+}
+
+// NewGRPCServer creates the gRPC server for the identity service.
+// Server options such as the authn interceptors are passed through to grpc.NewServer.
+func NewGRPCServer(logger *zap.Logger, ctrl *controllers.IdentityController, port int, opts ...grpc.ServerOption) *GRPCServer {
+	return &GRPCServer{
+		grpcServer: grpc.NewServer(opts...),
+		logger:     logger,
+		port:       port,
+		ctrl:       ctrl,
+	}
+}
+
+// Start initializes the gRPC server and listens for incoming connections.
+// It handles graceful shutdown on context cancellation.
+// If the context is canceled, it attempts to stop the server gracefully within a timeout period.
+func (s *GRPCServer) Start(ctx context.Context) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return err
+	}
+
+	identityHandler := handlers.NewIdentityHandler(s.ctrl, s.logger)
+
+	identitypb.RegisterIdentityServiceServer(s.grpcServer, identityHandler)
+
+	serveErrCh := make(chan error, 1)
+
+	go func() {
+		s.logger.Info("gRPC server started", zap.Int("port", s.port))
+		serveErrCh <- s.grpcServer.Serve(lis)
+	}()
+
+	select {
+	case <-ctx.Done():
+		s.logger.Info("Context canceled, shutting down gRPC server", zap.String("reason", ctx.Err().Error()))
+
+		doneCh := make(chan struct{})
+		go func() {
+			s.grpcServer.GracefulStop()
+			close(doneCh)
+		}()
+
+		select {
+		case <-doneCh:
+			s.logger.Info("gRPC server stopped gracefully")
+		case <-time.After(10 * time.Second):
+			s.logger.Warn("Timeout reached, forcing gRPC server stop")
+			s.grpcServer.Stop()
+		}
+
+		return nil
+
+	case err := <-serveErrCh:
+		return err
+	}
+}
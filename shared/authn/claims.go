@@ -0,0 +1,56 @@
+package authn
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims are the JWT claims carried by access tokens issued by the auth service.
+// They are shared so every service decodes tokens the same way.
+type Claims struct {
+	jwt.RegisteredClaims
+	// Scope is the space-separated list of scopes granted to the token, as in RFC 8693.
+	Scope string `json:"scope,omitempty"`
+	// OrgID is the organization the token is acting within, if any.
+	OrgID string `json:"org_id,omitempty"`
+	// SessionID identifies the login session the token belongs to, if any.
+	SessionID string `json:"sid,omitempty"`
+	// Actor is set on impersonation tokens and names who is acting as the subject.
+	Actor *Actor `json:"act,omitempty"`
+}
+
+// Actor is the act claim of RFC 8693 section 4.1: the party acting on behalf of the token's subject.
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// Scopes returns the granted scopes as a slice.
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasScope reports whether the token was granted the given scope.
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
+
+// Principal converts verified claims into the identity attached to a request context.
+func (c *Claims) Principal() *Principal {
+	p := &Principal{
+		Subject:   c.Subject,
+		OrgID:     c.OrgID,
+		Scopes:    c.Scopes(),
+		TokenID:   c.ID,
+		SessionID: c.SessionID,
+		Audience:  c.Audience,
+	}
+	if c.ExpiresAt != nil {
+		p.ExpiresAt = c.ExpiresAt.Time
+	}
+	if c.Actor != nil {
+		p.Actor = c.Actor.Subject
+	}
+	return p
+}
@@ -0,0 +1,77 @@
+package authn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	authpb "github.com/himakhaitan/noreboothq/proto/auth"
+	"google.golang.org/grpc/metadata"
+)
+
+// tokenRefreshMargin is how long before it expires a cached service token is replaced, so a
+// token is never sent that expires in flight.
+const tokenRefreshMargin = 30 * time.Second
+
+// TokenSource supplies the access token a service presents when it calls another service as itself.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// ClientCredentialsSource is a TokenSource for a service account. It obtains tokens from the auth
+// service's Token RPC with the client_credentials grant and reuses each until shortly before it expires.
+type ClientCredentialsSource struct {
+	client       authpb.AuthServiceClient
+	clientID     string
+	clientSecret string
+	scopes       []string
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewClientCredentialsSource creates a TokenSource for the service account with the given
+// credentials. Tokens carry scopes, or all of the account's scopes when none are given.
+func NewClientCredentialsSource(client authpb.AuthServiceClient, clientID string, clientSecret string, scopes ...string) *ClientCredentialsSource {
+	return &ClientCredentialsSource{
+		client:       client,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+	}
+}
+
+// Token implements TokenSource.
+func (s *ClientCredentialsSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Until(s.expiresAt) > tokenRefreshMargin {
+		return s.token, nil
+	}
+	resp, err := s.client.Token(ctx, &authpb.TokenRequest{
+		GrantType:    "client_credentials",
+		ClientId:     s.clientID,
+		ClientSecret: s.clientSecret,
+		Scope:        strings.Join(s.scopes, " "),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to obtain service token: %w", err)
+	}
+	s.token = resp.AccessToken
+	s.expiresAt = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	return s.token, nil
+}
+
+// WithServiceToken returns a copy of ctx whose outgoing calls carry a token from source as their
+// bearer token.
+func WithServiceToken(ctx context.Context, source TokenSource) (context.Context, error) {
+	token, err := source.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token), nil
+}
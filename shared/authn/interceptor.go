@@ -0,0 +1,152 @@
+package authn
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ErrInvalidToken is wrapped by verifiers when a token is malformed, expired, revoked
+// or otherwise must not be trusted. Any other verifier error is treated as an outage.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Verifier checks a raw bearer token and returns the principal it represents.
+type Verifier interface {
+	Verify(ctx context.Context, rawToken string) (*Principal, error)
+}
+
+// Interceptor authenticates incoming gRPC calls by verifying the bearer token in the
+// "authorization" metadata entry and attaching the resulting Principal to the context.
+type Interceptor struct {
+	verifier Verifier
+	public   map[string]struct{}
+	optional map[string]struct{}
+	audience string
+	logger   *zap.Logger
+}
+
+// NewInterceptor creates an Interceptor using verifier. Calls to publicMethods, given as full
+// gRPC method names such as "/auth.AuthService/Login", are let through without a token.
+func NewInterceptor(verifier Verifier, publicMethods ...string) *Interceptor {
+	public := make(map[string]struct{}, len(publicMethods))
+	for _, method := range publicMethods {
+		public[method] = struct{}{}
+	}
+	return &Interceptor{verifier: verifier, public: public, optional: map[string]struct{}{}, logger: zap.NewNop()}
+}
+
+// WithOptionalAuthentication lets calls to methods through without a token, like public ones, but
+// verifies the token of calls that send one and attaches its principal, so handlers can tell
+// signed-in callers apart. An invalid token is still rejected.
+func (i *Interceptor) WithOptionalAuthentication(methods ...string) *Interceptor {
+	for _, method := range methods {
+		i.optional[method] = struct{}{}
+	}
+	return i
+}
+
+// WithLogger makes the interceptor log every call made with an impersonation token,
+// naming both the impersonated subject and the actor behind it.
+func (i *Interceptor) WithLogger(logger *zap.Logger) *Interceptor {
+	i.logger = logger
+	return i
+}
+
+// WithAudience makes the interceptor reject tokens whose aud claim names other services only,
+// such as down-scoped tokens obtained through ExchangeToken for another service. audience is this
+// service's name as used in aud claims. Tokens without an aud claim are still accepted.
+func (i *Interceptor) WithAudience(audience string) *Interceptor {
+	i.audience = audience
+	return i
+}
+
+// Unary returns the interceptor for unary RPCs.
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := i.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Stream returns the interceptor for streaming RPCs.
+func (i *Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := i.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticate verifies the caller unless the method is public and returns the context to continue with.
+func (i *Interceptor) authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	if _, ok := i.public[fullMethod]; ok {
+		return ctx, nil
+	}
+
+	rawToken, ok := BearerToken(ctx)
+	if !ok {
+		if _, optional := i.optional[fullMethod]; optional {
+			return ctx, nil
+		}
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	principal, err := i.verifier.Verify(ctx, rawToken)
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+		}
+		return nil, status.Error(codes.Unavailable, "unable to verify bearer token")
+	}
+	if i.audience != "" && !principal.AllowsAudience(i.audience) {
+		return nil, status.Error(codes.Unauthenticated, "bearer token is not intended for this service")
+	}
+
+	if principal.Impersonated() {
+		i.logger.Info("Impersonated request",
+			zap.String("method", fullMethod),
+			zap.String("sub", principal.Subject),
+			zap.String("act", principal.Actor),
+			zap.String("jti", principal.TokenID),
+		)
+	}
+
+	return ContextWithPrincipal(ctx, principal), nil
+}
+
+// BearerToken extracts the token from the "authorization: Bearer <token>" metadata entry.
+func BearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	for _, value := range md.Get("authorization") {
+		scheme, token, found := strings.Cut(value, " ")
+		if found && strings.EqualFold(scheme, "bearer") && strings.TrimSpace(token) != "" {
+			return strings.TrimSpace(token), true
+		}
+	}
+	return "", false
+}
+
+// authenticatedStream overrides the stream context so handlers see the principal.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
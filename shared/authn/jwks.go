@@ -0,0 +1,148 @@
+package authn
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// jwksRefreshInterval bounds how long fetched keys are trusted before refetching.
+	jwksRefreshInterval = 5 * time.Minute
+	// jwksMinRefetch rate-limits refetches triggered by tokens with unknown key IDs.
+	jwksMinRefetch = 30 * time.Second
+)
+
+// JWKSVerifier verifies access tokens locally using the public keys the auth service
+// publishes at its JWKS endpoint. It never needs a signing secret, but it cannot see
+// revocations; use IntrospectionVerifier where immediate revocation matters.
+type JWKSVerifier struct {
+	url    string
+	issuer string
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// NewJWKSVerifier creates a verifier that fetches keys from jwksURL
+// (e.g. "http://auth:8081/.well-known/jwks.json") and accepts tokens from issuer.
+func NewJWKSVerifier(jwksURL string, issuer string) *JWKSVerifier {
+	return &JWKSVerifier{
+		url:    jwksURL,
+		issuer: issuer,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Verify implements Verifier.
+func (v *JWKSVerifier) Verify(ctx context.Context, rawToken string) (*Principal, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(rawToken, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return claims.Principal(), nil
+}
+
+// key returns the public key with the given ID, refetching the key set when it is stale
+// or does not contain the key yet (e.g. right after a rotation).
+func (v *JWKSVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, ok := v.keys[kid]
+	age := time.Since(v.fetchedAt)
+	if ok && age < jwksRefreshInterval {
+		return key, nil
+	}
+	if !ok && v.keys != nil && age < jwksMinRefetch {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	keys, err := v.fetch(ctx)
+	if err != nil {
+		if ok {
+			// Keep verifying with the stale key rather than failing closed on a fetch hiccup.
+			return key, nil
+		}
+		return nil, err
+	}
+	v.keys = keys
+	v.fetchedAt = time.Now()
+
+	if key, ok = keys[kid]; !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+// fetch downloads and decodes the JWKS document.
+func (v *JWKSVerifier) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var doc struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		switch {
+		case k.Kty == "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case k.Kty == "OKP" && k.Crv == "Ed25519":
+			x, err := base64.RawURLEncoding.DecodeString(k.X)
+			if err != nil || len(x) != ed25519.PublicKeySize {
+				continue
+			}
+			keys[k.Kid] = ed25519.PublicKey(x)
+		}
+	}
+	return keys, nil
+}
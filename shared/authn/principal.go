@@ -0,0 +1,88 @@
+package authn
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Principal is the authenticated identity behind a request.
+type Principal struct {
+	Subject   string // user ID, or "sa:<id>" for a service account
+	OrgID     string // active organization, if any
+	Scopes    []string
+	TokenID   string   // jti of the access token
+	SessionID string   // login session the token belongs to; empty for API keys and service accounts
+	Actor     string   // subject acting as Subject, set for impersonation tokens
+	Audience  []string // services the token is restricted to; empty means any
+	ExpiresAt time.Time
+}
+
+// AllowsAudience reports whether the token may be presented to the service named audience.
+// Tokens without an aud claim are valid for every service.
+func (p *Principal) AllowsAudience(audience string) bool {
+	return len(p.Audience) == 0 || slices.Contains(p.Audience, audience)
+}
+
+// Impersonated reports whether someone other than the subject is behind the request.
+func (p *Principal) Impersonated() bool {
+	return p.Actor != ""
+}
+
+// HasScope reports whether the principal was granted the given scope.
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal returns a copy of ctx carrying the principal.
+func ContextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal attached by the interceptor.
+// The boolean is false for public methods or calls that bypassed the interceptor.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// RequirePrincipal returns the caller's principal, or an Unauthenticated status error if there is none.
+func RequirePrincipal(ctx context.Context) (*Principal, error) {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	return p, nil
+}
+
+// RequireScope returns the caller's principal if it holds scope,
+// otherwise an Unauthenticated or PermissionDenied status error.
+func RequireScope(ctx context.Context, scope string) (*Principal, error) {
+	p, err := RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !p.HasScope(scope) {
+		return nil, status.Errorf(codes.PermissionDenied, "missing required scope %q", scope)
+	}
+	return p, nil
+}
+
+// RequireOrg returns the caller's principal if their token acts within an organization, otherwise an
+// Unauthenticated or FailedPrecondition status error. Services keeping per-tenant data use it and scope
+// every read and write by the principal's OrgID.
+func RequireOrg(ctx context.Context) (*Principal, error) {
+	p, err := RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if p.OrgID == "" {
+		return nil, status.Error(codes.FailedPrecondition, "no active organization; switch to one with SwitchOrganization")
+	}
+	return p, nil
+}
@@ -0,0 +1,270 @@
+package authz
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/himakhaitan/noreboothq/shared/authn"
+	"github.com/himakhaitan/noreboothq/shared/cache"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Grant is a role held on a resource, and on everything nested in it.
+type Grant struct {
+	Role        string
+	Scope       Resource
+	Permissions []string
+	// Team is the slug of the team the role was granted to, empty if it was granted to the subject.
+	Team string
+}
+
+// Allows reports whether the grant permits action on resource.
+func (g Grant) Allows(action string, resource Resource) bool {
+	if !g.Scope.Contains(resource) {
+		return false
+	}
+	return slices.ContainsFunc(g.Permissions, func(permission string) bool {
+		return MatchesPermission(permission, action)
+	})
+}
+
+// Snapshot is everything needed to decide a subject's requests within one organization.
+type Snapshot struct {
+	Grants   []Grant
+	Policies []Policy
+	// OrgRole is the subject's role in the organization: "owner", "member", or empty for non-members.
+	OrgRole string
+	Groups  []string
+	// Teams are the slugs of the subject's teams in the organization.
+	Teams []string
+}
+
+// Request is an authorization check: may Subject perform Action on Resource?
+type Request struct {
+	Subject  string
+	Action   string
+	Resource Resource
+	// ResourceAttributes describe the resource beyond its place in the hierarchy, e.g. "key".
+	// Policies see them as resource.<name>.
+	ResourceAttributes map[string]string
+	// Context describes the request itself, e.g. "ip". Policies see it as request.<name>.
+	Context map[string]string
+	// Time is when the request is made; the current time if zero.
+	Time time.Time
+}
+
+// Decision is the outcome of an authorization check. Policy names the policy that decided it, if any.
+// Otherwise, when allowed, Role, Scope and Team name the grant that allowed it, the most specific one
+// if several do; a denial without a policy means no role allows the action.
+type Decision struct {
+	Allowed bool
+	Role    string
+	Scope   Resource
+	Team    string
+	Policy  string
+}
+
+// Evaluate decides a request against a subject's snapshot. Policies that deny the request win,
+// then grants that allow it, then policies that allow it; anything else is denied. Policies are
+// considered in the snapshot's order. It is a pure function; Evaluator adds loading and caching around it.
+func Evaluate(snapshot *Snapshot, req Request) Decision {
+	if req.Time.IsZero() {
+		req.Time = time.Now()
+	}
+	attrs := attributes{snapshot: snapshot, req: req}
+
+	for _, policy := range snapshot.Policies {
+		if policy.Effect == EffectDeny && policy.applies(req, attrs) {
+			return Decision{Policy: policy.Name}
+		}
+	}
+
+	var decision Decision
+	for _, grant := range snapshot.Grants {
+		if !grant.Allows(req.Action, req.Resource) {
+			continue
+		}
+		if !decision.Allowed || grant.Scope.Depth() > decision.Scope.Depth() {
+			decision = Decision{Allowed: true, Role: grant.Role, Scope: grant.Scope, Team: grant.Team}
+		}
+	}
+	if decision.Allowed {
+		return decision
+	}
+
+	for _, policy := range snapshot.Policies {
+		if policy.Effect == EffectAllow && policy.applies(req, attrs) {
+			return Decision{Allowed: true, Policy: policy.Name}
+		}
+	}
+	return Decision{}
+}
+
+// attributes resolves the attributes policy conditions refer to.
+type attributes struct {
+	snapshot *Snapshot
+	req      Request
+}
+
+// values returns the values of a "principal.", "resource." or "request." attribute, reading times in location.
+func (a attributes) values(attribute string, location *time.Location) []string {
+	kind, name, _ := strings.Cut(attribute, ".")
+	switch kind {
+	case "principal":
+		switch name {
+		case "id":
+			return []string{a.req.Subject}
+		case "org_role":
+			return nonEmpty(a.snapshot.OrgRole)
+		case "groups":
+			return a.snapshot.Groups
+		case "teams":
+			return a.snapshot.Teams
+		case "roles":
+			var roles []string
+			for _, grant := range a.snapshot.Grants {
+				if grant.Scope.Contains(a.req.Resource) && !slices.Contains(roles, grant.Role) {
+					roles = append(roles, grant.Role)
+				}
+			}
+			return roles
+		}
+	case "resource":
+		switch name {
+		case "organization_id":
+			return []string{strconv.FormatUint(uint64(a.req.Resource.OrganizationID), 10)}
+		case "project_id":
+			if a.req.Resource.ProjectID == 0 {
+				return nil
+			}
+			return []string{strconv.FormatUint(uint64(a.req.Resource.ProjectID), 10)}
+		case "environment":
+			return nonEmpty(a.req.Resource.Environment)
+		}
+		value, ok := a.req.ResourceAttributes[name]
+		if ok {
+			return []string{value}
+		}
+	case "request":
+		switch name {
+		case "hour":
+			return []string{strconv.Itoa(a.req.Time.In(location).Hour())}
+		case "weekday":
+			return []string{strings.ToLower(a.req.Time.In(location).Weekday().String())}
+		}
+		value, ok := a.req.Context[name]
+		if ok {
+			return []string{value}
+		}
+	}
+	return nil
+}
+
+// nonEmpty returns value as the only value of an attribute, or no values if it is empty.
+func nonEmpty(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+// Source loads a subject's snapshot within an organization.
+type Source interface {
+	Snapshot(ctx context.Context, subject string, orgID uint) (*Snapshot, error)
+}
+
+// SourceFunc adapts a function to Source.
+type SourceFunc func(ctx context.Context, subject string, orgID uint) (*Snapshot, error)
+
+// Snapshot implements Source.
+func (f SourceFunc) Snapshot(ctx context.Context, subject string, orgID uint) (*Snapshot, error) {
+	return f(ctx, subject, orgID)
+}
+
+// snapshotKey identifies a cached snapshot.
+type snapshotKey struct {
+	subject string
+	orgID   uint
+}
+
+// Evaluator answers authorization checks from a Source. It caches every subject's snapshot, so
+// repeated checks are evaluated in-process without loading anything; decisions themselves are not
+// cached, since they depend on the time and attributes of each request. It is safe for concurrent use.
+type Evaluator struct {
+	source    Source
+	snapshots *cache.Cache[snapshotKey, *Snapshot]
+
+	// mu orders Invalidate against storing snapshots: one loaded before an invalidation, which may
+	// predate the change, is not stored after it.
+	mu         sync.RWMutex
+	generation uint64
+}
+
+// NewEvaluator creates an Evaluator that loads snapshots from source and caches up to cacheSize of
+// them for cacheTTL. A non-positive cacheTTL disables the cache.
+func NewEvaluator(source Source, cacheTTL time.Duration, cacheSize int) *Evaluator {
+	return &Evaluator{
+		source:    source,
+		snapshots: cache.New[snapshotKey, *Snapshot](cacheTTL, cacheSize),
+	}
+}
+
+// Authorize decides a request.
+func (e *Evaluator) Authorize(ctx context.Context, req Request) (Decision, error) {
+	key := snapshotKey{subject: req.Subject, orgID: req.Resource.OrganizationID}
+	snapshot, ok := e.snapshots.Get(key)
+	if !ok {
+		e.mu.RLock()
+		generation := e.generation
+		e.mu.RUnlock()
+
+		var err error
+		snapshot, err = e.source.Snapshot(ctx, req.Subject, req.Resource.OrganizationID)
+		if err != nil {
+			return Decision{}, err
+		}
+
+		e.mu.RLock()
+		if e.generation == generation {
+			e.snapshots.Set(key, snapshot)
+		}
+		e.mu.RUnlock()
+	}
+	return Evaluate(snapshot, req), nil
+}
+
+// Require returns the caller's principal if they may make the request, whose Subject is set to
+// theirs, otherwise an Unauthenticated or PermissionDenied status error naming the deciding policy.
+// Failing to load the caller's snapshot is reported as Unavailable.
+func (e *Evaluator) Require(ctx context.Context, req Request) (*authn.Principal, error) {
+	principal, err := authn.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.Subject = principal.Subject
+	decision, err := e.Authorize(ctx, req)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, "authorization is unavailable")
+	}
+	if !decision.Allowed {
+		if decision.Policy != "" {
+			return nil, status.Errorf(codes.PermissionDenied, "%s on %s is denied by policy %q", req.Action, req.Resource, decision.Policy)
+		}
+		return nil, status.Errorf(codes.PermissionDenied, "%s is not allowed on %s", req.Action, req.Resource)
+	}
+	return principal, nil
+}
+
+// Invalidate drops every cached snapshot. Call it whenever roles, role assignments, policies or
+// memberships change. Evaluators in other processes learn of changes through a ChangeWatcher.
+func (e *Evaluator) Invalidate() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.generation++
+	e.snapshots.Purge()
+}
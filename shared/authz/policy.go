@@ -0,0 +1,198 @@
+package authz
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Policy effects.
+const (
+	// EffectAllow permits the action when the policy applies, even without a role granting it.
+	EffectAllow = "allow"
+	// EffectDeny forbids the action when the policy applies, whatever the caller's roles.
+	EffectDeny = "deny"
+)
+
+// Condition operators. Attributes can have several values, such as a principal's groups; a missing
+// attribute has none.
+const (
+	// OperatorIn holds if any value of the attribute is one of the condition's values.
+	OperatorIn = "in"
+	// OperatorNotIn holds if no value of the attribute is one of the condition's values.
+	OperatorNotIn = "not_in"
+	// OperatorStartsWith holds if any value of the attribute starts with one of the condition's values.
+	OperatorStartsWith = "starts_with"
+	// OperatorNotStartsWith holds if no value of the attribute starts with one of the condition's values.
+	OperatorNotStartsWith = "not_starts_with"
+	// OperatorBetween holds if the attribute is a whole number within the condition's two values, inclusive.
+	OperatorBetween = "between"
+	// OperatorNotBetween holds unless OperatorBetween does.
+	OperatorNotBetween = "not_between"
+)
+
+// attributePattern matches condition attributes such as "principal.groups" or "resource.key".
+var attributePattern = regexp.MustCompile(`^(principal|resource|request)\.[a-z][a-z0-9_]*$`)
+
+// ErrInvalidPolicy is returned by Policy.Validate.
+var ErrInvalidPolicy = errors.New("invalid policy")
+
+// Condition is a test on one attribute of the request, such as
+// {Attribute: "principal.groups", Operator: OperatorIn, Values: []string{"on-call"}}.
+//
+// Attributes are named after what they describe:
+//   - principal.id, principal.org_role ("owner" or "member"), principal.groups, principal.teams (team
+//     slugs), and principal.roles, the roles the principal holds on the resource, themselves or through a team
+//   - resource.organization_id, resource.project_id, resource.environment, and any attribute the
+//     caller passes with the request, such as resource.key
+//   - request.hour (0 to 23) and request.weekday ("monday" to "sunday") in the policy's time zone,
+//     and any attribute the caller passes with the request, such as request.ip
+type Condition struct {
+	Attribute string   `json:"attribute"`
+	Operator  string   `json:"operator"`
+	Values    []string `json:"values"`
+}
+
+// Policy conditionally allows or denies actions on a resource and everything nested in it.
+// It applies when the action matches one of Actions, which are permissions such as "config.*",
+// and every condition holds.
+type Policy struct {
+	Name        string
+	Description string
+	Effect      string
+	Actions     []string
+	Scope       Resource
+	Conditions  []Condition
+	// Timezone is the IANA time zone request.hour and request.weekday are read in; UTC if empty.
+	Timezone string
+}
+
+// Validate checks that the policy can be evaluated, returning an error wrapping ErrInvalidPolicy otherwise.
+func (p Policy) Validate() error {
+	if p.Effect != EffectAllow && p.Effect != EffectDeny {
+		return fmt.Errorf(`%w: effect must be "allow" or "deny"`, ErrInvalidPolicy)
+	}
+	if len(p.Actions) == 0 {
+		return fmt.Errorf("%w: at least one action is required", ErrInvalidPolicy)
+	}
+	if _, err := loadLocation(p.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown time zone %q", ErrInvalidPolicy, p.Timezone)
+	}
+	for _, condition := range p.Conditions {
+		if !attributePattern.MatchString(condition.Attribute) {
+			return fmt.Errorf("%w: unknown attribute %q", ErrInvalidPolicy, condition.Attribute)
+		}
+		switch condition.Operator {
+		case OperatorIn, OperatorNotIn, OperatorStartsWith, OperatorNotStartsWith:
+			if len(condition.Values) == 0 {
+				return fmt.Errorf("%w: %s %s needs at least one value", ErrInvalidPolicy, condition.Attribute, condition.Operator)
+			}
+		case OperatorBetween, OperatorNotBetween:
+			if _, _, ok := bounds(condition.Values); !ok {
+				return fmt.Errorf("%w: %s %s needs two whole numbers", ErrInvalidPolicy, condition.Attribute, condition.Operator)
+			}
+		default:
+			return fmt.Errorf("%w: unknown operator %q", ErrInvalidPolicy, condition.Operator)
+		}
+	}
+	return nil
+}
+
+// applies reports whether the policy covers the request, given the attributes it is evaluated against.
+func (p Policy) applies(req Request, attrs attributes) bool {
+	if !p.Scope.Contains(req.Resource) {
+		return false
+	}
+	if !slices.ContainsFunc(p.Actions, func(permission string) bool {
+		return MatchesPermission(permission, req.Action)
+	}) {
+		return false
+	}
+
+	location, err := loadLocation(p.Timezone)
+	if err != nil {
+		// Policies are validated when stored; one that cannot be read never applies.
+		return false
+	}
+	for _, condition := range p.Conditions {
+		if !condition.holds(attrs.values(condition.Attribute, location)) {
+			return false
+		}
+	}
+	return true
+}
+
+// holds evaluates the condition against the values of its attribute.
+func (c Condition) holds(values []string) bool {
+	switch c.Operator {
+	case OperatorIn:
+		return slices.ContainsFunc(values, func(v string) bool { return slices.Contains(c.Values, v) })
+	case OperatorNotIn:
+		return !slices.ContainsFunc(values, func(v string) bool { return slices.Contains(c.Values, v) })
+	case OperatorStartsWith:
+		return slices.ContainsFunc(values, c.hasPrefix)
+	case OperatorNotStartsWith:
+		return !slices.ContainsFunc(values, c.hasPrefix)
+	case OperatorBetween:
+		return c.between(values)
+	case OperatorNotBetween:
+		return !c.between(values)
+	default:
+		return false
+	}
+}
+
+// hasPrefix reports whether v starts with one of the condition's values.
+func (c Condition) hasPrefix(v string) bool {
+	return slices.ContainsFunc(c.Values, func(prefix string) bool { return strings.HasPrefix(v, prefix) })
+}
+
+// between reports whether the attribute has a single whole-number value within the condition's bounds.
+func (c Condition) between(values []string) bool {
+	low, high, ok := bounds(c.Values)
+	if !ok || len(values) != 1 {
+		return false
+	}
+	n, err := strconv.Atoi(values[0])
+	return err == nil && low <= n && n <= high
+}
+
+// bounds parses the two values of a between condition.
+func bounds(values []string) (low int, high int, ok bool) {
+	if len(values) != 2 {
+		return 0, 0, false
+	}
+	low, err := strconv.Atoi(values[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	high, err = strconv.Atoi(values[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	return low, high, true
+}
+
+// locations caches time zones by name, since time.LoadLocation reads the zone database every call.
+var locations sync.Map
+
+// loadLocation returns the named time zone, UTC for an empty name.
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if location, ok := locations.Load(name); ok {
+		return location.(*time.Location), nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, location)
+	return location, nil
+}
@@ -0,0 +1,82 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	identitypb "github.com/himakhaitan/noreboothq/proto/identity"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+	"google.golang.org/grpc/metadata"
+)
+
+// ErrNoBearerToken is returned by IdentitySource when the context carries no incoming bearer token to forward.
+var ErrNoBearerToken = errors.New("no bearer token to forward to the identity service")
+
+// IdentitySource is a Source backed by the identity service's ListGrants RPC. It forwards the
+// bearer token of the incoming call, so it only loads the caller's own snapshot and the token must
+// be one the identity service accepts. Evaluate requests with the incoming context.
+type IdentitySource struct {
+	client identitypb.IdentityServiceClient
+}
+
+// NewIdentitySource creates a Source that asks the identity service through client.
+func NewIdentitySource(client identitypb.IdentityServiceClient) *IdentitySource {
+	return &IdentitySource{client: client}
+}
+
+// Snapshot implements Source.
+func (s *IdentitySource) Snapshot(ctx context.Context, subject string, orgID uint) (*Snapshot, error) {
+	if principal, ok := authn.PrincipalFromContext(ctx); !ok || principal.Subject != subject {
+		return nil, fmt.Errorf("identity snapshots can only be loaded for the caller, not %q", subject)
+	}
+	token, ok := authn.BearerToken(ctx)
+	if !ok {
+		return nil, ErrNoBearerToken
+	}
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	resp, err := s.client.ListGrants(ctx, &identitypb.ListGrantsRequest{OrganizationId: uint64(orgID)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list grants: %w", err)
+	}
+
+	snapshot := &Snapshot{OrgRole: resp.OrgRole, Groups: resp.Groups, Teams: resp.Teams}
+	for _, grant := range resp.Grants {
+		snapshot.Grants = append(snapshot.Grants, Grant{
+			Role:        grant.Role,
+			Scope:       fromResourceProto(grant.Scope),
+			Permissions: grant.Permissions,
+			Team:        grant.Team,
+		})
+	}
+	for _, policy := range resp.Policies {
+		conditions := make([]Condition, 0, len(policy.Conditions))
+		for _, condition := range policy.Conditions {
+			conditions = append(conditions, Condition{
+				Attribute: condition.GetAttribute(),
+				Operator:  condition.GetOperator(),
+				Values:    condition.GetValues(),
+			})
+		}
+		snapshot.Policies = append(snapshot.Policies, Policy{
+			Name:        policy.Name,
+			Description: policy.Description,
+			Effect:      policy.Effect,
+			Actions:     policy.Actions,
+			Scope:       fromResourceProto(policy.Scope),
+			Conditions:  conditions,
+			Timezone:    policy.Timezone,
+		})
+	}
+	return snapshot, nil
+}
+
+// fromResourceProto converts a resource from its wire form.
+func fromResourceProto(resource *identitypb.Resource) Resource {
+	return Resource{
+		OrganizationID: uint(resource.GetOrganizationId()),
+		ProjectID:      uint(resource.GetProjectId()),
+		Environment:    resource.GetEnvironment(),
+	}
+}
@@ -0,0 +1,66 @@
+package authz
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Resource is what an action is performed on. Resources nest: an organization contains projects and
+// a project contains environments. Unset fields end the path, so {OrganizationID: 7} is the organization
+// itself and {OrganizationID: 7, ProjectID: 12, Environment: "staging"} one environment of a project.
+type Resource struct {
+	OrganizationID uint
+	ProjectID      uint
+	Environment    string
+}
+
+// Contains reports whether other is r or nested inside it, so that a role granted on r applies to other.
+func (r Resource) Contains(other Resource) bool {
+	if r.OrganizationID != other.OrganizationID {
+		return false
+	}
+	if r.ProjectID != 0 && r.ProjectID != other.ProjectID {
+		return false
+	}
+	return r.Environment == "" || (r.ProjectID != 0 && r.Environment == other.Environment)
+}
+
+// Depth is how far down the hierarchy r is: 0 for an organization, 1 for a project, 2 for an environment.
+func (r Resource) Depth() int {
+	switch {
+	case r.Environment != "":
+		return 2
+	case r.ProjectID != 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Valid reports whether r names an organization, and an environment only within a project.
+func (r Resource) Valid() bool {
+	return r.OrganizationID != 0 && (r.Environment == "" || r.ProjectID != 0)
+}
+
+// String formats r as a path, e.g. "orgs/7/projects/12/envs/staging".
+func (r Resource) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "orgs/%d", r.OrganizationID)
+	if r.ProjectID != 0 {
+		fmt.Fprintf(&b, "/projects/%d", r.ProjectID)
+	}
+	if r.Environment != "" {
+		fmt.Fprintf(&b, "/envs/%s", r.Environment)
+	}
+	return b.String()
+}
+
+// MatchesPermission reports whether permission grants action. Permissions are dotted strings such as
+// "config.write"; "config.*" grants every action starting with "config." and "*" grants everything.
+func MatchesPermission(permission string, action string) bool {
+	if permission == "*" || permission == action {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(permission, "*")
+	return ok && strings.HasSuffix(prefix, ".") && strings.HasPrefix(action, prefix)
+}
@@ -0,0 +1,80 @@
+package authz
+
+import (
+	"context"
+	"time"
+
+	identitypb "github.com/himakhaitan/noreboothq/proto/identity"
+	"github.com/himakhaitan/noreboothq/shared/authn"
+	"go.uber.org/zap"
+)
+
+// watchRetryDelay is how long a ChangeWatcher waits before watching again after its stream broke.
+const watchRetryDelay = 5 * time.Second
+
+// ChangeWatcher keeps an Evaluator in step with the identity service. It follows the identity
+// service's WatchAuthorizationChanges stream and drops the evaluator's cached snapshots on every
+// change, so changes to roles, policies, memberships and teams apply in every process as soon as
+// they are made rather than when cached snapshots expire.
+type ChangeWatcher struct {
+	evaluator   *Evaluator
+	client      identitypb.IdentityServiceClient
+	credentials authn.TokenSource
+	logger      *zap.Logger
+}
+
+// NewChangeWatcher creates a watcher that invalidates evaluator on every change the identity
+// service reports through client. The stream is authenticated with tokens from credentials, which
+// must carry the "identity.watch" scope.
+func NewChangeWatcher(evaluator *Evaluator, client identitypb.IdentityServiceClient, credentials authn.TokenSource) *ChangeWatcher {
+	return &ChangeWatcher{
+		evaluator:   evaluator,
+		client:      client,
+		credentials: credentials,
+		logger:      zap.NewNop(),
+	}
+}
+
+// WithLogger logs every time the stream breaks.
+func (w *ChangeWatcher) WithLogger(logger *zap.Logger) *ChangeWatcher {
+	w.logger = logger
+	return w
+}
+
+// Run watches until ctx is done, reconnecting after watchRetryDelay whenever the stream breaks.
+// Changes made while it is down are missed, so the cache is dropped when the stream breaks and, as
+// the identity service starts every stream with a change of all organizations, once it is back.
+func (w *ChangeWatcher) Run(ctx context.Context) {
+	for {
+		err := w.watch(ctx)
+		w.evaluator.Invalidate()
+		if ctx.Err() != nil {
+			return
+		}
+		w.logger.Warn("Lost the authorization change stream", zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchRetryDelay):
+		}
+	}
+}
+
+// watch follows one stream until it breaks.
+func (w *ChangeWatcher) watch(ctx context.Context) error {
+	ctx, err := authn.WithServiceToken(ctx, w.credentials)
+	if err != nil {
+		return err
+	}
+	stream, err := w.client.WatchAuthorizationChanges(ctx, &identitypb.WatchAuthorizationChangesRequest{})
+	if err != nil {
+		return err
+	}
+	for {
+		if _, err := stream.Recv(); err != nil {
+			return err
+		}
+		w.evaluator.Invalidate()
+	}
+}
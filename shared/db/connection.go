@@ -36,8 +36,10 @@ func NewConnection(config Config, zapLogger *zap.Logger, models ...interface{})
 	// Create custom GORM logger using Zap
 	gormLogger := NewZapLogger(zapLogger)
 
+	// TranslateError maps driver-specific errors (e.g. unique violations) to gorm's generic errors
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: gormLogger,
+		Logger:         gormLogger,
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// FileMailer writes messages to a file or stdout instead of delivering them.
+// It is meant for local development and tests, where links in emails (e.g. password resets)
+// can be copied from the output.
+type FileMailer struct {
+	from string
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewFileMailer creates a FileMailer that appends to path, or writes to stdout if path is empty.
+func NewFileMailer(from string, path string) (*FileMailer, error) {
+	if path == "" {
+		return &FileMailer{from: from, out: os.Stdout}, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mail file %s: %w", path, err)
+	}
+	return &FileMailer{from: from, out: f}, nil
+}
+
+// Send writes msg followed by a separator line.
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := fmt.Fprintf(m.out, "%s\r\n----\r\n", format(m.from, msg)); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+	return nil
+}
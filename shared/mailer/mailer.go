@@ -0,0 +1,67 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Mailer sends email messages. Services depend on this interface so the transport
+// can be swapped between SMTP in production and a local file or stdout in development.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// validate rejects messages whose headers could smuggle in extra header lines.
+func (msg Message) validate() error {
+	if msg.To == "" {
+		return fmt.Errorf("mail recipient cannot be empty")
+	}
+	if strings.ContainsAny(msg.To+msg.Subject, "\r\n") {
+		return fmt.Errorf("mail headers cannot contain line breaks")
+	}
+	return nil
+}
+
+// Supported values for Config.Driver.
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+)
+
+type Config struct {
+	Driver string // "smtp" or "file"
+	From   string
+
+	// SMTP settings, used by the "smtp" driver.
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string // empty disables authentication
+	SMTPPassword string
+
+	// FilePath is where the "file" driver appends messages. Empty writes to stdout.
+	FilePath string
+}
+
+// New creates the Mailer selected by config.Driver.
+func New(config Config) (Mailer, error) {
+	if config.From == "" {
+		return nil, fmt.Errorf("mail from address cannot be empty")
+	}
+
+	switch config.Driver {
+	case DriverSMTP:
+		return NewSMTPMailer(config)
+	case DriverFile:
+		return NewFileMailer(config.From, config.FilePath)
+	default:
+		return nil, fmt.Errorf("unsupported mail driver %q", config.Driver)
+	}
+}
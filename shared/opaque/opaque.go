@@ -0,0 +1,40 @@
+package opaque
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+)
+
+// New generates a random, URL-safe opaque token such as a refresh token or an invite token.
+// It returns the raw token to hand to the client and the hash to persist.
+func New() (raw string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate opaque token: %w", err)
+	}
+	raw = base64.RawURLEncoding.EncodeToString(b)
+	return raw, Hash(raw), nil
+}
+
+// Hash returns the hex-encoded SHA-256 of an opaque token.
+// Only this hash is stored so a database leak does not expose usable tokens.
+func Hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// Link builds a URL emailed to a user, carrying the raw token as the "token" query parameter.
+func Link(baseURL string, rawToken string) (string, error) {
+	link, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+	query := link.Query()
+	query.Set("token", rawToken)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}